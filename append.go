@@ -0,0 +1,168 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// AppendFile appends the rows of e in the given format to the file path
+// which is created like in WriteFileAuto if it does not exist or is empty.
+// Appending is supported for CSV (.csv), TSV (.tsv) and JSON Lines (.ndjson
+// and .jsonl) files; SQLiteDumper provides appending for SQLite databases.
+//
+// The set of column names in the existing file must match the columns of
+// e: The header line of a CSV or TSV file is not repeated and the columns
+// are written in the order of the existing header. For JSON Lines files
+// the keys of the first object are checked.
+func AppendFile(path string, e *Extractor, format Format) error {
+	name, compression := formatForPath(path)
+	if compression != "" {
+		return fmt.Errorf("export: cannot append to compressed file %s", path)
+	}
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) || (err == nil && fi.Size() == 0) {
+		return WriteFileAuto(path, e, format)
+	} else if err != nil {
+		return err
+	}
+
+	var factory DumperFactory
+	var names []string
+	switch name {
+	case "csv", "tsv":
+		comma := ','
+		if name == "tsv" {
+			comma = '\t'
+		}
+		if names, err = readHeader(path, comma); err != nil {
+			return err
+		}
+		factory = func(w io.Writer) Dumper {
+			return DelimitedDumper{Writer: w, Comma: comma, OmitHeader: true}
+		}
+	case "ndjson":
+		if names, err = readJSONKeys(path); err != nil {
+			return err
+		}
+		factory = func(w io.Writer) Dumper { return JSONLinesDumper{Writer: w} }
+	default:
+		return fmt.Errorf("export: cannot append to %s files", name)
+	}
+	if e, err = e.prepare(); err != nil {
+		return err
+	}
+	if e, err = reorderColumns(e, names); err != nil {
+		return fmt.Errorf("export: cannot append to %s: %v", path, err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(file)
+	// Terminate an unterminated last line.
+	last := make([]byte, 1)
+	if _, err := file.ReadAt(last, fi.Size()-1); err == nil && last[0] != '\n' {
+		buf.WriteString("\n")
+	}
+	err = factory(buf).Dump(e, format)
+	if ferr := buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// readHeader returns the fields of the first record in the delimited file.
+func readHeader(path string, comma rune) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := csv.NewReader(file)
+	r.Comma = comma
+	return r.Read()
+}
+
+// readJSONKeys returns the keys of the first object in the JSON Lines file
+// in the order of their appearance.
+func readJSONKeys(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	line, err := bufio.NewReader(file).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	dec := json.NewDecoder(strings.NewReader(line))
+	malformed := fmt.Errorf("export: malformed first line in %s", path)
+	if t, err := dec.Token(); err != nil || t != json.Delim('{') {
+		return nil, malformed
+	}
+	var keys []string
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, malformed
+		}
+		keys = append(keys, t.(string))
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, malformed
+		}
+	}
+	return keys, nil
+}
+
+// reorderColumns returns an Extractor with the columns of e in the order of
+// names or an error if the column names of e differ from names.
+func reorderColumns(e *Extractor, names []string) (*Extractor, error) {
+	index := map[string]int{}
+	for i, field := range e.Columns {
+		index[field.Name] = i
+	}
+	var missing []string
+	columns := make([]Column, 0, len(names))
+	for _, name := range names {
+		i, ok := index[name]
+		if !ok {
+			missing = append(missing, name)
+			continue
+		}
+		columns = append(columns, e.Columns[i])
+		delete(index, name)
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing columns "+strings.Join(missing, ", "))
+	}
+	if len(index) > 0 {
+		var extra []string
+		for name := range index {
+			extra = append(extra, name)
+		}
+		sort.Strings(extra)
+		problems = append(problems, "extra columns "+strings.Join(extra, ", "))
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	r := *e
+	r.Columns = columns
+	return &r, nil
+}
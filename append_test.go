@@ -0,0 +1,56 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAppendFile(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dir := t.TempDir()
+
+	for _, tc := range []struct{ name, existing, want string }{
+		{"new.csv", "", "S,I\nHello,12\nWorld,14\n"},
+		{"a.csv", "I,S\n1,x", "I,S\n1,x\n12,Hello\n14,World\n"},
+		{"b.tsv", "S\tI\nx\t1\n", "S\tI\nx\t1\nHello\t12\nWorld\t14\n"},
+		{"c.ndjson", `{"I":1,"S":"x"}` + "\n", `{"I":1,"S":"x"}` + "\n" +
+			`{"I":12,"S":"Hello"}` + "\n" + `{"I":14,"S":"World"}` + "\n"},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if tc.existing != "" {
+			os.WriteFile(path, []byte(tc.existing), 0644)
+		}
+		if err := AppendFile(path, extractor, DefaultFormat); err != nil {
+			t.Errorf("%s: Unexpected error: %s", tc.name, err)
+			continue
+		}
+		if got, _ := os.ReadFile(path); string(got) != tc.want {
+			t.Errorf("%s: Got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	for _, tc := range []struct{ name, existing, err string }{
+		{"d.csv", "S,X\n", "missing columns X; extra columns I"},
+		{"e.ndjson", "[1]\n", "malformed"},
+		{"f.md", "| S |\n", "cannot append"},
+		{"g.csv.gz", "", "compressed"},
+	} {
+		path := filepath.Join(dir, tc.name)
+		if tc.existing != "" {
+			os.WriteFile(path, []byte(tc.existing), 0644)
+		}
+		err := AppendFile(path, extractor, DefaultFormat)
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("%s: Got error %v, want %s", tc.name, err, tc.err)
+		}
+	}
+}
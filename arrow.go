@@ -0,0 +1,88 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ArrowArray is the part of the typed arrays of the Apache Arrow Go
+// implementation (like *array.Int64, *array.Float64, *array.Boolean or
+// *array.String) needed to access their values. This allows to bind
+// Extractors to Arrow record batches without depending on the Arrow
+// packages and without copying the data.
+type ArrowArray[T any] interface {
+	Len() int
+	IsNull(i int) bool
+	Value(i int) T
+}
+
+// ArrowColumn returns a column named name which reads its values directly
+// from arr. Null values in arr are NA. The type of the column is derived
+// from T which must be one of the types usable as final element of a
+// column specifier.
+func ArrowColumn[T any](name string, arr ArrowArray[T]) (Column, error) {
+	rt := reflect.TypeOf((*T)(nil)).Elem()
+	typ := superType(rt)
+	if typ == NA {
+		return Column{}, fmt.Errorf("export: cannot use type %s", rt)
+	}
+	unsigned := false
+	switch rt.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		unsigned = true
+	}
+	return Column{
+		Name: name,
+		typ:  typ,
+		value: func(i int) interface{} {
+			if arr.IsNull(i) {
+				return nil
+			}
+			switch v := interface{}(arr.Value(i)).(type) {
+			case bool, int64, uint64, float64, string:
+				return v
+			}
+			return canonical(reflect.ValueOf(arr.Value(i)), typ, unsigned)
+		},
+	}, nil
+}
+
+// ArrowTimeColumn returns a Time column named name for an Arrow timestamp
+// array arr (like *array.Timestamp) whose values count units since the
+// Unix epoch; unit must be one of time.Second, time.Millisecond,
+// time.Microsecond or time.Nanosecond, the units of Arrow timestamps.
+// The times are presented in UTC.
+func ArrowTimeColumn[T ~int64](name string, arr ArrowArray[T], unit time.Duration) (Column, error) {
+	switch unit {
+	case time.Second, time.Millisecond, time.Microsecond, time.Nanosecond:
+	default:
+		return Column{}, fmt.Errorf("export: bad Arrow timestamp unit %s", unit)
+	}
+	per := int64(time.Second / unit)
+	return Column{
+		Name: name,
+		typ:  Time,
+		value: func(i int) interface{} {
+			if arr.IsNull(i) {
+				return nil
+			}
+			v := int64(arr.Value(i))
+			return time.Unix(v/per, (v%per)*int64(unit)).UTC()
+		},
+	}, nil
+}
+
+// NewArrowExtractor returns an Extractor for a record batch with numRows
+// rows whose columns have been constructed by ArrowColumn or
+// ArrowTimeColumn. The returned Extractor cannot be rebound.
+func NewArrowExtractor(numRows int, cols ...Column) *Extractor {
+	return &Extractor{
+		N:       numRows,
+		Columns: cols,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+// fakeArray mimics the typed arrays of the Arrow Go implementation.
+type fakeArray[T any] struct {
+	values []T
+	valid  []bool
+}
+
+func (a fakeArray[T]) Len() int          { return len(a.values) }
+func (a fakeArray[T]) IsNull(i int) bool { return !a.valid[i] }
+func (a fakeArray[T]) Value(i int) T     { return a.values[i] }
+
+type timestamp int64
+
+func TestArrowExtractor(t *testing.T) {
+	valid := []bool{true, false, true}
+	name, err := ArrowColumn("Name", fakeArray[string]{[]string{"a", "", "c"}, valid})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	price, err := ArrowColumn("Price", fakeArray[float32]{[]float32{1.5, 0, 2.25}, valid})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	count, err := ArrowColumn("Count", fakeArray[uint16]{[]uint16{7, 8, 9}, []bool{true, true, true}})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	when, err := ArrowTimeColumn("When", fakeArray[timestamp]{[]timestamp{1500, -500, 0}, valid},
+		time.Millisecond)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	extractor := NewArrowExtractor(3, name, price, count, when)
+	format := DefaultFormat
+	format.TimeFmt = time.RFC3339Nano
+	format.TimeLoc = nil
+	want := `Name,Price,Count,When
+a,1.5,7,1970-01-01T00:00:01.5Z
+,,8,
+c,2.25,9,1970-01-01T00:00:00Z
+`
+	buf := &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(buf)}.Dump(extractor, format)
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	if _, err := ArrowColumn("Bad", fakeArray[[]int]{}); err == nil {
+		t.Errorf("Missing error for unusable type")
+	}
+	for _, unit := range []time.Duration{time.Minute, 3 * time.Millisecond, 0} {
+		if _, err := ArrowTimeColumn("Bad", fakeArray[timestamp]{}, unit); err == nil {
+			t.Errorf("Missing error for unit %s", unit)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// The binary format written by BinaryDumper stores all values in little
+// endian byte order, the columns one after the other:
+//
+//	magic    "EXPBIN\x00\x01"
+//	header   uint32 number of columns, uint64 number of rows
+//	         per column: uint32 name length, name, uint8 type
+//	columns  per column: NA bitmap, values
+//
+// Bools are stored as one byte, Ints and Durations as int64, Uints as
+// uint64, Floats as float64, Complex as two float64, Times as int64 Unix
+// seconds followed by int64 nanoseconds (the location is lost) and Strings
+// and Bytes as n+1 uint64 offsets followed by the data. Each section is padded to a
+// multiple of 8 bytes.
+const binaryMagic = "EXPBIN\x00\x01"
+
+// BinaryDumper dumps the values in a compact binary format suitable to be
+// memory-mapped by OpenBinary.
+type BinaryDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is ignored as the values are stored in binary.
+func (d BinaryDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	w := &binaryWriter{w: bufio.NewWriter(d.Writer)}
+	w.write([]byte(binaryMagic))
+	w.uint32(uint32(len(e.Columns)))
+	w.uint64(uint64(e.N))
+	for _, field := range e.Columns {
+		w.uint32(uint32(len(field.Name)))
+		w.write([]byte(field.Name))
+		w.write([]byte{byte(field.Type())})
+	}
+	w.pad()
+
+	// The values of a column are extracted once for the bitmap, the
+	// offsets and the values.
+	values := make([]interface{}, e.N)
+	for _, field := range e.Columns {
+		bitmap := make([]byte, (e.N+7)/8)
+		for r := range values {
+			values[r] = field.value(r)
+			if values[r] == nil {
+				bitmap[r/8] |= 1 << uint(r%8)
+			}
+		}
+		w.write(bitmap)
+		w.pad()
+
+		if field.Type() == String || field.Type() == Bytes {
+			offset := uint64(0)
+			w.uint64(offset)
+			for _, v := range values {
+				switch v := v.(type) {
+				case string:
+					offset += uint64(len(v))
+				case []byte:
+					offset += uint64(len(v))
+				}
+				w.uint64(offset)
+			}
+		}
+		for _, v := range values {
+			w.value(field.Type(), v)
+		}
+		w.pad()
+	}
+	e.progress(0, e.N)
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// binaryWriter keeps track of the number of bytes written and the first
+// error encountered.
+type binaryWriter struct {
+	w   *bufio.Writer
+	n   int
+	err error
+	buf [8]byte
+}
+
+func (w *binaryWriter) write(p []byte) {
+	if w.err != nil {
+		return
+	}
+	var n int
+	n, w.err = w.w.Write(p)
+	w.n += n
+}
+
+func (w *binaryWriter) uint32(u uint32) {
+	binary.LittleEndian.PutUint32(w.buf[:4], u)
+	w.write(w.buf[:4])
+}
+
+func (w *binaryWriter) uint64(u uint64) {
+	binary.LittleEndian.PutUint64(w.buf[:], u)
+	w.write(w.buf[:])
+}
+
+// pad writes zeros until a multiple of 8 bytes is written.
+func (w *binaryWriter) pad() {
+	if r := w.n % 8; r != 0 {
+		w.write(make([]byte, 8-r))
+	}
+}
+
+// value writes v which is of type typ. NA values are written as zero.
+func (w *binaryWriter) value(typ Type, v interface{}) {
+	switch typ {
+	case Bool:
+		b, _ := v.(bool)
+		if b {
+			w.write([]byte{1})
+		} else {
+			w.write([]byte{0})
+		}
+	case Int:
+		i, _ := v.(int64)
+		w.uint64(uint64(i))
+	case Float:
+		f, _ := v.(float64)
+		w.uint64(math.Float64bits(f))
+	case Complex:
+		c, _ := v.(complex128)
+		w.uint64(math.Float64bits(real(c)))
+		w.uint64(math.Float64bits(imag(c)))
+	case String:
+		s, _ := v.(string)
+		w.write([]byte(s))
+	case Time:
+		var sec, nsec int64
+		if t, ok := v.(time.Time); ok {
+			sec, nsec = t.Unix(), int64(t.Nanosecond())
+		}
+		w.uint64(uint64(sec))
+		w.uint64(uint64(nsec))
+	case Duration:
+		d, _ := v.(time.Duration)
+		w.uint64(uint64(d))
+	case Bytes:
+		b, _ := v.([]byte)
+		w.write(b)
+	case Uint:
+		u, _ := v.(uint64)
+		w.uint64(u)
+	}
+}
+
+var errBadBinary = errors.New("export: malformed binary data")
+
+// NewBinaryExtractor returns an Extractor for data which must have been
+// produced by a BinaryDumper. The values are decoded from data on access;
+// data must not be modified while the returned Extractor is in use.
+// Times are presented in UTC. The Extractor cannot be rebound.
+func NewBinaryExtractor(data []byte) (*Extractor, error) {
+	le := binary.LittleEndian
+	if len(data) < len(binaryMagic) || string(data[:len(binaryMagic)]) != binaryMagic {
+		return nil, errBadBinary
+	}
+	pos := len(binaryMagic)
+	// take returns the next size bytes of data or nil if data is too short.
+	take := func(size uint64) []byte {
+		if size > uint64(len(data)-pos) {
+			return nil
+		}
+		b := data[pos : pos+int(size)]
+		pos += int(size)
+		return b
+	}
+	pad := func() bool { return take(uint64(-pos&7)) != nil }
+	head := take(12)
+	if head == nil {
+		return nil, errBadBinary
+	}
+	ncols, n := uint64(le.Uint32(head)), le.Uint64(head[4:])
+	// Each row needs at least a bit in the NA bitmap of every column.
+	if ncols > uint64(len(data)) || (ncols > 0 && n > 8*uint64(len(data))) {
+		return nil, errBadBinary
+	}
+
+	ex := &Extractor{N: int(n)}
+	for c := uint64(0); c < ncols; c++ {
+		l := take(4)
+		if l == nil {
+			return nil, errBadBinary
+		}
+		name := take(uint64(le.Uint32(l)))
+		typ := take(1)
+		if name == nil || typ == nil || Type(typ[0]) > Uint {
+			return nil, errBadBinary
+		}
+		ex.Columns = append(ex.Columns, Column{Name: string(name), typ: Type(typ[0])})
+	}
+	if !pad() {
+		return nil, errBadBinary
+	}
+
+	for c := range ex.Columns {
+		typ := ex.Columns[c].typ
+		bitmap := take((n + 7) / 8)
+		if bitmap == nil || !pad() {
+			return nil, errBadBinary
+		}
+		var offsets []byte
+		size := map[Type]uint64{Bool: 1, Int: 8, Float: 8, Complex: 16,
+			Time: 16, Duration: 8, Uint: 8}[typ] * n
+		if typ == String || typ == Bytes {
+			if offsets = take(8 * (n + 1)); offsets == nil {
+				return nil, errBadBinary
+			}
+			size = le.Uint64(offsets[8*n:])
+			// The values of row i are values[offsets[i]:offsets[i+1]].
+			for i := uint64(0); i < n; i++ {
+				if le.Uint64(offsets[8*i:]) > le.Uint64(offsets[8*i+8:]) {
+					return nil, errBadBinary
+				}
+			}
+		}
+		values := take(size)
+		if values == nil || !pad() {
+			return nil, errBadBinary
+		}
+		ex.Columns[c].value = binaryColumn(typ, bitmap, offsets, values)
+	}
+	return ex, nil
+}
+
+// binaryColumn returns the value function for a column of type typ stored
+// in the given sections.
+func binaryColumn(typ Type, bitmap, offsets, values []byte) func(i int) interface{} {
+	le := binary.LittleEndian
+	return func(i int) interface{} {
+		if bitmap[i/8]&(1<<uint(i%8)) != 0 {
+			return nil
+		}
+		switch typ {
+		case Bool:
+			return values[i] != 0
+		case Int:
+			return int64(le.Uint64(values[8*i:]))
+		case Float:
+			return math.Float64frombits(le.Uint64(values[8*i:]))
+		case Complex:
+			return complex(math.Float64frombits(le.Uint64(values[16*i:])),
+				math.Float64frombits(le.Uint64(values[16*i+8:])))
+		case String:
+			return string(values[le.Uint64(offsets[8*i:]):le.Uint64(offsets[8*i+8:])])
+		case Time:
+			sec, nsec := int64(le.Uint64(values[16*i:])), int64(le.Uint64(values[16*i+8:]))
+			return time.Unix(sec, nsec).UTC()
+		case Duration:
+			return time.Duration(le.Uint64(values[8*i:]))
+		case Bytes:
+			start, end := le.Uint64(offsets[8*i:]), le.Uint64(offsets[8*i+8:])
+			return values[start:end:end]
+		case Uint:
+			return le.Uint64(values[8*i:])
+		}
+		return nil
+	}
+}
+
+// BinaryFile is a file written by a BinaryDumper mapped into memory.
+type BinaryFile struct {
+	data []byte
+}
+
+// OpenBinary maps the file written by a BinaryDumper into memory.
+// The file must be closed once the data is no longer needed.
+func OpenBinary(path string) (*BinaryFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if fi.Size() < int64(len(binaryMagic)) {
+		return nil, errBadBinary
+	}
+	data, err := mmap(f, int(fi.Size()))
+	if err != nil {
+		return nil, fmt.Errorf("export: cannot map %s: %v", path, err)
+	}
+	return &BinaryFile{data: data}, nil
+}
+
+// Extractor returns an Extractor bound to the content of f.
+// The Extractor must not be used after f has been closed.
+func (f *BinaryFile) Extractor() (*Extractor, error) {
+	return NewBinaryExtractor(f.data)
+}
+
+// Close unmaps the file.
+func (f *BinaryFile) Close() error {
+	data := f.data
+	f.data = nil
+	return munmap(data)
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBinaryRoundtrip(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "table.bin")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := (BinaryDumper{Writer: file}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	bf, err := OpenBinary(path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer bf.Close()
+	mapped, err := bf.Extractor()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := PreciseFormat
+	format.TimeLoc = time.UTC
+	want, got := &bytes.Buffer{}, &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(want)}.Dump(extractor, format)
+	CSVDumper{Writer: csv.NewWriter(got)}.Dump(mapped, format)
+	if got.String() != want.String() {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestBinaryMalformed(t *testing.T) {
+	buf := &bytes.Buffer{}
+	extractor, _ := NewExtractor(table, "S", "F")
+	BinaryDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	data := buf.Bytes()
+	for _, n := range []int{0, 5, 20, len(data) - 9} {
+		if _, err := NewBinaryExtractor(data[:n]); err == nil {
+			t.Errorf("Missing error for data truncated to %d bytes", n)
+		}
+	}
+
+	// The offsets of column S start at byte 40, after the header and the
+	// NA bitmap.
+	for _, offset := range []uint64{1 << 40, 1<<64 - 1} {
+		bad := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint64(bad[48:], offset)
+		if _, err := NewBinaryExtractor(bad); err != errBadBinary {
+			t.Errorf("Got %v for offset %d", err, offset)
+		}
+	}
+	bad := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(bad[12:], 1<<62)
+	if _, err := NewBinaryExtractor(bad); err != errBadBinary {
+		t.Errorf("Got %v for too many rows", err)
+	}
+}
+
+func TestBinaryDumperCalls(t *testing.T) {
+	v := 1.5
+	data := []measurement{{Value: &v}, {}, {Value: &v}}
+	extractor, err := NewExtractor(data, "Check()", "Sensor")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	materializeCalls = 0
+	if err := (BinaryDumper{Writer: io.Discard}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if materializeCalls != len(data) {
+		t.Errorf("Check called %d times for %d rows", materializeCalls, len(data))
+	}
+}
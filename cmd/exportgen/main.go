@@ -0,0 +1,395 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Exportgen generates reflection-free Extractors for slices of a type.
+//
+// Usage:
+//
+//	exportgen -type T [-func NewTExtractor] [-o t_export.go] [spec ...]
+//
+// Exportgen loads the package in the current directory and writes a
+// function
+//
+//	func NewTExtractor(data []T) *export.Extractor
+//
+// which returns an Extractor for data with one column per column spec. The
+// values are accessed by generated code instead of reflection which makes
+// dumping large slices considerably faster. A type *T generates a function
+// for data of type []*T.
+//
+// The column specs are a subset of those of export.NewExtractor: Chains of
+// fields and method calls like "Price", "Cut.Name" or `Bought.Format("2006")`
+// which may pass through pointers; nil pointers and failing methods yield
+// NA. Final values which are not of a basic type, time.Time, time.Duration
+// or a byte slice must implement fmt.Stringer. Without specs all exported
+// fields of T with a usable type become columns.
+//
+// Exportgen is typically run by go generate:
+//
+//	//go:generate exportgen -type Gem Cut Color Price Carat
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	typeName := flag.String("type", "", "the element type T of the slice data; *T for pointers")
+	funcName := flag.String("func", "", "the name of the generated function (default NewTExtractor)")
+	output := flag.String("o", "", "the output file (default t_export.go)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: exportgen -type T [-func name] [-o file] [spec ...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeName == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	base := strings.TrimPrefix(*typeName, "*")
+	if *funcName == "" {
+		*funcName = "New" + base + "Extractor"
+	}
+	if *output == "" {
+		*output = strings.ToLower(base) + "_export.go"
+	}
+
+	src, err := generate(".", *typeName, *funcName, *output, flag.Args())
+	if err == nil {
+		err = os.WriteFile(*output, src, 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "exportgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the source of the file output in dir which defines the
+// function funcName for slices of typeName with the given column specs.
+func generate(dir, typeName, funcName, output string, specs []string) ([]byte, error) {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	base := strings.TrimPrefix(typeName, "*")
+	obj, ok := pkg.Scope().Lookup(base).(*types.TypeName)
+	if !ok {
+		return nil, fmt.Errorf("no type %s in package %s", base, pkg.Name())
+	}
+	var elem types.Type = obj.Type()
+	if base != typeName {
+		elem = types.NewPointer(elem)
+	}
+
+	var columns []column
+	if len(specs) == 0 {
+		st, ok := obj.Type().Underlying().(*types.Struct)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not a struct, column specs needed", base)
+		}
+		for i := 0; i < st.NumFields(); i++ {
+			if f := st.Field(i); f.Exported() {
+				if c, err := newColumn(pkg, elem, f.Name()); err == nil {
+					columns = append(columns, c)
+				}
+			}
+		}
+	}
+	for _, spec := range specs {
+		c, err := newColumn(pkg, elem, spec)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, c)
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("no usable columns in %s", base)
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "// Code generated by \"exportgen %s\"; DO NOT EDIT.\n\n",
+		strings.Join(append([]string{"-type", typeName}, specs...), " "))
+	fmt.Fprintf(buf, "package %s\n\nimport \"github.com/vdobler/export\"\n\n", pkg.Name())
+	names := make([]string, len(columns))
+	for i, c := range columns {
+		names[i] = c.name
+	}
+	fmt.Fprintf(buf, "// %s returns an Extractor for data with the columns\n// %s.\n",
+		funcName, strings.Join(names, ", "))
+	fmt.Fprintf(buf, "func %s(data []%s) *export.Extractor {\n", funcName, typeName)
+	fmt.Fprintf(buf, "\treturn export.NewFuncExtractor(len(data),\n")
+	for _, c := range columns {
+		fmt.Fprintf(buf, "export.FuncColumn(%q, export.%s, func(i int) interface{} {\n%s\n}),\n",
+			c.name, c.typ, strings.Join(c.body, "\n"))
+	}
+	fmt.Fprintf(buf, ")\n}\n")
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid code: %v", err)
+	}
+	return src, nil
+}
+
+// loadPackage parses and type checks the package in dir ignoring test
+// files and the file output. Type errors are ignored as the package may
+// use the code not yet generated.
+func loadPackage(dir, output string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var files []*ast.File
+	for _, path := range paths {
+		name := filepath.Base(path)
+		if strings.HasSuffix(name, "_test.go") || name == filepath.Base(output) {
+			continue
+		}
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 && f.Name.Name != files[0].Name.Name {
+			return nil, fmt.Errorf("multiple packages in %s", dir)
+		}
+		files = append(files, f)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no Go files in %s", dir)
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(files[0].Name.Name, fset, files, nil)
+	return pkg, nil
+}
+
+// column is a generated column.
+type column struct {
+	name string   // name of the column
+	typ  string   // typ is the name of the export.Type of the column.
+	body []string // body are the statements of the value function.
+}
+
+// newColumn generates the column for spec in slices of elem.
+func newColumn(pkg *types.Package, elem types.Type, spec string) (column, error) {
+	g := &columnGen{pkg: pkg, x: "data[i]", t: elem}
+	elements, err := splitSpec(spec)
+	if err != nil {
+		return column{}, err
+	}
+	var name []string
+	for _, e := range elements {
+		if i := strings.Index(e, "("); i >= 0 && strings.HasSuffix(e, ")") {
+			err = g.method(e[:i], e[i:])
+			if e[i:] == "()" {
+				e = e[:i]
+			}
+		} else {
+			err = g.field(e)
+		}
+		if err != nil {
+			return column{}, fmt.Errorf("bad spec %s: %v", spec, err)
+		}
+		name = append(name, e)
+	}
+	typ, err := g.final()
+	if err != nil {
+		return column{}, fmt.Errorf("bad spec %s: %v", spec, err)
+	}
+	return column{name: strings.Join(name, "."), typ: typ, body: g.body}, nil
+}
+
+// columnGen generates the statements of a value function step by step.
+type columnGen struct {
+	pkg  *types.Package
+	x    string     // x is the addressable expression of the current value.
+	t    types.Type // t is the type of x.
+	vars int        // vars is the number of declared variables.
+	body []string
+}
+
+// newVar returns the name of a new variable.
+func (g *columnGen) newVar() string {
+	g.vars++
+	return fmt.Sprintf("v%d", g.vars)
+}
+
+// deref follows all pointers of the current value.
+func (g *columnGen) deref() {
+	for {
+		p, ok := g.t.Underlying().(*types.Pointer)
+		if !ok {
+			return
+		}
+		v := g.newVar()
+		g.body = append(g.body, fmt.Sprintf("%s := %s\nif %s == nil {\nreturn nil\n}", v, g.x, v))
+		g.x = "*" + v
+		g.t = p.Elem()
+	}
+}
+
+// selector returns the current value as the operand of a selector
+// expression: Dereferenced variables are selected from directly.
+func (g *columnGen) selector() string {
+	return strings.TrimPrefix(g.x, "*")
+}
+
+// field steps into the field name of the current value.
+func (g *columnGen) field(name string) error {
+	g.deref()
+	obj, index, indirect := types.LookupFieldOrMethod(g.t, true, g.pkg, name)
+	f, ok := obj.(*types.Var)
+	if !ok {
+		return fmt.Errorf("type %s has no field %s", g.t, name)
+	}
+	if indirect && len(index) > 1 {
+		return fmt.Errorf("field %s is promoted through a pointer", name)
+	}
+	g.x = g.selector() + "." + name
+	g.t = f.Type()
+	return nil
+}
+
+// method calls the method name with the parenthesized arguments args on the
+// current value.
+func (g *columnGen) method(name, args string) error {
+	g.deref()
+	obj, _, _ := types.LookupFieldOrMethod(g.t, true, g.pkg, name)
+	m, ok := obj.(*types.Func)
+	if !ok {
+		return fmt.Errorf("type %s has no method %s", g.t, name)
+	}
+	res := m.Type().(*types.Signature).Results()
+	call := g.selector() + "." + name + args
+	v := g.newVar()
+	switch {
+	case res.Len() == 1:
+		g.body = append(g.body, v+" := "+call)
+	case res.Len() == 2 && types.Identical(res.At(1).Type(), types.Universe.Lookup("error").Type()):
+		g.body = append(g.body, v+", err := "+call+"\nif err != nil {\nreturn nil\n}")
+	default:
+		return fmt.Errorf("cannot use method %s of %s", name, g.t)
+	}
+	g.x, g.t = v, res.At(0).Type()
+	return nil
+}
+
+// final adds the return statement converting the current value to its
+// canonical type and returns the name of its export.Type.
+func (g *columnGen) final() (string, error) {
+	g.deref()
+	ret := func(format string) {
+		g.body = append(g.body, "return "+fmt.Sprintf(format, g.x))
+	}
+	if named, ok := g.t.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "time" {
+		switch named.Obj().Name() {
+		case "Time":
+			ret("%s")
+			return "Time", nil
+		case "Duration":
+			ret("%s")
+			return "Duration", nil
+		}
+	}
+	switch u := g.t.Underlying().(type) {
+	case *types.Basic:
+		info := u.Info()
+		switch {
+		case u.Kind() == types.Bool:
+			ret("bool(%s)")
+			return "Bool", nil
+		case u.Kind() == types.Uint || u.Kind() == types.Uint64 || u.Kind() == types.Uintptr:
+			ret("uint64(%s)")
+			return "Uint", nil
+		case info&types.IsInteger != 0:
+			ret("int64(%s)")
+			return "Int", nil
+		case info&types.IsFloat != 0:
+			ret("float64(%s)")
+			return "Float", nil
+		case info&types.IsComplex != 0:
+			ret("complex128(%s)")
+			return "Complex", nil
+		case info&types.IsString != 0:
+			ret("string(%s)")
+			return "String", nil
+		}
+	case *types.Slice:
+		// Named byte slices like net.IP are better represented by
+		// their String method.
+		if b, ok := u.Elem().Underlying().(*types.Basic); ok && b.Kind() == types.Byte && !g.stringer() {
+			g.body = append(g.body, fmt.Sprintf("if %s == nil {\nreturn nil\n}", g.x))
+			ret("[]byte(%s)")
+			return "Bytes", nil
+		}
+	}
+	if g.stringer() {
+		g.x = g.selector()
+		ret("%s.String()")
+		return "String", nil
+	}
+	return "", fmt.Errorf("cannot use type %s", g.t)
+}
+
+// stringer reports whether the current value has a String method like
+// fmt.Stringer.
+func (g *columnGen) stringer() bool {
+	obj, _, _ := types.LookupFieldOrMethod(g.t, true, g.pkg, "String")
+	m, ok := obj.(*types.Func)
+	if !ok {
+		return false
+	}
+	sig := m.Type().(*types.Signature)
+	return sig.Params().Len() == 0 && sig.Results().Len() == 1 &&
+		types.Identical(sig.Results().At(0).Type(), types.Typ[types.String])
+}
+
+// splitSpec splits spec at the dots outside of method arguments.
+func splitSpec(spec string) ([]string, error) {
+	var elements []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == '[':
+			return nil, fmt.Errorf("map keys are not supported in %s", spec)
+		case c == '.' && depth == 0:
+			elements = append(elements, spec[start:i])
+			start = i + 1
+		}
+	}
+	elements = append(elements, spec[start:])
+	for _, e := range elements {
+		if e == "" || depth != 0 || quote != 0 {
+			return nil, fmt.Errorf("malformed spec %s", spec)
+		}
+	}
+	return elements, nil
+}
@@ -0,0 +1,89 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const gemSource = `package gems
+
+import (
+	"errors"
+	"time"
+)
+
+type Level int
+
+func (l Level) String() string { return "L" }
+
+type Grade struct{ L Level }
+
+func (g *Grade) String() string { return "G" }
+
+type Gem struct {
+	Color  string
+	Price  uint16
+	Size   *float64
+	Bought time.Time
+	Grade  Grade
+	ch     chan int
+}
+
+func (g Gem) Value() (float64, error) { return 0, errors.New("none") }
+func (g Gem) Pair() (int, int)        { return 1, 2 }
+`
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "gems.go"), []byte(gemSource), 0644); err != nil {
+		t.Fatal(err)
+	}
+	src, err := generate(dir, "*Gem", "NewGems", "gem_export.go",
+		[]string{"Color", "Size", `Bought.Format("2006")`, "Value()", "Grade", "Grade.L"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	code := string(src)
+	for _, want := range []string{
+		"package gems\n",
+		`import "github.com/vdobler/export"`,
+		"func NewGems(data []*Gem) *export.Extractor {",
+		"export.FuncColumn(\"Color\", export.String, func(i int) interface{} {\n\t\t\tv1 := data[i]\n\t\t\tif v1 == nil {",
+		"return string(v1.Color)",
+		"v2 := v1.Size\n\t\t\tif v2 == nil {\n\t\t\t\treturn nil\n\t\t\t}\n\t\t\treturn float64(*v2)",
+		`export.FuncColumn("Bought.Format(\"2006\")", export.String,`,
+		"v2 := v1.Bought.Format(\"2006\")\n\t\t\treturn string(v2)",
+		"v2, err := v1.Value()\n\t\t\tif err != nil {",
+		"return v1.Grade.String()",
+		`export.FuncColumn("Grade.L", export.Int,`,
+		"return int64(v1.Grade.L)",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("Missing %q in\n%s", want, code)
+		}
+	}
+
+	// Without specs all usable exported fields are columns.
+	src, err = generate(dir, "Gem", "NewGemExtractor", "gem_export.go", nil)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := strings.Count(string(src), "export.FuncColumn("); got != 5 {
+		t.Errorf("Got %d columns in\n%s", got, src)
+	}
+
+	for _, spec := range []string{"Weight", "Pair()", "ch", "Color.Len", "Labels[a]", "Value("} {
+		if _, err := generate(dir, "Gem", "NewGemExtractor", "gem_export.go", []string{spec}); err == nil {
+			t.Errorf("Missing error for %s", spec)
+		}
+	}
+	if _, err := generate(dir, "Stone", "NewStoneExtractor", "stone_export.go", nil); err == nil {
+		t.Errorf("Missing error for unknown type")
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Coerce changes the type of the column name to typ by converting each of
+// its values; values which cannot be converted are NA. The conversion is
+// kept if e is rebound. The possible conversions are:
+//   - Any type to String, formatted like in PreciseFormat; Times are
+//     formatted with layout if given.
+//   - String to any type, parsed like in NewDelimitedExtractor with layout
+//     as the layout of Times.
+//   - Between Int, Uint and Float if the value can be represented, Bool
+//     to Int (0 or 1).
+//   - Between Int and Duration as nanoseconds.
+//   - Between Int and Time as time since the Unix epoch in the unit given
+//     by layout: "s" (the default), "ms", "us" or "ns".
+//
+// Coerce returns an error if the conversion is not possible or if name
+// does not denote exactly one column which accesses the data, i.e. is not
+// an index, key or computed column.
+func (e *Extractor) Coerce(name string, typ Type, layout string) error {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		return err
+	}
+	field := &e.Columns[idx[0]]
+	conv, err := coercion(field.Type(), typ, layout)
+	if err != nil {
+		return fmt.Errorf("export: cannot coerce column %s: %v", name, err)
+	}
+	return e.convertColumn(field, typ, conv)
+}
+
+// convertColumn applies conv to the values of field which become values of
+// type typ. Errors of conv result in NA.
+func (e *Extractor) convertColumn(field *Column, typ Type, conv func(v interface{}) (interface{}, error)) error {
+	if e.typ != nil {
+		// Rebindable columns become expressions applying conv.
+		switch {
+		case field.synthetic || field.mapKey || field.compute != nil:
+			return fmt.Errorf("export: cannot convert column %s", field.Name)
+		case field.expr != nil:
+			root := field.expr.root
+			field.expr = &expr{operands: field.expr.operands,
+				root: &exprNode{op: 'c', x: root, typ: typ, operand: -1, conv: conv}}
+		default:
+			operand := *field
+			leaf := &exprNode{typ: operand.Type(), operand: 0}
+			field.expr = &expr{operands: []Column{operand},
+				root: &exprNode{op: 'c', x: leaf, typ: typ, operand: -1, conv: conv}}
+		}
+	}
+	value := field.value
+	field.value = func(i int) interface{} {
+		v := value(i)
+		if v == nil {
+			return nil
+		}
+		if x, err := conv(v); err == nil {
+			return x
+		}
+		return nil
+	}
+	field.typ = typ
+	return nil
+}
+
+// timeUnits are the units of Int values coerced to Times.
+var timeUnits = map[string]time.Duration{
+	"": time.Second, "s": time.Second, "ms": time.Millisecond,
+	"us": time.Microsecond, "ns": time.Nanosecond,
+}
+
+// coercion returns the function converting canonical values of type from
+// to values of type to.
+func coercion(from, to Type, layout string) (func(v interface{}) (interface{}, error), error) {
+	rangeErr := fmt.Errorf("value out of range")
+	switch {
+	case from == to && layout == "":
+		return func(v interface{}) (interface{}, error) { return v, nil }, nil
+	case to == String:
+		return func(v interface{}) (interface{}, error) {
+			if t, ok := v.(time.Time); ok && layout != "" {
+				return t.Format(layout), nil
+			}
+			return formatValue(PreciseFormat, from, v), nil
+		}, nil
+	case from == String:
+		if to == NA || to > Uint {
+			break
+		}
+		def := ColumnDef{Type: to, Layout: layout}
+		return func(v interface{}) (interface{}, error) { return def.parse(v.(string)) }, nil
+	case from == Bool && to == Int:
+		return func(v interface{}) (interface{}, error) {
+			if v.(bool) {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		}, nil
+	case from != to && (from == Int || from == Uint || from == Float) && (to == Int || to == Uint || to == Float):
+		return func(v interface{}) (interface{}, error) {
+			switch x := v.(type) {
+			case int64:
+				if to == Float {
+					return float64(x), nil
+				} else if x < 0 {
+					return nil, rangeErr
+				}
+				return uint64(x), nil
+			case uint64:
+				if to == Float {
+					return float64(x), nil
+				} else if x > math.MaxInt64 {
+					return nil, rangeErr
+				}
+				return int64(x), nil
+			}
+			f := v.(float64)
+			if f != math.Trunc(f) || f < -(1<<63) || f >= 1<<64 || to == Int && f >= 1<<63 || to == Uint && f < 0 {
+				return nil, rangeErr
+			}
+			if to == Int {
+				return int64(f), nil
+			}
+			return uint64(f), nil
+		}, nil
+	case from == Int && to == Duration:
+		return func(v interface{}) (interface{}, error) { return time.Duration(v.(int64)), nil }, nil
+	case from == Duration && to == Int:
+		return func(v interface{}) (interface{}, error) { return int64(v.(time.Duration)), nil }, nil
+	case from == Int && to == Time, from == Time && to == Int:
+		unit, ok := timeUnits[layout]
+		if !ok {
+			return nil, fmt.Errorf("unknown time unit %q", layout)
+		}
+		if to == Time {
+			return func(v interface{}) (interface{}, error) {
+				x := v.(int64)
+				return time.Unix(x/int64(time.Second/unit), x%int64(time.Second/unit)*int64(unit)), nil
+			}, nil
+		}
+		return func(v interface{}) (interface{}, error) {
+			t := v.(time.Time)
+			return t.Unix()*int64(time.Second/unit) + int64(t.Nanosecond())/int64(unit), nil
+		}, nil
+	}
+	return nil, fmt.Errorf("cannot convert %s to %s", from, to)
+}
@@ -0,0 +1,93 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+type job struct {
+	Name    string
+	Started string
+	Elapsed int64
+	Epoch   int64
+	Score   float64
+}
+
+func TestCoerce(t *testing.T) {
+	data := []job{
+		{"a", "2024-03-01", 1500000000, 1700000000123, 2},
+		{"b", "yesterday", -1, 0, 2.5},
+	}
+	extractor, err := NewExtractor(data, "Name", "Started", "Elapsed", "Epoch", "Score", "Score*2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, c := range []struct {
+		name, layout string
+		typ          Type
+	}{
+		{"Started", "2006-01-02", Time},
+		{"Elapsed", "", Duration},
+		{"Epoch", "ms", Time},
+		{"Score", "", Int},
+		{"Score*2", "", String},
+	} {
+		if err := extractor.Coerce(c.name, c.typ, c.layout); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	check := func(rows [][]interface{}) {
+		t.Helper()
+		for r, row := range rows {
+			for c, want := range row {
+				got := extractor.Columns[c+1].Value(r)
+				if gt, ok := got.(time.Time); ok {
+					got = gt.UTC()
+				}
+				if got != want {
+					t.Errorf("Row %d column %s: got %#v, want %#v",
+						r, extractor.Columns[c+1].Name, got, want)
+				}
+			}
+		}
+	}
+	wantTypes := []Type{String, Time, Duration, Time, Int, String}
+	for i, field := range extractor.Columns {
+		if field.Type() != wantTypes[i] {
+			t.Errorf("Column %s has type %s", field.Name, field.Type())
+		}
+	}
+	check([][]interface{}{
+		{time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), 1500 * time.Millisecond,
+			time.Date(2023, 11, 14, 22, 13, 20, 123e6, time.UTC), int64(2), "4"},
+		{nil, time.Duration(-1), time.Unix(0, 0).UTC(), nil, "5"},
+	})
+
+	// Coercions are kept on rebinding.
+	extractor.Bind([]job{{"c", "2000-01-02", 60e9, 1000, 7}})
+	check([][]interface{}{
+		{time.Date(2000, 1, 2, 0, 0, 0, 0, time.UTC), time.Minute,
+			time.Date(1970, 1, 1, 0, 0, 1, 0, time.UTC), int64(7), "14"},
+	})
+
+	extractor.AddIndexColumn("Row", 0)
+	for i, c := range []struct {
+		name, layout string
+		typ          Type
+	}{
+		{"Elapsed", "", Bytes},
+		{"Elapsed", "", Bool},
+		{"Epoch", "weeks", Int},
+		{"Row", "", String},
+		{"Weight", "", Float},
+		{"Name", "", NA},
+	} {
+		if err := extractor.Coerce(c.name, c.typ, c.layout); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+}
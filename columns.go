@@ -0,0 +1,124 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"strings"
+)
+
+// columnIndices returns the indices of the columns of e with the given
+// names or an error if a name is unknown, ambiguous or given twice.
+func (e *Extractor) columnIndices(names []string) ([]int, error) {
+	index := map[string]int{}
+	for i, field := range e.Columns {
+		if _, ok := index[field.Name]; ok {
+			index[field.Name] = -1
+		} else {
+			index[field.Name] = i
+		}
+	}
+	var problems []string
+	indices := make([]int, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		i, ok := index[name]
+		switch {
+		case !ok:
+			problems = append(problems, "no column "+name)
+		case i < 0:
+			problems = append(problems, "ambiguous column "+name)
+		case seen[name]:
+			problems = append(problems, "duplicate column "+name)
+		default:
+			indices = append(indices, i)
+		}
+		seen[name] = true
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("export: %s", strings.Join(problems, "; "))
+	}
+	return indices, nil
+}
+
+// SelectColumns keeps only the columns with the given names in the given
+// order. It returns an error and leaves e unchanged if a name does not
+// denote exactly one column or is given twice.
+func (e *Extractor) SelectColumns(names ...string) error {
+	indices, err := e.columnIndices(names)
+	if err != nil {
+		return err
+	}
+	columns := make([]Column, len(indices))
+	for k, i := range indices {
+		columns[k] = e.Columns[i]
+	}
+	e.Columns = columns
+	return nil
+}
+
+// DropColumns removes the columns with the given names. Like
+// SelectColumns it validates the names.
+func (e *Extractor) DropColumns(names ...string) error {
+	indices, err := e.columnIndices(names)
+	if err != nil {
+		return err
+	}
+	drop := make([]bool, len(e.Columns))
+	for _, i := range indices {
+		drop[i] = true
+	}
+	columns := make([]Column, 0, len(e.Columns)-len(indices))
+	for i, field := range e.Columns {
+		if !drop[i] {
+			columns = append(columns, field)
+		}
+	}
+	e.Columns = columns
+	return nil
+}
+
+// Reorder moves the columns with the given names in the given order in
+// front of the other columns which keep their order. Like SelectColumns
+// it validates the names.
+func (e *Extractor) Reorder(names ...string) error {
+	indices, err := e.columnIndices(names)
+	if err != nil {
+		return err
+	}
+	moved := make([]bool, len(e.Columns))
+	columns := make([]Column, 0, len(e.Columns))
+	for _, i := range indices {
+		columns = append(columns, e.Columns[i])
+		moved[i] = true
+	}
+	for i, field := range e.Columns {
+		if !moved[i] {
+			columns = append(columns, field)
+		}
+	}
+	e.Columns = columns
+	return nil
+}
+
+// RenameColumn changes the name of the column old to new. It returns an
+// error if old does not denote exactly one column or if a different
+// column is named new already.
+func (e *Extractor) RenameColumn(old, new string) error {
+	indices, err := e.columnIndices([]string{old})
+	if err != nil {
+		return err
+	}
+	if old == new {
+		return nil
+	}
+	for _, field := range e.Columns {
+		if field.Name == new {
+			return fmt.Errorf("export: column %s exists already", new)
+		}
+	}
+	e.Columns[indices[0]].Name = new
+	return nil
+}
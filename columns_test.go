@@ -0,0 +1,76 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"strings"
+	"testing"
+)
+
+func columnNames(e *Extractor) string {
+	names := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		names[i] = field.Name
+	}
+	return strings.Join(names, ",")
+}
+
+func TestColumnHelpers(t *testing.T) {
+	newExtractor := func() *Extractor {
+		data := []gem{{Cut: "Ideal", Price: 326}, {Cut: "Good", Price: 500}}
+		e, err := NewExtractor(data, "Cut", "Color", "Price", "Carat")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return e
+	}
+
+	for i, tc := range []struct {
+		op   func(e *Extractor) error
+		want string
+	}{
+		{func(e *Extractor) error { return e.SelectColumns("Price", "Cut") }, "Price,Cut"},
+		{func(e *Extractor) error { return e.DropColumns("Color", "Carat") }, "Cut,Price"},
+		{func(e *Extractor) error { return e.Reorder("Carat", "Color") }, "Carat,Color,Cut,Price"},
+		{func(e *Extractor) error { return e.RenameColumn("Carat", "Weight") }, "Cut,Color,Price,Weight"},
+		{func(e *Extractor) error { return e.RenameColumn("Cut", "Cut") }, "Cut,Color,Price,Carat"},
+	} {
+		e := newExtractor()
+		if err := tc.op(e); err != nil {
+			t.Errorf("%d: Unexpected error: %s", i, err)
+			continue
+		}
+		if got := columnNames(e); got != tc.want {
+			t.Errorf("%d: Got %s, want %s", i, got, tc.want)
+		}
+	}
+
+	e := newExtractor()
+	if err := e.SelectColumns("Price"); err != nil || e.Columns[0].value(1) != int64(500) {
+		t.Errorf("Got %v, %v", err, e.Columns[0].value(1))
+	}
+
+	for i, op := range []func(e *Extractor) error{
+		func(e *Extractor) error { return e.SelectColumns("Cut", "Depth") },
+		func(e *Extractor) error { return e.DropColumns("Cut", "Cut") },
+		func(e *Extractor) error { return e.Reorder("X") },
+		func(e *Extractor) error { return e.RenameColumn("X", "Y") },
+		func(e *Extractor) error { return e.RenameColumn("Cut", "Price") },
+	} {
+		e := newExtractor()
+		if err := op(e); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+		if got := columnNames(e); got != "Cut,Color,Price,Carat" {
+			t.Errorf("%d: Columns changed to %s", i, got)
+		}
+	}
+
+	e = newExtractor()
+	e.Columns[1].Name = "Cut"
+	if err := e.SelectColumns("Cut"); err == nil {
+		t.Errorf("Missing error for ambiguous name")
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !purego
+
+package export
+
+import (
+	"reflect"
+	"time"
+	"unsafe"
+)
+
+// compileAccessor returns a function which retrieves the value of the
+// steps in the i'th element of slice like retrieve but without reflection
+// by reading the memory at precomputed field offsets. Only chains of plain
+// struct fields (possibly behind pointers) ending in a value of a basic
+// kind, a time.Time or a byte slice are compiled; nil is returned for all
+// other steps, e.g. method calls, map keys or conversions.
+func compileAccessor(slice reflect.Value, indir int, steps []step, typ Type) func(i int) interface{} {
+	if slice.Kind() != reflect.Slice {
+		return nil
+	}
+	t := slice.Type().Elem()
+	size := t.Size()
+	for k := 0; k < indir; k++ {
+		t = t.Elem()
+	}
+
+	// path contains the offset of each field and the number of pointer
+	// indirections following it.
+	type hop struct {
+		offset uintptr
+		indir  int
+	}
+	path := make([]hop, len(steps))
+	for k, s := range steps {
+		if s.isMethodCall() || s.key.IsValid() || s.convert != nil || s.promoted != nil {
+			return nil
+		}
+		f := t.Field(s.field)
+		path[k] = hop{f.Offset, s.indir}
+		t = f.Type
+		for j := 0; j < s.indir; j++ {
+			t = t.Elem()
+		}
+	}
+	load := loader(t, typ)
+	if load == nil {
+		return nil
+	}
+
+	base := slice.UnsafePointer()
+	return func(i int) interface{} {
+		p := unsafe.Add(base, uintptr(i)*size)
+		for k := 0; k < indir; k++ {
+			if p = *(*unsafe.Pointer)(p); p == nil {
+				return nil
+			}
+		}
+		for _, h := range path {
+			p = unsafe.Add(p, h.offset)
+			for j := 0; j < h.indir; j++ {
+				if p = *(*unsafe.Pointer)(p); p == nil {
+					return nil
+				}
+			}
+		}
+		return load(p)
+	}
+}
+
+// loader returns a function which reads a value of type t at p as the
+// canonical value of typ or nil if t is not supported.
+func loader(t reflect.Type, typ Type) func(p unsafe.Pointer) interface{} {
+	if typ == Time {
+		if !isTime(t) {
+			return nil
+		}
+		return func(p unsafe.Pointer) interface{} { return *(*time.Time)(p) }
+	}
+	if typ == Bytes {
+		if t.Kind() != reflect.Slice {
+			return nil
+		}
+		return func(p unsafe.Pointer) interface{} {
+			if b := *(*[]byte)(p); b != nil {
+				return b
+			}
+			return nil
+		}
+	}
+	if typ == Duration {
+		return func(p unsafe.Pointer) interface{} { return time.Duration(*(*int64)(p)) }
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return func(p unsafe.Pointer) interface{} { return *(*bool)(p) }
+	case reflect.Int:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*int)(p)) }
+	case reflect.Int8:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*int8)(p)) }
+	case reflect.Int16:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*int16)(p)) }
+	case reflect.Int32:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*int32)(p)) }
+	case reflect.Int64:
+		return func(p unsafe.Pointer) interface{} { return *(*int64)(p) }
+	case reflect.Uint8:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*uint8)(p)) }
+	case reflect.Uint16:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*uint16)(p)) }
+	case reflect.Uint32:
+		return func(p unsafe.Pointer) interface{} { return int64(*(*uint32)(p)) }
+	case reflect.Uint:
+		return func(p unsafe.Pointer) interface{} { return uint64(*(*uint)(p)) }
+	case reflect.Uint64:
+		return func(p unsafe.Pointer) interface{} { return *(*uint64)(p) }
+	case reflect.Uintptr:
+		return func(p unsafe.Pointer) interface{} { return uint64(*(*uintptr)(p)) }
+	case reflect.Float32:
+		return func(p unsafe.Pointer) interface{} { return float64(*(*float32)(p)) }
+	case reflect.Float64:
+		return func(p unsafe.Pointer) interface{} { return *(*float64)(p) }
+	case reflect.Complex64:
+		return func(p unsafe.Pointer) interface{} { return complex128(*(*complex64)(p)) }
+	case reflect.Complex128:
+		return func(p unsafe.Pointer) interface{} { return *(*complex128)(p) }
+	case reflect.String:
+		return func(p unsafe.Pointer) interface{} { return *(*string)(p) }
+	}
+	return nil
+}
@@ -0,0 +1,15 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build purego
+
+package export
+
+import "reflect"
+
+// compileAccessor returns nil: Without package unsafe all values are
+// retrieved with reflection.
+func compileAccessor(slice reflect.Value, indir int, steps []step, typ Type) func(i int) interface{} {
+	return nil
+}
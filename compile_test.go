@@ -0,0 +1,87 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+type compiledInner struct {
+	F32  float32
+	When time.Time
+	Raw  []byte
+}
+
+type compiledData struct {
+	B     bool
+	I8    int8
+	U16   uint16
+	U     uint
+	C64   complex64
+	S     string
+	D     time.Duration
+	Inner compiledInner
+	Ptr   *compiledInner
+	PI    **int
+}
+
+func (c compiledData) Name() string { return c.S + "!" }
+
+func TestCompileAccessor(t *testing.T) {
+	seven := 7
+	pseven := &seven
+	data := []*compiledData{
+		{true, -8, 16, 42, 1 + 2i, "a", time.Second,
+			compiledInner{1.5, time1, []byte("x")}, &compiledInner{F32: 2.5}, &pseven},
+		{S: "b"},
+		nil,
+	}
+	specs := []string{"B", "I8", "U16", "U", "C64", "S", "D", "Inner.F32",
+		"Inner.When", "Inner.Raw", "Ptr.F32", "Ptr.Raw", "PI", "Name()"}
+	extractor, err := NewExtractor(data, specs...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	v := reflect.ValueOf(data)
+	for c, field := range extractor.Columns {
+		compiled := compileAccessor(v, extractor.indir, field.access, field.Type())
+		if specs[c] == "Name()" {
+			if compiled != nil {
+				t.Errorf("Method call %s compiled", field.Name)
+			}
+			continue
+		}
+		if compiled == nil {
+			continue // built with purego
+		}
+		for i := range data {
+			want := retrieve(v.Index(i), field.access, extractor.indir, field.Type(), field.unsigned)
+			if got := compiled(i); !reflect.DeepEqual(got, want) {
+				t.Errorf("Column %s row %d: got %#v, want %#v", specs[c], i, got, want)
+			}
+		}
+	}
+}
+
+func BenchmarkColumnValues(b *testing.B) {
+	data := make([]compiledData, 10000)
+	for i := range data {
+		data[i] = compiledData{I8: int8(i), S: "abc", Inner: compiledInner{F32: float32(i)}}
+	}
+	extractor, err := NewExtractor(data, "B", "I8", "U", "S", "D", "Inner.F32")
+	if err != nil {
+		b.Fatalf("Unexpected error: %s", err)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		for _, field := range extractor.Columns {
+			for i := 0; i < extractor.N; i++ {
+				field.value(i)
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// A Compressor returns a writer which compresses the data written to it
+// with the given level to w. Level 0 selects the default level of the
+// compression algorithm, so an algorithm's level 0 like gzip.NoCompression
+// is not available; uncompressed data is written without a Compressor.
+// Closing the writer must flush all data to w but must not close w.
+type Compressor func(w io.Writer, level int) (io.WriteCloser, error)
+
+var compressors = map[string]Compressor{
+	"gzip": func(w io.Writer, level int) (io.WriteCloser, error) {
+		if level == 0 {
+			level = gzip.DefaultCompression
+		}
+		return gzip.NewWriterLevel(w, level)
+	},
+}
+
+// compressionExtensions maps file extensions to compressor names.
+var compressionExtensions = map[string]string{
+	".gz":  "gzip",
+	".zst": "zstd",
+}
+
+// RegisterCompressor makes compressor available under the given name.
+// Only "gzip" is provided by this package; other algorithms can be added,
+// e.g. zstd with package github.com/klauspost/compress/zstd:
+//
+//	export.RegisterCompressor("zstd", func(w io.Writer, level int) (io.WriteCloser, error) {
+//		if level == 0 {
+//			return zstd.NewWriter(w)
+//		}
+//		return zstd.NewWriter(w, zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(level)))
+//	})
+//
+// Registering a nil compressor removes the name.
+func RegisterCompressor(name string, compressor Compressor) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if compressor == nil {
+		delete(compressors, name)
+		return
+	}
+	compressors[name] = compressor
+}
+
+// LookupCompressor returns the compressor registered under name or nil.
+func LookupCompressor(name string) Compressor {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return compressors[name]
+}
+
+// CompressedDumper compresses the output of another Dumper.
+type CompressedDumper struct {
+	Writer io.Writer     // Writer is the writer to output the compressed data.
+	Dumper DumperFactory // Dumper constructs the Dumper to compress.
+
+	// Compression is the name of a registered Compressor.
+	// It defaults to "gzip".
+	Compression string
+
+	// Level is the compression level, 0 selects the default level.
+	// Leave out the CompressedDumper to store the data uncompressed.
+	Level int
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d CompressedDumper) Dump(e *Extractor, format Format) error {
+	name := d.Compression
+	if name == "" {
+		name = "gzip"
+	}
+	compressor := LookupCompressor(name)
+	if compressor == nil {
+		return fmt.Errorf("export: no compressor registered for %q", name)
+	}
+	w, err := compressor(d.Writer, d.Level)
+	if err != nil {
+		return err
+	}
+	err = d.Dumper(w).Dump(e, format)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,67 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompressedDumper(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "S,I\nHello,12\nWorld,14\n"
+
+	for _, level := range []int{0, gzip.BestSpeed, gzip.BestCompression} {
+		buf := &bytes.Buffer{}
+		d := CompressedDumper{Writer: buf, Dumper: LookupDumper("csv"), Level: level}
+		if err := d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Level %d: Unexpected error: %s", level, err)
+		}
+		gz, err := gzip.NewReader(buf)
+		if err != nil {
+			t.Fatalf("Level %d: Unexpected error: %s", level, err)
+		}
+		if got, _ := io.ReadAll(gz); string(got) != want {
+			t.Errorf("Level %d: Got %q", level, got)
+		}
+	}
+
+	d := CompressedDumper{Writer: io.Discard, Dumper: LookupDumper("csv"), Level: 42}
+	if err := d.Dump(extractor, DefaultFormat); err == nil {
+		t.Errorf("Missing error for invalid level")
+	}
+
+	d = CompressedDumper{Writer: io.Discard, Dumper: LookupDumper("csv"), Compression: "zstd"}
+	if err := d.Dump(extractor, DefaultFormat); err == nil {
+		t.Errorf("Missing error for unregistered compressor")
+	}
+
+	RegisterCompressor("upper", func(w io.Writer, level int) (io.WriteCloser, error) {
+		return upperWriter{w}, nil
+	})
+	defer RegisterCompressor("upper", nil)
+	buf := &bytes.Buffer{}
+	d = CompressedDumper{Writer: buf, Dumper: LookupDumper("csv"), Compression: "upper"}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); got != strings.ToUpper(want) {
+		t.Errorf("Got %q", got)
+	}
+}
+
+type upperWriter struct{ w io.Writer }
+
+func (u upperWriter) Write(p []byte) (int, error) {
+	return u.w.Write(bytes.ToUpper(p))
+}
+
+func (u upperWriter) Close() error { return nil }
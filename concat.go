@@ -0,0 +1,120 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Concat returns an Extractor with the rows of all extractors, one after
+// the other. The extractors must have the same columns: Equal names and
+// types in the same order. The columns of the result (e.g. their Render
+// functions) are those of the first extractor. The result reads its values
+// from the extractors, cannot be rebound and becomes invalid if one of
+// the extractors is rebound.
+func Concat(extractors ...*Extractor) (*Extractor, error) {
+	if len(extractors) == 0 {
+		return &Extractor{}, nil
+	}
+	first := extractors[0]
+	mapping := make([][]int, len(extractors))
+	for k, e := range extractors {
+		if len(e.Columns) != len(first.Columns) {
+			return nil, fmt.Errorf("export: extractor %d has %d columns, want %d",
+				k, len(e.Columns), len(first.Columns))
+		}
+		mapping[k] = make([]int, len(first.Columns))
+		for c, field := range e.Columns {
+			want := first.Columns[c]
+			if field.Name != want.Name || field.Type() != want.Type() {
+				return nil, fmt.Errorf("export: column %d of extractor %d is %s of type %s, want %s of type %s",
+					c, k, field.Name, field.Type(), want.Name, want.Type())
+			}
+			mapping[k][c] = c
+		}
+	}
+	return concat(extractors, first.Columns, mapping), nil
+}
+
+// ConcatByName is like Concat but reconciles the columns by name: The
+// result has the columns of all extractors in the order of their first
+// appearance; rows from an extractor lacking a column are NA in this
+// column. Columns of the same name must have the same type and the names
+// must be unique within each extractor.
+func ConcatByName(extractors ...*Extractor) (*Extractor, error) {
+	var columns []Column
+	index := map[string]int{}
+	for k, e := range extractors {
+		seen := map[string]bool{}
+		for _, field := range e.Columns {
+			if seen[field.Name] {
+				return nil, fmt.Errorf("export: duplicate column %s in extractor %d", field.Name, k)
+			}
+			seen[field.Name] = true
+			c, ok := index[field.Name]
+			if !ok {
+				index[field.Name] = len(columns)
+				columns = append(columns, field)
+			} else if columns[c].Type() != field.Type() {
+				return nil, fmt.Errorf("export: column %s of extractor %d has type %s, want %s",
+					field.Name, k, field.Type(), columns[c].Type())
+			}
+		}
+	}
+	mapping := make([][]int, len(extractors))
+	for k, e := range extractors {
+		mapping[k] = make([]int, len(columns))
+		for c := range mapping[k] {
+			mapping[k][c] = -1
+		}
+		for i, field := range e.Columns {
+			mapping[k][index[field.Name]] = i
+		}
+	}
+	return concat(extractors, columns, mapping), nil
+}
+
+// concat returns the concatenation of the rows of extractors with the
+// given columns where mapping[k][c] is the index of column c in the k'th
+// extractor or -1 if it lacks this column.
+func concat(extractors []*Extractor, columns []Column, mapping [][]int) *Extractor {
+	// starts[k] is the first row of the k'th extractor in the result.
+	starts := make([]int, len(extractors)+1)
+	for k, e := range extractors {
+		starts[k+1] = starts[k] + e.N
+	}
+	locate := func(i int) (int, int) {
+		k := sort.Search(len(extractors), func(k int) bool { return starts[k+1] > i })
+		return k, i - starts[k]
+	}
+
+	result := &Extractor{N: starts[len(extractors)], Columns: make([]Column, len(columns))}
+	for c, field := range columns {
+		c := c
+		field.value = func(i int) interface{} {
+			k, r := locate(i)
+			if m := mapping[k][c]; m >= 0 {
+				return extractors[k].Columns[m].value(r)
+			}
+			return nil
+		}
+		field.fail = nil
+		for k, e := range extractors {
+			if m := mapping[k][c]; m >= 0 && e.Columns[m].fail != nil {
+				field.fail = func(i int) error {
+					k, r := locate(i)
+					if m := mapping[k][c]; m >= 0 && extractors[k].Columns[m].fail != nil {
+						return extractors[k].Columns[m].fail(r)
+					}
+					return nil
+				}
+				break
+			}
+		}
+		result.Columns[c] = field
+	}
+	return result
+}
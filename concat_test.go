@@ -0,0 +1,50 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestConcat(t *testing.T) {
+	a, _ := NewExtractor([]gem{{Cut: "Ideal", Price: 1}, {Cut: "Good", Price: 2}}, "Cut", "Price")
+	b, _ := NewExtractor([]gem{}, "Cut", "Price")
+	c, _ := NewExtractor([]gem{{Cut: "Fair", Price: 3, Color: "E"}}, "Cut", "Price")
+	dump := func(e *Extractor) string {
+		buf := &bytes.Buffer{}
+		DelimitedDumper{Writer: buf}.Dump(e, DefaultFormat)
+		return buf.String()
+	}
+
+	all, err := Concat(a, b, c)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(all), "Cut,Price\nIdeal,1\nGood,2\nFair,3\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	d, _ := NewExtractor([]gem{{Cut: "Very Good", Color: "J", Price: 4}}, "Color", "Cut")
+	if _, err := Concat(a, d); err == nil {
+		t.Errorf("Missing error for different columns")
+	}
+	all, err = ConcatByName(a, d)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(all), "Cut,Price,Color\nIdeal,1,\nGood,2,\nVery Good,,J\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	e, _ := NewExtractor([]gem{{Cut: "Fair"}}, "Carat")
+	e.Columns[0].Name = "Price"
+	if _, err := ConcatByName(a, e); err == nil {
+		t.Errorf("Missing error for different types")
+	}
+	if _, err := Concat(a, e); err == nil {
+		t.Errorf("Missing error for different types")
+	}
+}
@@ -0,0 +1,268 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ColumnDef declares name and type of a column in a data source which
+// lacks Go type information like a delimited text file.
+type ColumnDef struct {
+	Name string // Name of the column.
+	Type Type   // Type of the values in this column.
+
+	// Layout is the package time layout used to parse Time columns.
+	// An empty Layout defaults to time.RFC3339Nano.
+	Layout string
+
+	// Label and Description are the Label and Description of the column.
+	Label, Description string
+}
+
+// parse converts s to the canonical value of the type of d.
+// Empty strings are NA for all types except String; Bytes are hex encoded.
+func (d ColumnDef) parse(s string) (interface{}, error) {
+	if s == "" && d.Type != String {
+		return nil, nil
+	}
+	switch d.Type {
+	case Bool:
+		return strconv.ParseBool(s)
+	case Int:
+		return strconv.ParseInt(s, 10, 64)
+	case Uint:
+		return strconv.ParseUint(s, 10, 64)
+	case Float:
+		return strconv.ParseFloat(s, 64)
+	case Complex:
+		return strconv.ParseComplex(s, 128)
+	case String:
+		return s, nil
+	case Time:
+		layout := d.Layout
+		if layout == "" {
+			layout = time.RFC3339Nano
+		}
+		return time.Parse(layout, s)
+	case Duration:
+		if dur, err := time.ParseDuration(s); err == nil {
+			return dur, nil
+		}
+		ns, err := strconv.ParseInt(s, 10, 64)
+		return time.Duration(ns), err
+	case Bytes:
+		return hex.DecodeString(s)
+	}
+	return nil, fmt.Errorf("export: cannot parse values of type %s", d.Type)
+}
+
+// delimitedSource provides random access to the records of delimited text
+// by keeping only the offsets of the records in memory.
+type delimitedSource struct {
+	r       io.ReaderAt
+	comma   rune
+	offsets []int64 // offsets[i] is the start of the i'th data record
+
+	mu     sync.Mutex // mu guards cached and cache for parallel workers.
+	cached int        // index of the record in cache, -1 if none
+	cache  []string   // the cached record, never modified
+}
+
+// record returns the i'th data record of s. It is safe for concurrent use
+// as r is read with ReadAt only.
+func (s *delimitedSource) record(i int) []string {
+	s.mu.Lock()
+	if i == s.cached {
+		rec := s.cache
+		s.mu.Unlock()
+		return rec
+	}
+	s.mu.Unlock()
+	sr := io.NewSectionReader(s.r, s.offsets[i], math.MaxInt64-s.offsets[i])
+	cr := csv.NewReader(sr)
+	cr.Comma = s.comma
+	rec, err := cr.Read()
+	if err != nil {
+		rec = nil
+	}
+	s.mu.Lock()
+	s.cached, s.cache = i, rec
+	s.mu.Unlock()
+	return rec
+}
+
+// NewDelimitedExtractor returns an Extractor for the delimited text in r
+// which must be in the format understood by package encoding/csv with the
+// given field delimiter comma (e.g. ',' for CSV and '\t' for TSV files).
+// The records in r must have one field per column definition in defs.
+// If header is true the first record is a header line: It is skipped and
+// provides the names for definitions without Name.
+//
+// Only the offsets of the records are kept in memory, the values are read
+// from r and parsed when accessed. This allows to convert files larger than
+// the available memory. Values which cannot be parsed are NA.
+// The returned Extractor cannot be rebound.
+func NewDelimitedExtractor(r io.ReaderAt, comma rune, header bool, defs ...ColumnDef) (*Extractor, error) {
+	src := &delimitedSource{
+		r:      r,
+		comma:  comma,
+		cached: -1,
+	}
+	cr := csv.NewReader(io.NewSectionReader(r, 0, math.MaxInt64))
+	cr.Comma = comma
+	cr.FieldsPerRecord = len(defs)
+	cr.ReuseRecord = true
+	defs = append([]ColumnDef(nil), defs...)
+	for first := true; ; first = false {
+		offset := cr.InputOffset()
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if first && header {
+			for i := range defs {
+				if defs[i].Name == "" {
+					defs[i].Name = rec[i]
+				}
+			}
+			continue
+		}
+		src.offsets = append(src.offsets, offset)
+	}
+
+	ex := &Extractor{N: len(src.offsets)}
+	for i, def := range defs {
+		i, def := i, def
+		ex.Columns = append(ex.Columns, Column{
+			Name:        def.Name,
+			Label:       def.Label,
+			Description: def.Description,
+			typ:         def.Type,
+			value: func(r int) interface{} {
+				rec := src.record(r)
+				if rec == nil {
+					return nil
+				}
+				v, err := def.parse(rec[i])
+				if err != nil {
+					return nil
+				}
+				return v
+			},
+		})
+	}
+	return ex, nil
+}
+
+// NewRecordsExtractor returns an Extractor for records, e.g. read from a
+// CSV file by package encoding/csv. If header is true the first record
+// provides the names for definitions without Name and is skipped.
+// Column definitions of type NA (and all columns if defs is empty) get a
+// type inferred from the values: Int, Float, Bool, Time (in RFC 3339
+// format or as date "2006-01-02" optionally followed by time "15:04:05"),
+// Duration or String, the first which can parse all non-empty values.
+// Columns without a name are named V1, V2 and so on.
+//
+// The values are parsed when accessed; values which cannot be parsed and
+// missing fields are NA. The returned Extractor cannot be rebound.
+func NewRecordsExtractor(records [][]string, header bool, defs ...ColumnDef) (*Extractor, error) {
+	var names []string
+	if header {
+		if len(records) == 0 {
+			return nil, fmt.Errorf("export: missing header record")
+		}
+		names, records = records[0], records[1:]
+	}
+	if len(defs) == 0 {
+		n := len(names)
+		for _, rec := range records {
+			if len(rec) > n {
+				n = len(rec)
+			}
+		}
+		defs = make([]ColumnDef, n)
+	} else {
+		defs = append([]ColumnDef(nil), defs...)
+	}
+
+	ex := &Extractor{N: len(records)}
+	for i, def := range defs {
+		i := i
+		if def.Name == "" && i < len(names) {
+			def.Name = names[i]
+		}
+		if def.Name == "" {
+			def.Name = fmt.Sprintf("V%d", i+1)
+		}
+		if def.Type == NA {
+			inferred := inferColumnDef(def.Name, records, i)
+			inferred.Label, inferred.Description = def.Label, def.Description
+			def = inferred
+		}
+		ex.Columns = append(ex.Columns, Column{
+			Name:        def.Name,
+			Label:       def.Label,
+			Description: def.Description,
+			typ:         def.Type,
+			value: func(r int) interface{} {
+				if i >= len(records[r]) {
+					return nil
+				}
+				v, err := def.parse(records[r][i])
+				if err != nil {
+					return nil
+				}
+				return v
+			},
+		})
+	}
+	return ex, nil
+}
+
+// inferredDefs are the column definitions tried in order by inferColumnDef.
+var inferredDefs = []ColumnDef{
+	{Type: Int},
+	{Type: Float},
+	{Type: Bool},
+	{Type: Time, Layout: time.RFC3339Nano},
+	{Type: Time, Layout: "2006-01-02"},
+	{Type: Time, Layout: "2006-01-02 15:04:05"},
+	{Type: Duration},
+}
+
+// inferColumnDef returns the definition of the column name with the first
+// type in inferredDefs which parses the i'th field of all records.
+// Columns without values are String columns.
+func inferColumnDef(name string, records [][]string, i int) ColumnDef {
+	empty := true
+candidates:
+	for _, def := range inferredDefs {
+		for _, rec := range records {
+			if i >= len(rec) || rec[i] == "" {
+				continue
+			}
+			empty = false
+			if _, err := def.parse(rec[i]); err != nil {
+				continue candidates
+			}
+		}
+		if empty {
+			break
+		}
+		def.Name = name
+		return def
+	}
+	return ColumnDef{Name: name, Type: String}
+}
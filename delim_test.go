@@ -0,0 +1,136 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+var delimitedData = `Name	Count	Price	When	Lap
+Apple	3	1.25	2014-05-06T07:08:09Z	1m30s
+"Pear, ""green"""	7		2014-05-07T07:08:09Z	250
+Plum	x	0.5		
+`
+
+func TestDelimitedExtractor(t *testing.T) {
+	extractor, err := NewDelimitedExtractor(strings.NewReader(delimitedData), '\t', true,
+		ColumnDef{Type: String},
+		ColumnDef{Name: "N", Type: Int},
+		ColumnDef{Type: Float},
+		ColumnDef{Type: Time},
+		ColumnDef{Type: Duration},
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if extractor.N != 3 {
+		t.Fatalf("Got N=%d, want 3", extractor.N)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	want := `Name,N,Price,When,Lap
+Apple,3,1.25,2014-05-06T07:08:09,1m30s
+"Pear, ""green""",7,,2014-05-07T07:08:09,250ns
+Plum,,0.5,,
+`
+	buf := &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(buf)}.Dump(extractor, format)
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	// Random access in column order.
+	for _, i := range []int{2, 0, 1} {
+		if got := extractor.Columns[1].value(i); (got == nil) != (i == 2) {
+			t.Errorf("Row %d: got %v", i, got)
+		}
+	}
+}
+
+func TestDelimitedExtractorErrors(t *testing.T) {
+	_, err := NewDelimitedExtractor(strings.NewReader("a,b\n1,2,3\n"), ',', true,
+		ColumnDef{Type: Int}, ColumnDef{Type: Int})
+	if err == nil {
+		t.Errorf("Missing error for wrong number of fields")
+	}
+}
+
+func TestRecordsExtractor(t *testing.T) {
+	records := [][]string{
+		{"Name", "Count", "Price", "Ok", "Day", "Lap", "Note"},
+		{"Apple", "3", "1.25", "true", "2014-05-06", "1m30s", ""},
+		{"Pear", "", "2", "F", "2014-05-07", "2s", ""},
+		{"Plum", "-7", "", "1", "", "", ""},
+	}
+	extractor, err := NewRecordsExtractor(records, true)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := []Type{String, Int, Float, Bool, Time, Duration, String}
+	for i, field := range extractor.Columns {
+		if field.Name != records[0][i] || field.Type() != want[i] {
+			t.Errorf("Column %d: Got %s of type %s", i, field.Name, field.Type())
+		}
+	}
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf}.Dump(extractor, format)
+	wantCSV := `Name,Count,Price,Ok,Day,Lap,Note
+Apple,3,1.25,true,2014-05-06T00:00:00,1m30s,
+Pear,,2,false,2014-05-07T00:00:00,2s,
+Plum,-7,,true,,,
+`
+	if got := buf.String(); got != wantCSV {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, wantCSV)
+	}
+
+	// Type hints and ragged records without header.
+	extractor, err = NewRecordsExtractor([][]string{{"1", "x"}, {"2.5"}}, false,
+		ColumnDef{Type: Float}, ColumnDef{Name: "Label"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if c := extractor.Columns[0]; c.Name != "V1" || c.Type() != Float || c.value(1) != 2.5 {
+		t.Errorf("Got %s %s %v", c.Name, c.Type(), c.value(1))
+	}
+	if c := extractor.Columns[1]; c.Name != "Label" || c.Type() != String || c.value(1) != nil {
+		t.Errorf("Got %s %s %v", c.Name, c.Type(), c.value(1))
+	}
+
+	if _, err := NewRecordsExtractor(nil, true); err == nil {
+		t.Errorf("Missing error for missing header")
+	}
+}
+
+func TestDelimitedExtractorWorkers(t *testing.T) {
+	text := &strings.Builder{}
+	for i := 0; i < 2000; i++ {
+		fmt.Fprintf(text, "r%d,%d,%d\n", i, i, 2*i)
+	}
+	extractor, err := NewDelimitedExtractor(strings.NewReader(text.String()), ',', false,
+		ColumnDef{Name: "S", Type: String}, ColumnDef{Name: "I", Type: Int}, ColumnDef{Name: "J", Type: Int})
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dump := func() string {
+		buf := &bytes.Buffer{}
+		if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return buf.String()
+	}
+	want := dump()
+	extractor.Workers = 8
+	if got := dump(); got != want {
+		t.Errorf("Workers changed output")
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode/utf8"
+)
+
+// QuotePolicy determines which fields a DelimitedDumper encloses in quotes.
+type QuotePolicy int
+
+const (
+	// QuoteMinimal quotes only fields which need quoting, i.e. fields
+	// containing the delimiter, quotes or line breaks and fields with
+	// leading spaces.
+	QuoteMinimal QuotePolicy = iota
+
+	// QuoteAll quotes all fields.
+	QuoteAll
+
+	// QuoteNonNumeric quotes all fields except values in Int, Float
+	// and Complex columns, the header fields are quoted.
+	QuoteNonNumeric
+
+	// QuoteNone never quotes and outputs the fields verbatim.
+	QuoteNone
+)
+
+// DelimitedDumper dumps the values as delimited text like CSV or TSV
+// without the need to set up a csv.Writer.
+type DelimitedDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the header line.
+
+	Comma   rune        // Comma is the field delimiter, 0 means ','.
+	Quote   QuotePolicy // Quote determines which fields are quoted.
+	UseCRLF bool        // UseCRLF terminates lines with \r\n instead of \n.
+
+	// QuoteEmpty distinguishes NA from empty values unless Quote is
+	// QuoteNone: Empty values are always quoted and NA values never.
+	QuoteEmpty bool
+
+	// Header, if non-nil, transforms the column names in the header line,
+	// e.g. the Name method of a HeaderPolicy.
+	Header func(name string) string
+
+	// LabelRow adds a second header line with the labels of the columns,
+	// empty for columns without a label.
+	LabelRow bool
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d DelimitedDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	comma := d.Comma
+	if comma == 0 {
+		comma = ','
+	}
+	eol := "\n"
+	if d.UseCRLF {
+		eol = "\r\n"
+	}
+	w := bufio.NewWriter(d.Writer)
+	// line appends the fields appended by field to buf; field reports
+	// whether the field is numeric or NA.
+	line := func(buf []byte, field func(buf []byte, i int) ([]byte, bool, bool)) []byte {
+		for i := range e.Columns {
+			if i > 0 {
+				buf = utf8.AppendRune(buf, comma)
+			}
+			start, numeric, na := len(buf), false, false
+			buf, numeric, na = field(buf, i)
+			switch {
+			case d.QuoteEmpty && d.Quote != QuoteNone && na:
+			case d.QuoteEmpty && d.Quote != QuoteNone && len(buf) == start:
+				buf = append(buf, '"', '"')
+			default:
+				buf = d.quote(buf, start, numeric, comma)
+			}
+		}
+		return append(buf, eol...)
+	}
+	if !d.OmitHeader {
+		w.Write(line(nil, func(buf []byte, i int) ([]byte, bool, bool) {
+			name := e.Columns[i].Name
+			if d.Header != nil {
+				name = d.Header(name)
+			}
+			return append(buf, name...), false, false
+		}))
+		if d.LabelRow {
+			w.Write(line(nil, func(buf []byte, i int) ([]byte, bool, bool) {
+				return append(buf, e.Columns[i].Label...), false, false
+			}))
+		}
+	}
+	var f Formater = format // converted once, not per value
+	err = e.formatRows(func(buf []byte, r int) []byte {
+		return line(buf, func(buf []byte, i int) ([]byte, bool, bool) {
+			field := &e.Columns[i]
+			val := field.value(r)
+			buf = field.appendValue(buf, f, val)
+			switch field.Type() {
+			case Int, Uint, Float, Complex:
+				return buf, true, val == nil
+			}
+			return buf, false, val == nil
+		})
+	}, func(p []byte) error {
+		_, err := w.Write(p)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// quote applies the quoting policy of d to the field buf[start:] and
+// returns the extended buffer.
+func (d DelimitedDumper) quote(buf []byte, start int, numeric bool, comma rune) []byte {
+	s := buf[start:]
+	switch d.Quote {
+	case QuoteNone:
+		return buf
+	case QuoteNonNumeric:
+		if numeric {
+			return buf
+		}
+	case QuoteMinimal:
+		if !fieldNeedsQuotes(s, comma) {
+			return buf
+		}
+	}
+	// Enclose the field in quotes and double its quotes in place by
+	// moving its bytes backwards to their final position.
+	end := len(buf)
+	grow := bytes.Count(s, []byte{'"'}) + 2
+	for k := 0; k < grow; k++ {
+		buf = append(buf, '"')
+	}
+	j := len(buf) - 2
+	for k := end - 1; k >= start; k-- {
+		buf[j] = buf[k]
+		if j--; buf[k] == '"' {
+			buf[j] = '"'
+			j--
+		}
+	}
+	buf[j] = '"'
+	return buf
+}
+
+// fieldNeedsQuotes reports whether s must be quoted in delimited text
+// with the given field delimiter comma.
+func fieldNeedsQuotes(s []byte, comma rune) bool {
+	if len(s) == 0 {
+		return false
+	}
+	return bytes.ContainsRune(s, comma) || bytes.ContainsAny(s, "\"\r\n") ||
+		s[0] == ' ' || s[0] == '\t'
+}
+
+func init() {
+	RegisterDumper("tsv", func(w io.Writer) Dumper {
+		return DelimitedDumper{Writer: w, Comma: '\t'}
+	})
+}
@@ -0,0 +1,81 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDelimitedDumper(t *testing.T) {
+	data := []struct {
+		S string
+		I int
+		F *float64
+	}{{"a;b", 1, nil}, {`say "hi"`, -2, new(float64)}, {" x\ty", 3, nil}}
+	extractor, err := NewExtractor(data, "S", "I", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i, tc := range []struct {
+		d    DelimitedDumper
+		want string
+	}{
+		{
+			DelimitedDumper{},
+			"S,I,F\na;b,1,\n\"say \"\"hi\"\"\",-2,0\n\" x\ty\",3,\n",
+		},
+		{
+			DelimitedDumper{Comma: ';', UseCRLF: true, OmitHeader: true},
+			"\"a;b\";1;\r\n\"say \"\"hi\"\"\";-2;0\r\n\" x\ty\";3;\r\n",
+		},
+		{
+			DelimitedDumper{Comma: '\t', Quote: QuoteNonNumeric},
+			"\"S\"\t\"I\"\t\"F\"\n\"a;b\"\t1\t\n\"say \"\"hi\"\"\"\t-2\t0\n\" x\ty\"\t3\t\n",
+		},
+		{
+			DelimitedDumper{Comma: '|', Quote: QuoteAll, OmitHeader: true},
+			"\"a;b\"|\"1\"|\"\"\n\"say \"\"hi\"\"\"|\"-2\"|\"0\"\n\" x\ty\"|\"3\"|\"\"\n",
+		},
+		{
+			DelimitedDumper{Comma: '\t', Quote: QuoteNone, OmitHeader: true},
+			"a;b\t1\t\nsay \"hi\"\t-2\t0\n x\ty\t3\t\n",
+		},
+	} {
+		buf := &bytes.Buffer{}
+		tc.d.Writer = buf
+		if err := tc.d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("%d: Unexpected error: %s", i, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func BenchmarkDelimitedDumper(b *testing.B) {
+	type row struct {
+		I int
+		F float64
+		S string
+		D time.Duration
+	}
+	data := make([]row, 10000)
+	for i := range data {
+		data[i] = row{i, float64(i) / 7, "abc", time.Duration(i) * time.Millisecond}
+	}
+	extractor, err := NewExtractor(data, "I", "F", "S", "D")
+	if err != nil {
+		b.Fatalf("Unexpected error: %s", err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		(DelimitedDumper{Writer: io.Discard}).Dump(extractor, PreciseFormat)
+	}
+}
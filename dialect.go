@@ -0,0 +1,108 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"io"
+	"strings"
+)
+
+// CSVDialect is a set of conventions for delimited text expected by a
+// consumer, i.e. the settings of a DelimitedDumper and of the Format
+// used with it:
+//
+//	d := PostgresCSV
+//	err := d.Dumper(w).Dump(e, d.Format(DefaultFormat))
+type CSVDialect struct {
+	// Comma, Quote, QuoteEmpty, UseCRLF, OmitHeader, Header and LabelRow
+	// are the fields of the DelimitedDumper.
+	Comma      rune
+	Quote      QuotePolicy
+	QuoteEmpty bool
+	UseCRLF    bool
+	OmitHeader bool
+	Header     func(name string) string
+	LabelRow   bool
+
+	// Encoding is the character encoding of the output.
+	Encoding Encoding
+
+	// NARep, EscapeControls and NonFinite replace the fields of the
+	// Format. The other representations and TimeFmt replace them if not
+	// empty.
+	NARep                    string
+	EscapeControls           bool
+	NonFinite                NonFinitePolicy
+	TrueRep, FalseRep        string
+	NaNRep, PInfRep, MInfRep string
+	TimeFmt                  string
+}
+
+// Common dialects of delimited text.
+var (
+	// RFC4180 is CSV as described in RFC 4180: Fields are quoted if
+	// needed and lines end in CRLF.
+	RFC4180 = CSVDialect{Comma: ',', UseCRLF: true}
+
+	// ExcelCSV is CSV which Excel reads with non-ASCII characters, bools
+	// and times recognised; NaN and infinite values are empty.
+	ExcelCSV = CSVDialect{Comma: ',', UseCRLF: true, Encoding: UTF8BOM, NonFinite: NonFiniteNA,
+		TrueRep: "TRUE", FalseRep: "FALSE", TimeFmt: "2006-01-02 15:04:05"}
+
+	// PostgresCSV is the CSV format of the COPY command of PostgreSQL:
+	// NULLs are unquoted empty fields, empty strings are quoted and the
+	// header is lower case like unquoted identifiers.
+	PostgresCSV = CSVDialect{Comma: ',', QuoteEmpty: true, Header: strings.ToLower,
+		NaNRep: "NaN", PInfRep: "Infinity", MInfRep: "-Infinity",
+		TimeFmt: "2006-01-02 15:04:05.999999999-07:00"}
+
+	// PostgresText is the default text format of the COPY command of
+	// PostgreSQL: Tab separated fields without header and quoting,
+	// backslash escapes and \N for NULL.
+	PostgresText = CSVDialect{Comma: '\t', Quote: QuoteNone, OmitHeader: true,
+		NARep: `\N`, EscapeControls: true,
+		NaNRep: "NaN", PInfRep: "Infinity", MInfRep: "-Infinity",
+		TimeFmt: "2006-01-02 15:04:05.999999999-07:00"}
+)
+
+// Dumper returns a Dumper writing delimited text to w in dialect d: A
+// DelimitedDumper, wrapped in an EncodedDumper if the Encoding is not UTF8.
+func (d CSVDialect) Dumper(w io.Writer) Dumper {
+	delimited := func(w io.Writer) Dumper {
+		return DelimitedDumper{
+			Writer:     w,
+			OmitHeader: d.OmitHeader,
+			Comma:      d.Comma,
+			Quote:      d.Quote,
+			UseCRLF:    d.UseCRLF,
+			QuoteEmpty: d.QuoteEmpty,
+			Header:     d.Header,
+			LabelRow:   d.LabelRow,
+		}
+	}
+	if d.Encoding == UTF8 {
+		return delimited(w)
+	}
+	// The EncodedDumper closes the encoding writer after the Dump which
+	// writes the byte order mark of empty output and the replacement of
+	// an incomplete last character.
+	return EncodedDumper{Writer: w, Dumper: delimited, Encoding: d.Encoding}
+}
+
+// Format returns f adapted to dialect d. Strings are not quoted by the
+// Format as quoting is done by the Dumper.
+func (d CSVDialect) Format(f Format) Format {
+	f.NARep, f.EscapeControls, f.NonFinite = d.NARep, d.EscapeControls, d.NonFinite
+	f.StringFmt, f.StringQuote = "%s", QuoteWithFmt
+	for _, rep := range []struct{ dst, src *string }{
+		{&f.TrueRep, &d.TrueRep}, {&f.FalseRep, &d.FalseRep}, {&f.NaNRep, &d.NaNRep},
+		{&f.PInfRep, &d.PInfRep}, {&f.MInfRep, &d.MInfRep}, {&f.TimeFmt, &d.TimeFmt},
+	} {
+		if *rep.src != "" {
+			*rep.dst = *rep.src
+		}
+	}
+	return f
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+type ticket struct {
+	Title  string
+	Note   *string
+	Price  float64
+	Open   bool
+	Opened time.Time
+}
+
+func TestCSVDialects(t *testing.T) {
+	note := "a\tb\nc\\d"
+	opened := time.Date(2024, 5, 6, 7, 8, 9, 500000000, time.UTC)
+	data := []ticket{
+		{"", &note, math.Inf(1), true, opened},
+		{"x, \"y\"", nil, 2.5, false, opened},
+	}
+	extractor, err := NewExtractor(data, "Title", "Note", "Price", "Open", "Opened")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	base := PreciseFormat
+	base.TimeLoc = time.UTC
+	for _, tc := range []struct {
+		name    string
+		dialect CSVDialect
+		want    string
+	}{
+		{"RFC4180", RFC4180, "Title,Note,Price,Open,Opened\r\n" +
+			",\"a\tb\nc\\d\",+∞,true,2024-05-06T07:08:09.5Z\r\n" +
+			"\"x, \"\"y\"\"\",,2.5,false,2024-05-06T07:08:09.5Z\r\n"},
+		{"Excel", ExcelCSV, "\ufeffTitle,Note,Price,Open,Opened\r\n" +
+			",\"a\tb\nc\\d\",,TRUE,2024-05-06 07:08:09\r\n" +
+			"\"x, \"\"y\"\"\",,2.5,FALSE,2024-05-06 07:08:09\r\n"},
+		{"PostgresCSV", PostgresCSV, "title,note,price,open,opened\n" +
+			"\"\",\"a\tb\nc\\d\",Infinity,true,2024-05-06 07:08:09.5+00:00\n" +
+			"\"x, \"\"y\"\"\",,2.5,false,2024-05-06 07:08:09.5+00:00\n"},
+		{"PostgresText", PostgresText,
+			"\ta\\tb\\nc\\\\d\tInfinity\ttrue\t2024-05-06 07:08:09.5+00:00\n" +
+				"x, \"y\"\t\\N\t2.5\tfalse\t2024-05-06 07:08:09.5+00:00\n"},
+	} {
+		buf := &bytes.Buffer{}
+		if err := tc.dialect.Dumper(buf).Dump(extractor, tc.dialect.Format(base)); err != nil {
+			t.Fatalf("%s: Unexpected error: %s", tc.name, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: Got\n%q, want\n%q", tc.name, got, tc.want)
+		}
+	}
+
+	// The byte order mark is written for empty output, too.
+	buf := &bytes.Buffer{}
+	excel := ExcelCSV
+	excel.OmitHeader = true
+	if err := excel.Dumper(buf).Dump(extractor.Head(0), excel.Format(base)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); got != "\ufeff" {
+		t.Errorf("Got %q for empty output", got)
+	}
+}
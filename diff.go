@@ -0,0 +1,129 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Diff compares the rows of the snapshots old and new which are matched by
+// the values of the key columns keys. It returns an Extractor with a
+// String column "Change" followed by the columns of new: Rows of new
+// without a match in old are "added", rows of old without a match in new
+// are "removed" (with the values of old) and matched rows with different
+// values in at least one column are "changed" (with the values of new).
+// Unchanged rows are omitted. The rows are sorted by the keys in ascending
+// order with NA keys last.
+//
+// Both extractors must have the same set of uniquely named columns of the
+// same types (in any order) and the keys must identify the rows. The
+// result reads its values from old and new, cannot be rebound and becomes
+// invalid if old or new are rebound.
+func Diff(old, new *Extractor, keys ...string) (*Extractor, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("export: missing key columns")
+	}
+	names := make([]string, len(new.Columns))
+	for c, field := range new.Columns {
+		names[c] = field.Name
+	}
+	if _, err := new.columnIndices(names); err != nil {
+		return nil, err
+	}
+	oldIndex, err := old.columnIndices(names)
+	if err != nil {
+		return nil, err
+	}
+	if len(old.Columns) != len(new.Columns) {
+		return nil, fmt.Errorf("export: old has %d columns, new has %d",
+			len(old.Columns), len(new.Columns))
+	}
+	for c, field := range new.Columns {
+		if typ := old.Columns[oldIndex[c]].Type(); typ != field.Type() {
+			return nil, fmt.Errorf("export: column %s has type %s in old and %s in new",
+				field.Name, typ, field.Type())
+		}
+	}
+	newKeys, err := new.columnIndices(keys)
+	if err != nil {
+		return nil, err
+	}
+	oldKeys, _ := old.columnIndices(keys)
+
+	oldRows := map[string]int{}
+	for r := 0; r < old.N; r++ {
+		_, key := old.rowKey(oldKeys, r)
+		if _, ok := oldRows[key]; ok {
+			return nil, fmt.Errorf("export: duplicate key in row %d of old", r)
+		}
+		oldRows[key] = r
+	}
+
+	type change struct {
+		kind string
+		row  int // row in the concatenation of old and new
+		key  []interface{}
+	}
+	var changes []change
+	matched := make([]bool, old.N)
+	seen := map[string]bool{}
+	for r := 0; r < new.N; r++ {
+		values, key := new.rowKey(newKeys, r)
+		if seen[key] {
+			return nil, fmt.Errorf("export: duplicate key in row %d of new", r)
+		}
+		seen[key] = true
+		o, ok := oldRows[key]
+		if !ok {
+			changes = append(changes, change{"added", old.N + r, values})
+			continue
+		}
+		matched[o] = true
+		for c, field := range new.Columns {
+			if !equalValues(old.Columns[oldIndex[c]].value(o), field.value(r)) {
+				changes = append(changes, change{"changed", old.N + r, values})
+				break
+			}
+		}
+	}
+	for r := 0; r < old.N; r++ {
+		if !matched[r] {
+			values, _ := old.rowKey(oldKeys, r)
+			changes = append(changes, change{"removed", r, values})
+		}
+	}
+	sort.SliceStable(changes, func(i, j int) bool {
+		return keyLessValues(changes[i].key, changes[j].key)
+	})
+
+	identity := make([]int, len(new.Columns))
+	for c := range identity {
+		identity[c] = c
+	}
+	both := concat([]*Extractor{old, new}, new.Columns, [][]int{oldIndex, identity})
+	rows := make([]int, len(changes))
+	for i, ch := range changes {
+		rows[i] = ch.row
+	}
+	diff := both.subset(rows)
+	kind := Column{
+		Name:  "Change",
+		typ:   String,
+		value: func(i int) interface{} { return changes[i].kind },
+	}
+	diff.Columns = append([]Column{kind}, diff.Columns...)
+	return diff, nil
+}
+
+// equalValues reports whether the canonical values a and b are equal; NAs
+// and NaNs are equal to each other only.
+func equalValues(a, b interface{}) bool {
+	na, nb := isNA(a), isNA(b)
+	if na || nb {
+		return na && nb
+	}
+	return compareValues(a, b) == 0
+}
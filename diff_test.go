@@ -0,0 +1,56 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	yesterday := []gem{
+		{Cut: "Ideal", Color: "E", Price: 300},
+		{Cut: "Good", Color: "J", Price: 500},
+		{Cut: "Fair", Color: "D", Price: 100},
+	}
+	today := []gem{
+		{Cut: "Ideal", Color: "E", Price: 300},
+		{Cut: "Good", Color: "J", Price: 550},
+		{Cut: "Premium", Color: "D", Price: 900},
+		{Cut: "Ideal", Color: "D", Price: 400},
+	}
+	old, _ := NewExtractor(yesterday, "Price", "Cut", "Color")
+	new, _ := NewExtractor(today, "Cut", "Color", "Price")
+	diff, err := Diff(old, new, "Cut", "Color")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf}.Dump(diff, DefaultFormat)
+	want := `Change,Cut,Color,Price
+removed,Fair,D,100
+changed,Good,J,550
+added,Ideal,D,400
+added,Premium,D,900
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	if _, err := Diff(old, new, "Color"); err == nil {
+		t.Errorf("Missing error for duplicate keys")
+	}
+	if _, err := Diff(old, new); err == nil {
+		t.Errorf("Missing error for missing keys")
+	}
+	other, _ := NewExtractor(yesterday, "Cut", "Color", "Carat")
+	if _, err := Diff(other, new, "Cut", "Color"); err == nil {
+		t.Errorf("Missing error for different columns")
+	}
+	other.Columns[2].Name = "Price"
+	if _, err := Diff(other, new, "Cut", "Color"); err == nil {
+		t.Errorf("Missing error for different types")
+	}
+}
@@ -8,7 +8,11 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 	"text/tabwriter"
+	"time"
+	"unicode"
 )
 
 // Dumper is the interface which wrapps the Dump methods
@@ -17,6 +21,31 @@ type Dumper interface {
 	Dump(e *Extractor, format Format) error
 }
 
+// DumpRange dumps the rows from to to-1 of e with d; the bounds are clipped
+// to the rows of e. Dumping a large Extractor range by range allows to do
+// other work or flush the output in between, typically with the header
+// suppressed for all but the first range:
+//
+//	for from := 0; from < e.N; from += 10000 {
+//		err := DumpRange(dumper, e, from, from+10000, format)
+//		...
+//		dumper.OmitHeader = true
+//	}
+//
+// Index columns, the rows of the failures reported by DumpErrors and the
+// progress reported to e.Progress count from the start of e.
+func DumpRange(d Dumper, e *Extractor, from, to int, format Format) error {
+	w := e.Slice(from, to)
+	start := from
+	if start < 0 {
+		start = 0
+	}
+	if progress := e.Progress; progress != nil {
+		w.Progress = func(done, total int) { progress(start+done, e.N) }
+	}
+	return d.Dump(w, format)
+}
+
 // CSVDumper dumps values to a csv writer.
 type CSVDumper struct {
 	Writer     *csv.Writer // Writer is the csv writer to output the data.
@@ -25,6 +54,10 @@ type CSVDumper struct {
 
 // Dump implements the Dump method of a Dumper.
 func (d CSVDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
 	row := make([]string, len(e.Columns))
 	if !d.OmitHeader {
 		for i, field := range e.Columns {
@@ -40,6 +73,7 @@ func (d CSVDumper) Dump(e *Extractor, format Format) error {
 		if err != nil {
 			return err
 		}
+		e.progress(r, r+1)
 	}
 	d.Writer.Flush()
 	return d.Writer.Error()
@@ -54,6 +88,10 @@ type TabDumper struct {
 // Dump implements the Dump method of a Dumper.
 // Dump does not call Flush on the underlying tabwriter.
 func (d TabDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
 	if !d.OmitHeader {
 		ff := "%s"
 		for _, field := range e.Columns {
@@ -69,12 +107,30 @@ func (d TabDumper) Dump(e *Extractor, format Format) error {
 			ff = "\t%s"
 		}
 		fmt.Fprintln(d.Writer)
+		e.progress(r, r+1)
 	}
 
 	return nil
 }
 
+// RFrame selects the R function an RVecDumper uses to combine the column
+// vectors.
+type RFrame int
+
+const (
+	// RDataFrame constructs a base R data.frame.
+	RDataFrame RFrame = iota
+
+	// RTibble constructs a tibble with tibble::tibble.
+	RTibble
+
+	// RDataTable constructs a data.table with data.table::data.table.
+	RDataTable
+)
+
 // RVecDumper dumps as a R vectors, optionaly combined into a data frame.
+// The vectors are named after the columns made syntactically valid and
+// unique like R's make.names does, e.g. "Net Price" becomes Net.Price.
 type RVecDumper struct {
 	Writer io.Writer // Writer is the writer to output the data.
 
@@ -82,19 +138,75 @@ type RVecDumper struct {
 	// individual column vectors. A empty value suppresses the generation
 	// of this combining data frame.
 	DataFrame string
+
+	// Frame determines whether the data frame is a data.frame, a tibble
+	// or a data.table.
+	Frame RFrame
+
+	// StringsAsFactors dumps all String columns as factors with levels
+	// determined by R, like the stringsAsFactors argument of data.frame
+	// but independent of Frame. Factors takes precedence.
+	StringsAsFactors bool
+
+	// Factors maps column names to the levels of the factor the column
+	// is dumped as: The vector c(...) is wrapped in factor(...) whose
+	// levels are determined by R if the levels are nil. Otherwise the
+	// factor is ordered with the levels in the given order; values not
+	// among the levels are NA in R.
+	Factors map[string][]string
+
+	// EpochTimes dumps Time columns as the numeric seconds since the
+	// Unix epoch with the POSIXct class and the time zone of the format's
+	// TimeLoc (UTC if nil) attached. This is exact for any TimeFmt and
+	// faster for R to parse than a vector of as.POSIXct calls.
+	EpochTimes bool
 }
 
 // Dump implements the Dump method of a Dumper.
 // The given format must produce suitabel literals for the R values if the
 // dumped data shall be processed as R code; RFormat is suitable.
+// Bools are always dumped as the R literals TRUE and FALSE. Durations are
+// dumped as difftime vectors if the DurationUnit of format is a second,
+// minute, hour, day or week; durations formated as nanoseconds like in
+// RFormat are dumped as seconds. Column labels are set as the "label"
+// attribute of the vectors like e.g. package haven does.
 func (d RVecDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	if format.DurationFmt == "%d" && format.DurationUnit <= 0 {
+		format.DurationUnit = time.Second
+	}
 	all := ""
+	names := rNames(e.Columns)
 	for f, field := range e.Columns {
-		if _, err := fmt.Fprintf(d.Writer, "%s <- c(", field.Name); err != nil {
+		levels, factor := d.Factors[field.Name]
+		factor = factor || (d.StringsAsFactors && field.Type() == String)
+		epoch := d.EpochTimes && !factor && field.Type() == Time && field.render() == nil
+		units := ""
+		if !factor && field.Type() == Duration && field.render() == nil {
+			units = rDifftimeUnits[format.DurationUnit]
+		}
+		open := "c("
+		switch {
+		case factor:
+			open = "factor(c("
+		case epoch:
+			open = "structure(c("
+		case units != "":
+			open = "as.difftime(c("
+		}
+		if _, err := fmt.Fprintf(d.Writer, "%s <- %s", names[f], open); err != nil {
 			return err
 		}
 		for r := 0; r < e.N; r++ {
-			s := field.Print(format, r)
+			s := "NA"
+			if !epoch {
+				s = field.Print(rFormat{format}, r)
+			} else if t, ok := field.Value(r).(time.Time); ok {
+				s = rEpoch(t)
+			}
 			if r < e.N-1 {
 				if r%10 == 9 {
 					s += ",\n"
@@ -106,19 +218,198 @@ func (d RVecDumper) Dump(e *Extractor, format Format) error {
 				return err
 			}
 		}
-		if _, err := fmt.Fprintf(d.Writer, ")\n"); err != nil {
+		closing := ")\n"
+		if epoch {
+			closing = fmt.Sprintf("), class = c(\"POSIXct\", \"POSIXt\"), tzone = %q)\n",
+				rTimeZone(format.TimeLoc))
+		} else if units != "" {
+			closing = fmt.Sprintf("), units = %q)\n", units)
+		}
+		if factor {
+			closing = "))\n"
+			if levels != nil {
+				quoted := make([]string, len(levels))
+				for i, level := range levels {
+					quoted[i] = format.String(level)
+				}
+				closing = fmt.Sprintf("), levels = c(%s), ordered = TRUE)\n",
+					strings.Join(quoted, ", "))
+			}
+		}
+		if _, err := fmt.Fprint(d.Writer, closing); err != nil {
 			return err
 		}
+		if field.Label != "" {
+			if _, err := fmt.Fprintf(d.Writer, "attr(%s, \"label\") <- %q\n", names[f], field.Label); err != nil {
+				return err
+			}
+		}
 		if f > 0 {
 			all += ", "
 		}
-		all += field.Name
+		all += names[f]
 	}
+	e.progress(0, e.N)
 
 	if d.DataFrame != "" {
-		if _, err := fmt.Fprintf(d.Writer, "%s <- data.frame(%s)\n", d.DataFrame, all); err != nil {
+		frame := "data.frame"
+		switch d.Frame {
+		case RTibble:
+			frame = "tibble::tibble"
+		case RDataTable:
+			frame = "data.table::data.table"
+		}
+		if _, err := fmt.Fprintf(d.Writer, "%s <- %s(%s)\n", d.DataFrame, frame, all); err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// rReserved are the reserved words of R.
+var rReserved = map[string]bool{
+	"if": true, "else": true, "repeat": true, "while": true, "function": true,
+	"for": true, "next": true, "break": true, "in": true, "TRUE": true,
+	"FALSE": true, "NULL": true, "Inf": true, "NaN": true, "NA": true,
+	"NA_integer_": true, "NA_real_": true, "NA_character_": true,
+	"NA_complex_": true,
+}
+
+// rName returns name made a syntactically valid R name like make.names:
+// Invalid characters are replaced by a dot, an "X" is prepended if name
+// does not start with a letter or a dot not followed by a digit and a dot
+// is appended to reserved words.
+func rName(name string) string {
+	valid := []rune(name)
+	for i, r := range valid {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' && r != '_' {
+			valid[i] = '.'
+		}
+	}
+	switch {
+	case len(valid) == 0,
+		!unicode.IsLetter(valid[0]) && valid[0] != '.',
+		valid[0] == '.' && len(valid) > 1 && unicode.IsDigit(valid[1]):
+		valid = append([]rune{'X'}, valid...)
+	case rReserved[string(valid)]:
+		valid = append(valid, '.')
+	}
+	return string(valid)
+}
+
+// rNames returns the valid R names of columns, made unique like
+// make.names(..., unique = TRUE) by appending .1, .2 and so on to
+// duplicates.
+func rNames(columns []Column) []string {
+	names := make([]string, len(columns))
+	seen := map[string]bool{}
+	for i, field := range columns {
+		names[i] = rName(field.Name)
+		seen[names[i]] = true
+	}
+	used := map[string]bool{}
+	for i, name := range names {
+		for k := 1; used[names[i]]; k++ {
+			if candidate := name + "." + strconv.Itoa(k); !seen[candidate] {
+				names[i], seen[candidate] = candidate, true
+			}
+		}
+		used[names[i]] = true
+	}
+	return names
+}
+
+// rDifftimeUnits are the units of R's difftime for DurationUnits.
+var rDifftimeUnits = map[time.Duration]string{
+	time.Second:        "secs",
+	time.Minute:        "mins",
+	time.Hour:          "hours",
+	24 * time.Hour:     "days",
+	7 * 24 * time.Hour: "weeks",
+}
+
+// rTimeZone returns the R time zone name of loc: The empty string for the
+// local time zone and UTC if loc is nil.
+func rTimeZone(loc *time.Location) string {
+	switch loc {
+	case nil:
+		return "UTC"
+	case time.Local:
+		return ""
+	}
+	return loc.String()
+}
+
+// rEpoch returns the seconds since the Unix epoch of t.
+func rEpoch(t time.Time) string {
+	s := float64(t.Unix()) + float64(t.Nanosecond())/1e9
+	return strconv.FormatFloat(s, 'f', -1, 64)
+}
+
+// rFormat is a Format producing the R literals for bools and times.
+type rFormat struct{ Format }
+
+func (f rFormat) Bool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+// Time produces a POSIXct in the time zone of TimeLoc (or of t if TimeLoc
+// is nil) if the TimeFmt of RFormat is used; the fractional seconds are
+// kept.
+func (f rFormat) Time(t time.Time) string {
+	if f.TimeFmt != RFormat.TimeFmt || f.TimeUnit > 0 {
+		return f.Format.Time(t)
+	}
+	loc := f.TimeLoc
+	if loc == nil {
+		loc = t.Location()
+	}
+	return fmt.Sprintf("as.POSIXct(%q, tz=%q)",
+		t.In(loc).Format("2006-01-02 15:04:05.999999999"), rTimeZone(loc))
+}
+
+// MarkdownDumper dumps the values as a Markdown table with right aligned
+// numeric columns.
+type MarkdownDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d MarkdownDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	header, rule := "|", "|"
+	for _, field := range e.Columns {
+		header += " " + markdownEscape(field.Name) + " |"
+		switch field.Type() {
+		case Int, Uint, Float, Complex, Duration:
+			rule += " ---: |"
+		default:
+			rule += " --- |"
+		}
+	}
+	if _, err := fmt.Fprintf(d.Writer, "%s\n%s\n", header, rule); err != nil {
+		return err
+	}
+	for r := 0; r < e.N; r++ {
+		line := "|"
+		for _, field := range e.Columns {
+			line += " " + markdownEscape(field.Print(format, r)) + " |"
+		}
+		if _, err := fmt.Fprintln(d.Writer, line); err != nil {
+			return err
+		}
+		e.progress(r, r+1)
+	}
+	return nil
+}
+
+// markdownEscape escapes s for use in a Markdown table cell.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", `\|`, "\r\n", " ", "\n", " ").Replace(s)
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"io"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding of dumped text.
+type Encoding int
+
+const (
+	// UTF8 is the encoding produced by the Dumpers.
+	UTF8 Encoding = iota
+
+	// UTF8BOM is UTF-8 starting with a byte order mark which makes
+	// Excel recognise UTF-8 encoded CSV files.
+	UTF8BOM
+
+	// Latin1 is ISO 8859-1; characters not in Latin-1 are replaced
+	// by '?'.
+	Latin1
+
+	// UTF16LE is little endian UTF-16 starting with a byte order mark.
+	UTF16LE
+)
+
+// EncodedDumper transcodes the output of another Dumper to an Encoding,
+// e.g. for Windows tools which misread UTF-8.
+type EncodedDumper struct {
+	Writer   io.Writer     // Writer is the writer to output the encoded text.
+	Dumper   DumperFactory // Dumper constructs the Dumper to transcode.
+	Encoding Encoding      // Encoding is the encoding of the output.
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d EncodedDumper) Dump(e *Extractor, format Format) error {
+	w := NewEncodingWriter(d.Writer, d.Encoding)
+	err := d.Dumper(w).Dump(e, format)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// NewEncodingWriter returns a writer which transcodes the UTF-8 text
+// written to it to enc and writes it to w. Characters may be split across
+// writes. Invalid UTF-8 is written as the replacement character U+FFFD
+// ('?' in Latin-1). Close writes the byte order mark if nothing has been
+// written and the replacement for an incomplete last character; it does
+// not close w.
+func NewEncodingWriter(w io.Writer, enc Encoding) io.WriteCloser {
+	return &encodingWriter{w: w, enc: enc}
+}
+
+type encodingWriter struct {
+	w       io.Writer
+	enc     Encoding
+	started bool   // started is set once the byte order mark is written.
+	pending []byte // pending is an incomplete character of the last Write.
+	buf     []byte
+}
+
+func (w *encodingWriter) Write(p []byte) (int, error) {
+	data := p
+	if len(w.pending) > 0 {
+		data = append(w.pending, p...)
+		w.pending = nil
+	}
+	// Keep an incomplete character at the end for the next Write.
+	for k := 1; k < utf8.UTFMax && k <= len(data); k++ {
+		if utf8.RuneStart(data[len(data)-k]) {
+			if !utf8.FullRune(data[len(data)-k:]) {
+				w.pending = append([]byte(nil), data[len(data)-k:]...)
+				data = data[:len(data)-k]
+			}
+			break
+		}
+	}
+	if err := w.write(data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (w *encodingWriter) Close() error {
+	var data []byte
+	if len(w.pending) > 0 {
+		data = utf8.AppendRune(nil, utf8.RuneError)
+	}
+	w.pending = nil
+	return w.write(data)
+}
+
+// write writes the byte order mark if needed and data encoded.
+func (w *encodingWriter) write(data []byte) error {
+	buf := w.buf[:0]
+	if !w.started {
+		w.started = true
+		switch w.enc {
+		case UTF8BOM:
+			buf = append(buf, 0xEF, 0xBB, 0xBF)
+		case UTF16LE:
+			buf = append(buf, 0xFF, 0xFE)
+		}
+	}
+	switch w.enc {
+	case Latin1:
+		for len(data) > 0 {
+			r, size := utf8.DecodeRune(data)
+			data = data[size:]
+			if r > 0xFF || r == utf8.RuneError && size <= 1 {
+				r = '?'
+			}
+			buf = append(buf, byte(r))
+		}
+	case UTF16LE:
+		var units [2]uint16
+		for len(data) > 0 {
+			r, size := utf8.DecodeRune(data)
+			data = data[size:]
+			for _, u := range utf16.AppendRune(units[:0], r) {
+				buf = append(buf, byte(u), byte(u>>8))
+			}
+		}
+	default:
+		if utf8.Valid(data) {
+			buf = append(buf, data...)
+			break
+		}
+		for len(data) > 0 {
+			r, size := utf8.DecodeRune(data)
+			if r == utf8.RuneError && size <= 1 {
+				buf = utf8.AppendRune(buf, r)
+			} else {
+				buf = append(buf, data[:size]...)
+			}
+			data = data[size:]
+		}
+	}
+	w.buf = buf
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := w.w.Write(buf)
+	return err
+}
@@ -0,0 +1,72 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func TestEncodingWriter(t *testing.T) {
+	text := "Größe,€\n"
+	for _, tc := range []struct {
+		enc  Encoding
+		want string
+	}{
+		{UTF8, text},
+		{UTF8BOM, "\xef\xbb\xbf" + text},
+		{Latin1, "Gr\xf6\xdfe,?\n"},
+		{UTF16LE, "\xff\xfeG\x00r\x00\xf6\x00\xdf\x00e\x00,\x00\xac\x20\n\x00"},
+	} {
+		// Write byte by byte to split the characters.
+		buf := &bytes.Buffer{}
+		w := NewEncodingWriter(buf, tc.enc)
+		for i := 0; i < len(text); i++ {
+			if n, err := w.Write([]byte{text[i]}); n != 1 || err != nil {
+				t.Fatalf("Got %d, %v", n, err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%d: Got %q, want %q", tc.enc, got, tc.want)
+		}
+	}
+
+	// Invalid and incomplete characters.
+	buf := &bytes.Buffer{}
+	w := NewEncodingWriter(buf, UTF8)
+	io.WriteString(w, "a\xffb\xe2\x82")
+	w.Close()
+	if got, want := buf.String(), "a�b�"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	buf.Reset()
+	NewEncodingWriter(buf, UTF16LE).Close()
+	if got := buf.String(); got != "\xff\xfe" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestEncodedDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	d := EncodedDumper{
+		Writer:   buf,
+		Dumper:   func(w io.Writer) Dumper { return DelimitedDumper{Writer: w, OmitHeader: true} },
+		Encoding: Latin1,
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := buf.String(), "Hello\nWorld\nGo\nA Lot\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrorPolicy determines how values are handled whose method call
+// (of a method returning a result and an error) fails.
+type ErrorPolicy int
+
+const (
+	// ErrorNA dumps failing values as NA.
+	ErrorNA ErrorPolicy = iota
+
+	// ErrorSkip omits rows containing a failing value.
+	ErrorSkip
+
+	// ErrorAbort stops the Dump with a *RowError before anything is
+	// written.
+	ErrorAbort
+)
+
+// RowError describes a failing method call for a value.
+type RowError struct {
+	Row    int    // Row is the index of the row in the bound data.
+	Column string // Column is the name of the column.
+	Err    error  // Err is the error of the failing method call.
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("export: row %d, column %s: %v", e.Row, e.Column, e.Err)
+}
+
+// DumpErrors dumps e with d like d.Dump(e, format) and returns the failed
+// method calls under the ErrorNA and ErrorSkip policies ordered by row.
+// Like Dump it may be called concurrently for the same Extractor.
+func DumpErrors(d Dumper, e *Extractor, format Format) ([]*RowError, error) {
+	var mu sync.Mutex
+	var errs []*RowError
+	type rowColumn struct {
+		row    int
+		column string
+	}
+	seen := map[rowColumn]bool{}
+	c := *e
+	c.report = func(re *RowError) {
+		mu.Lock()
+		defer mu.Unlock()
+		// Dumpers may extract a value more than once.
+		if key := (rowColumn{re.Row, re.Column}); !seen[key] {
+			seen[key] = true
+			errs = append(errs, re)
+		}
+	}
+	err := d.Dump(&c, format)
+	// Workers report whole rows in column order but rows out of order.
+	sort.SliceStable(errs, func(i, j int) bool { return errs[i].Row < errs[j].Row })
+	return errs, err
+}
+
+// prepare is called at the start of a Dump and applies the error policy
+// of e and returns an Extractor with the rows to dump in which columns
+// with a Render function or Format are String columns and units are
+// appended to the column names if UnitsInHeader is set. Under ErrorNA
+// the failures are reported while the values are extracted; ErrorSkip and
+// ErrorAbort extract all values in columns which may fail beforehand and
+// keep them in memory. Calling prepare on a prepared Extractor is a no-op.
+// Apart from resetting the memos, which only causes a recomputation of
+// memoized prefixes, prepare does not modify e so that several Dumps of e
+// may run concurrently.
+func (e *Extractor) prepare() (*Extractor, error) {
+	if e.prepared {
+		return e, nil
+	}
+	e.memos.reset()
+	p := *e
+	copied := false
+	copyColumns := func() {
+		if !copied {
+			p.Columns = append([]Column(nil), p.Columns...)
+			copied = true
+		}
+	}
+
+	if e.OnError == ErrorNA {
+		for i, field := range p.Columns {
+			if field.fail == nil || e.report == nil {
+				continue
+			}
+			copyColumns()
+			value, fail, name, report := field.value, field.fail, field.Name, e.report
+			field.value = func(r int) interface{} {
+				v := value(r)
+				if v == nil {
+					// Only NA values can stem from a failed call.
+					if err := fail(r); err != nil {
+						report(&RowError{Row: r, Column: name, Err: err})
+					}
+				}
+				return v
+			}
+			p.Columns[i] = field
+		}
+	} else {
+		var fallible []int
+		for c, field := range e.Columns {
+			if field.fail != nil {
+				fallible = append(fallible, c)
+			}
+		}
+		values := make([][]interface{}, len(fallible))
+		for k := range values {
+			values[k] = make([]interface{}, e.N)
+		}
+		var keep []int
+		failed := false
+		for r := 0; len(fallible) > 0 && r < e.N; r++ {
+			ok := true
+			for k, c := range fallible {
+				field := e.Columns[c]
+				values[k][r] = field.value(r)
+				if values[k][r] != nil {
+					// Only NA values can stem from a failed call.
+					continue
+				}
+				err := field.fail(r)
+				if err == nil {
+					continue
+				}
+				re := &RowError{Row: r, Column: field.Name, Err: err}
+				if e.report != nil {
+					e.report(re)
+				}
+				if e.OnError == ErrorAbort {
+					return nil, re
+				}
+				ok, failed = false, true
+			}
+			if ok {
+				keep = append(keep, r)
+			}
+		}
+		row := func(i int) int { return i }
+		if failed {
+			p = *e.view(len(keep), func(i int) int { return keep[i] })
+			p.Progress, p.ProgressInterval = e.Progress, e.ProgressInterval
+			p.Workers = e.Workers
+			copied = true
+			row = func(i int) int { return keep[i] }
+		}
+		for k, c := range fallible {
+			copyColumns()
+			column := values[k]
+			p.Columns[c].value = func(i int) interface{} { return column[row(i)] }
+		}
+	}
+
+	for i, field := range p.Columns {
+		unit := e.UnitsInHeader && field.Unit != ""
+		if field.render() == nil && !unit {
+			continue
+		}
+		copyColumns()
+		if field.render() != nil {
+			field = field.rendered()
+		}
+		if unit {
+			field.Name += " [" + field.Unit + "]"
+		}
+		p.Columns[i] = field
+	}
+	p.prepared = true
+	return &p, nil
+}
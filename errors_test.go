@@ -0,0 +1,107 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestErrorPolicy(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "BME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct {
+		policy ErrorPolicy
+		want   string
+	}{
+		{ErrorNA, "S,BME\nHello,true\nWorld,true\nGo,\nA Lot,\n"},
+		{ErrorSkip, "S,BME\nHello,true\nWorld,true\n"},
+	} {
+		extractor.OnError = tc.policy
+		buf := &bytes.Buffer{}
+		errs, err := DumpErrors(DelimitedDumper{Writer: buf, Comma: ','}, extractor, DefaultFormat)
+		if err != nil {
+			t.Fatalf("Policy %d: Unexpected error: %s", tc.policy, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("Policy %d: Got %q, want %q", tc.policy, got, tc.want)
+		}
+		if len(errs) != 2 || errs[1].Row != 3 ||
+			errs[1].Column != "BME" || errs[1].Err.(methodError).err != someError {
+			t.Errorf("Policy %d: Got errors %v", tc.policy, errs)
+		}
+	}
+
+	extractor.OnError = ErrorAbort
+	buf := &bytes.Buffer{}
+	err = MarkdownDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	re, ok := err.(*RowError)
+	if !ok || re.Row != 2 || re.Column != "BME" || buf.Len() != 0 {
+		t.Errorf("Got error %v and output %q", err, buf.String())
+	}
+	want := "export: row 2, column BME: method call failed on BME: some error"
+	if err != nil && err.Error() != want {
+		t.Errorf("Got error %q, want %q", err, want)
+	}
+
+	extractor.Bind(table[:2])
+	if errs, err := DumpErrors(MarkdownDumper{Writer: buf}, extractor, DefaultFormat); err != nil || len(errs) != 0 {
+		t.Errorf("Got error %v and errors %v", err, errs)
+	}
+}
+
+func TestErrorSkipCalls(t *testing.T) {
+	v := 1.5
+	data := []measurement{{Value: &v}, {}, {Value: &v}}
+	extractor, err := NewExtractor(data, "Sensor", "Check()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, policy := range []ErrorPolicy{ErrorNA, ErrorSkip} {
+		extractor.OnError = policy
+		materializeCalls = 0
+		errs, err := DumpErrors(DelimitedDumper{Writer: &bytes.Buffer{}}, extractor, DefaultFormat)
+		if err != nil || len(errs) != 1 {
+			t.Fatalf("Policy %d: Got error %v and errors %v", policy, err, errs)
+		}
+		if materializeCalls != len(data) {
+			t.Errorf("Policy %d: Check called %d times for %d rows", policy, materializeCalls, len(data))
+		}
+	}
+}
+
+func TestConcurrentDumps(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "BME()", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Workers = 2
+	want := "S,BME,F\nHello,true,3.141\nWorld,true,2.718\nGo,,\nA Lot,,6.022e+23\n"
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			buf := &bytes.Buffer{}
+			dumper := DelimitedDumper{Writer: buf}
+			if i%2 == 0 {
+				if err := dumper.Dump(extractor, DefaultFormat); err != nil {
+					t.Errorf("Unexpected error: %s", err)
+				}
+			} else if errs, err := DumpErrors(dumper, extractor, DefaultFormat); err != nil ||
+				len(errs) != 2 || errs[0].Row != 2 || errs[1].Row != 3 {
+				t.Errorf("Got error %v and errors %v", err, errs)
+			}
+			if got := buf.String(); got != want {
+				t.Errorf("Got %q, want %q", got, want)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
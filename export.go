@@ -8,6 +8,9 @@
 // The main type is Extractor which determines which data is output and in
 // which order. An Extractor is constructed from (almost) any slice type
 // and may access nested fields and/or methods of the slice elements.
+// Column oriented data stored in a struct of slices can be used too.
+// Values received from a channel or produced by an iterator can be dumped
+// batch by batch with a Stream.
 //
 // Example
 //
@@ -44,12 +47,37 @@
 //   - Accessing a nested field (in the example T) inside a field (C in the
 //     example) is written as T.C
 //   - Methods require "()" in the columne specifier (here "M()").
-//   - Methods may not take arguments.
+//   - Methods may take arguments of boolean, numeric and string type
+//     which are given as Go literals, e.g. `T.Format("2006-01-02")` or
+//     "Quantile(0.95)". Durations may be given as quoted strings like
+//     `Round("1h")`. The arguments are part of the column name.
 //   - Only methods returnig one value or a (value, error) pair may
 //     be used.
+//   - Methods with pointer receivers are called on the address of the
+//     slice element or field if it is addressable and on a copy
+//     otherwise (e.g. on the result of a method call).
+//   - Fields and methods promoted from embedded structs can be used like
+//     in Go, e.g. "X" for a field X of an embedded struct.
+//   - The specifier "*" expands to all exported fields (in declaration
+//     order, including promoted ones) whose type is usable as final
+//     element, "C.*" does the same for the fields of C. Prefixing a
+//     specifier with "-" excludes it, e.g. "*", "-Password", "-Internal.*"
+//     drops the column Password and all columns of the fields of Internal.
+//   - Expressions combine fields and methods (and literals) with the
+//     operators + - * / and parentheses, e.g. "Price/Carat", "X*Y*Z"
+//     or `Cut + " / " + Color`. Numbers are added, subtracted and
+//     multiplied as Ints if both are Ints and as Floats otherwise; / always
+//     yields a Float. Strings can be concatenated, Durations added and
+//     subtracted, Times subtracted and shifted by Durations. An NA operand
+//     results in NA. As "-X" is an exclusion, negate a single field as
+//     "-(X)".
+//   - Values in maps are accessed by a key given as Go literal in
+//     brackets, e.g. `Labels["region"]`. A missing key results in NA.
 //   - Pointers are dereferenced automatically.
 //   - Nil Pointers and method calls returning a non-nil error result in
 //     a NA value for this field.
+//   - On time.Time values the pseudo methods "ISOWeek()" and "Quarter()"
+//     yield strings like "2024-W07" and "2024Q1".
 //
 // The final field (or the type returned by a final method call) must be
 // one of:
@@ -59,22 +87,36 @@
 //   - complex64 and complex128
 //   - string
 //   - time.Time and time.Duration
+//   - []byte
+//   - sql.NullBool, sql.NullInt64, sql.NullString, sql.NullTime and the
+//     other nullable types of package database/sql which yield NA if
+//     not Valid
+//   - generic option types like sql.Null[T] with a Valid field, a Valid
+//     or IsZero method and a V or Value field or Value method which
+//     yield NA if no value is present
 //
 // This package handles floats and int as 64bit values and complex values
-// as complex128. Thus an uint64 may overflow without notice.
+// as complex128. Columns of type uint, uint64 and uintptr have their own
+// Type Uint and are kept as uint64 values.
 //
 // Dumping
 //
 // Dumping the data bound to an Extractor is done via a Dumper. This package
-// provides three types: CSVDumper, TabDumper and RVecDumper. It is the
-// dumpers responsibility to iterate over the rows and columns of an Extractor
-// and generating values via the the Columns Print method which takes a
-// Formater which does the actual string generation.
+// provides several types, e.g. CSVDumper, TabDumper, MarkdownDumper and
+// RVecDumper. It is the dumpers responsibility to iterate over the rows and
+// columns of an Extractor and generating values via the the Columns Print
+// method which takes a Formater which does the actual string generation.
+//
+// Dumpers can be registered under a format name with RegisterDumper and
+// selected at runtime by DumpAs.
 package export
 
 import (
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -95,15 +137,76 @@ type Extractor struct {
 	// columns.
 	Columns []Column
 
+	// Progress, if non-nil, is called by the Dumpers of this package
+	// after every ProgressInterval rows (default 1000) and after the
+	// last row. Dumpers which write the values column by column report
+	// only the completion of the last row.
+	Progress         func(rowsDone, totalRows int)
+	ProgressInterval int
+
+	// OnError determines how the Dumpers of this package handle values
+	// whose method call fails.
+	OnError ErrorPolicy
+
+	// report, if non-nil, receives the failed method calls of a Dump,
+	// see DumpErrors.
+	report func(re *RowError)
+
+	// KeyOrder, if non-nil, reports whether the key a sorts before the
+	// key b for Extractors of map data. The default order is ascending
+	// for keys of boolean, numeric and string type and by the package
+	// fmt representation otherwise. KeyOrder takes effect on Bind.
+	KeyOrder func(a, b interface{}) bool
+
+	// Workers, if greater than one, is the number of goroutines which
+	// extract and format the rows in parallel in DelimitedDumper,
+	// JSONDumper and JSONLinesDumper; the order of the rows in the output
+	// is preserved. All value functions, i.e. the methods called and the
+	// functions of computed columns and Render, must be safe for
+	// concurrent use; the ones of the Extractors constructed by this
+	// package from delimited text, records, JSON, gob, Arrow and binary
+	// data are.
+	Workers int
+
+	// UnitsInHeader appends the Unit of columns in brackets to their
+	// name in the output of the Dumpers of this package, e.g. "Size [MiB]".
+	UnitsInHeader bool
+
+	prepared bool // prepared is set for Extractors returned by prepare.
+
 	som   bool // som is true for slice-of-measurement type data.
 	indir int  // number of primary som indirections; e.g. 2 for []**Data
 
+	// row returns the i'th element of the bound som data. It is nil
+	// for other Extractors.
+	row func(i int) interface{}
+
+	// keys are the sorted keys of bound map data.
+	keys reflect.Value
+
+	// data is the bound som data. It has been reallocated by Append and
+	// is not shared with the caller if grown is set.
+	data  reflect.Value
+	grown bool
+
+	// flushed is the number of rows dumped by Flush.
+	flushed int
+
+	// memos are the memoized step prefixes shared by the bound columns.
+	memos prefixMemos
+
 	// typ contains the go type this Extractor
 	// can work on i.e. can be bound to.
 	typ reflect.Type
 }
 
 // NewExtractor returns an extractor for the given column specifications of data.
+// Data is either a slice (slice-of-measurements) or a struct whose fields
+// are slices of equal length (columns-of-slices). For the later the first
+// element of each column specifier names the slice field; the rest of the
+// specifier, if any, applies to the slice elements. Data may be a map too
+// whose values are used like the elements of a slice in the order of
+// their keys, see KeyOrder and AddKeyColumn.
 func NewExtractor(data interface{}, columnSpecs ...string) (*Extractor, error) {
 	typ := reflect.TypeOf(data)
 	switch typ.Kind() {
@@ -116,8 +219,24 @@ func NewExtractor(data interface{}, columnSpecs ...string) (*Extractor, error) {
 		ex.typ = typ
 		ex.bindSOM(data) // This sets up ex.N and ex.Columns[i].Value.
 		return ex, nil
+	case reflect.Map:
+		ex, err := newSOMExtractor(data, columnSpecs...)
+		if err != nil {
+			return ex, err
+		}
+		ex.typ = typ
+		ex.bindMap(data)
+		return ex, nil
 	case reflect.Struct:
-		panic("COS data frame not implemented")
+		ex, err := newCOSExtractor(typ, columnSpecs...)
+		if err != nil {
+			return ex, err
+		}
+		ex.typ = typ
+		if err := ex.bindCOS(data); err != nil {
+			return nil, err
+		}
+		return ex, nil
 	}
 	return &Extractor{}, fmt.Errorf("Cannot build Extrator for %s", typ.String())
 }
@@ -132,8 +251,156 @@ func (e *Extractor) Bind(data interface{}) {
 	}
 	if e.som {
 		e.bindSOM(data)
-	} else {
-		panic("COS data frame not implemented")
+	} else if typ.Kind() == reflect.Map {
+		e.bindMap(data)
+	} else if err := e.bindCOS(data); err != nil {
+		panic(err.Error())
+	}
+	e.grown, e.flushed = false, 0
+}
+
+// Append appends rows, either a slice of the type of the bound data or a
+// single element of it, to the slice data bound to e and increases N. The
+// slice bound by Bind or NewExtractor is not modified.
+func (e *Extractor) Append(rows interface{}) error {
+	if !e.som {
+		return fmt.Errorf("export: cannot append to data of type %v", e.typ)
+	}
+	data := e.data
+	if !e.grown {
+		data = data.Slice3(0, data.Len(), data.Len())
+	}
+	v := reflect.ValueOf(rows)
+	switch {
+	case !v.IsValid():
+		return fmt.Errorf("export: cannot append nil")
+	case v.Type() == e.typ:
+		data = reflect.AppendSlice(data, v)
+	case v.Type() == e.typ.Elem():
+		data = reflect.Append(data, v)
+	default:
+		return fmt.Errorf("export: cannot append %v to %v", v.Type(), e.typ)
+	}
+	e.grown = true
+	e.bindSOM(data.Interface())
+	return nil
+}
+
+// Flush dumps the rows of e which have not been dumped by an earlier Flush
+// since the last Bind, e.g. the rows added by Append. The first Flush uses
+// the Dumper first, all later ones rest which typically is first with its
+// header suppressed. If rest is nil first is used always. Flush does nothing
+// if there are no new rows. Index columns count from the start of the bound
+// data.
+func (e *Extractor) Flush(first, rest Dumper, format Format) error {
+	start := e.flushed
+	if start >= e.N {
+		return nil
+	}
+	dumper := first
+	if start > 0 && rest != nil {
+		dumper = rest
+	}
+	w := e.window(start, e.N)
+	w.OnError = e.OnError
+	if err := dumper.Dump(w, format); err != nil {
+		return err
+	}
+	e.flushed = e.N
+	return nil
+}
+
+// AddIndexColumn inserts an Int column with the given name in front of the
+// other columns whose values are the row numbers, starting at base (which
+// is typically 0 or 1, the later matching the row names of R data frames).
+// The index column is kept if e is rebound.
+func (e *Extractor) AddIndexColumn(name string, base int) {
+	index := Column{
+		Name:      name,
+		typ:       Int,
+		value:     func(i int) interface{} { return int64(base + i) },
+		synthetic: true,
+	}
+	e.Columns = append([]Column{index}, e.Columns...)
+}
+
+// AddComputedColumn appends a column with the given name and type whose
+// values are computed by fn from the rows of the bound data, e.g. to export
+// derived values without adding methods to the data type. For slice data
+// the row is the slice element, for a struct of slices it is the row index
+// (an int). The result of fn must be nil (NA) or of a Go type whose Type is
+// typ; errors are handled like failing method calls. AddComputedColumn
+// panics if typ is NA or unknown.
+func (e *Extractor) AddComputedColumn(name string, typ Type, fn func(row interface{}) (interface{}, error)) {
+	if typ == NA || typ > Uint {
+		panic(fmt.Sprintf("export: cannot add computed column %s of type %s", name, typ))
+	}
+	column := Column{
+		Name:    name,
+		typ:     typ,
+		compute: fn,
+	}
+	column.bindComputed(e.rowFunc())
+	e.Columns = append(e.Columns, column)
+}
+
+// rowFunc returns the function providing the rows passed to computed
+// columns.
+func (e *Extractor) rowFunc() func(i int) interface{} {
+	if e.row != nil {
+		return e.row
+	}
+	return func(i int) interface{} { return i }
+}
+
+// bindComputed sets the value and fail functions of the computed column c
+// for the given rows.
+func (c *Column) bindComputed(row func(i int) interface{}) {
+	compute, typ := c.compute, c.typ
+	get := func(i int) (interface{}, error) {
+		x, err := compute(row(i))
+		if err != nil || x == nil {
+			return nil, err
+		}
+		rv := reflect.ValueOf(x)
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				return nil, nil
+			}
+			rv = rv.Elem()
+		}
+		if t := superType(rv.Type()); t != typ {
+			return nil, fmt.Errorf("value of type %s is not %s", rv.Type(), typ)
+		}
+		unsigned := false
+		switch rv.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			unsigned = true
+		}
+		return canonical(rv, typ, unsigned), nil
+	}
+	c.value = func(i int) interface{} {
+		x, _ := get(i)
+		return x
+	}
+	c.fail = func(i int) error {
+		_, err := get(i)
+		return err
+	}
+}
+
+// progress reports the advancement from prev to done dumped rows to
+// e.Progress if an interval boundary or the last row is reached.
+func (e *Extractor) progress(prev, done int) {
+	if e.Progress == nil || done == prev {
+		return
+	}
+	every := e.ProgressInterval
+	if every <= 0 {
+		every = 1000
+	}
+	if done == e.N || done/every > prev/every {
+		e.Progress(done, e.N)
 	}
 }
 
@@ -152,12 +419,14 @@ const (
 	String
 	Time
 	Duration
+	Bytes
+	Uint
 )
 
 // String returns the name of t.
 func (t Type) String() string {
 	return []string{"NA", "Bool", "Int", "Float", "Complex", "String",
-		"Time", "Duration"}[t]
+		"Time", "Duration", "Bytes", "Uint"}[t]
 }
 
 // Column represents one column in the export. Columns are created
@@ -168,6 +437,29 @@ type Column struct {
 	// be changed afterwards.
 	Name string
 
+	// Render, if non-nil, formats the non-NA values of this column,
+	// e.g. to map enumeration values to labels. The Dumpers of this
+	// package treat such a column as a String column.
+	Render func(v interface{}) string
+
+	// Format, if non-nil, is used instead of the format passed to Dump
+	// to format the non-NA values of this column, e.g. to show one
+	// column as a percentage or the times of one column in a different
+	// TimeLoc or as Unix seconds. Like with Render the Dumpers of this
+	// package treat such a column as a String column. Render takes
+	// precedence over Format.
+	Format *Format
+
+	// Unit is the unit of the values like "s" or "MiB", if any. It is
+	// used by ConvertUnit and appended to the header name if the
+	// Extractor's UnitsInHeader is set.
+	Unit string
+
+	// Label is a short human-readable name of the column and Description
+	// explains its content. Both are optional and used by some Dumpers,
+	// e.g. as a second header line or as comments in a schema.
+	Label, Description string
+
 	typ Type // The type of the column.
 
 	// value returns the i'th value in this column.
@@ -176,18 +468,75 @@ type Column struct {
 
 	access   []step // The steps needed to access the result.
 	unsigned bool   // For Type == Int
+
+	slice      int // For COS data: Index of the slice field.
+	sliceIndir int // For COS data: Number of indirections of the slice elements.
+
+	synthetic bool // value does not access the bound data and is kept by Bind.
+
+	// compute is the function of a computed column.
+	compute func(row interface{}) (interface{}, error)
+
+	expr *expr // expr is the expression of an expression column.
+
+	mapKey bool // The column contains the keys of map data.
+
+	// fail returns the error of a failing method call for the i'th
+	// value. It is nil if no method in the column may fail.
+	fail func(i int) error
 }
 
 // Type returns the type of the column c.
 func (c Column) Type() Type { return c.typ }
 
+// Value returns the i'th value of column c as bool, int64, uint64,
+// float64, complex128, string, time.Time, time.Duration or []byte
+// depending on the type of c or nil for NA values (nil pointers and
+// failing method calls). Render functions and Formats are not applied.
+func (c Column) Value(i int) interface{} { return c.value(i) }
+
 // Print the i'th entry of column c with the given format.
 func (c Column) Print(f Formater, i int) string {
-	val := c.value(i)
+	return c.printValue(f, c.value(i))
+}
+
+// printValue formats the value val of column c like Print.
+func (c Column) printValue(f Formater, val interface{}) string {
 	if val == nil {
 		return f.NA()
 	}
-	switch c.typ {
+	if render := c.render(); render != nil {
+		return f.String(render(val))
+	}
+	return formatValue(f, c.typ, val)
+}
+
+// AppendTo appends the i'th entry of column c formatted like Print to dst
+// and returns the extended buffer. Values formatted by a Format with the
+// common verbs and styles are appended without allocating a string.
+func (c Column) AppendTo(dst []byte, f Formater, i int) []byte {
+	return c.appendValue(dst, f, c.value(i))
+}
+
+// appendValue appends the value val of c formatted with f to dst.
+func (c Column) appendValue(dst []byte, f Formater, val interface{}) []byte {
+	if val == nil {
+		return append(dst, f.NA()...)
+	}
+	if render := c.render(); render != nil {
+		return append(dst, f.String(render(val))...)
+	}
+	if format, ok := f.(Format); ok {
+		if b, ok := format.appendValue(dst, val); ok {
+			return b
+		}
+	}
+	return append(dst, formatValue(f, c.typ, val)...)
+}
+
+// formatValue formats the non-NA canonical value val of type typ with f.
+func formatValue(f Formater, typ Type, val interface{}) string {
+	switch typ {
 	case Bool:
 		return f.Bool(val.(bool))
 	case Int:
@@ -202,11 +551,42 @@ func (c Column) Print(f Formater, i int) string {
 		return f.Time(val.(time.Time))
 	case Duration:
 		return f.Duration(val.(time.Duration))
+	case Bytes:
+		return formatBytes(f, val.([]byte))
+	case Uint:
+		return formatUint(f, val.(uint64))
 	}
 
 	return fmt.Sprintf("%v", val)
 }
 
+// render returns the function rendering the values of c as set by the
+// Render or Format field or nil if c has none.
+func (c Column) render() func(v interface{}) string {
+	if c.Render != nil {
+		return c.Render
+	}
+	if c.Format != nil {
+		format, typ := *c.Format, c.typ
+		return func(v interface{}) string { return formatValue(format, typ, v) }
+	}
+	return nil
+}
+
+// rendered returns c as a String column of the values formatted by
+// c.render().
+func (c Column) rendered() Column {
+	value, render := c.value, c.render()
+	c.value = func(i int) interface{} {
+		if v := value(i); v != nil {
+			return render(v)
+		}
+		return nil
+	}
+	c.typ, c.Render, c.Format = String, nil, nil
+	return c
+}
+
 // newSOMExtractor sets up an unbound Extractor for a slice-of-measurements
 // type data.
 func newSOMExtractor(data interface{}, colSpecs ...string) (*Extractor, error) {
@@ -220,18 +600,36 @@ func newSOMExtractor(data interface{}, colSpecs ...string) (*Extractor, error) {
 		indir: indir,
 	}
 
+	walk := func(prefix string) (reflect.Type, error) {
+		_, t, err := walkSteps(typ, prefix)
+		return t, err
+	}
+	usable := func(spec string) bool {
+		_, _, _, err := buildSteps(typ, spec)
+		return err == nil
+	}
+	colSpecs, err := expandWildcards(typ, colSpecs, walk, usable)
+	if err != nil {
+		return nil, err
+	}
+
 	for _, spec := range colSpecs {
+		if isExpression(spec) {
+			x, err := parseExpr(spec, func(path string) (Column, error) {
+				steps, rType, unsigned, err := buildSteps(typ, path)
+				return Column{Name: path, typ: rType, access: steps, unsigned: unsigned}, err
+			})
+			if err != nil {
+				return nil, err
+			}
+			ex.Columns = append(ex.Columns, Column{Name: spec, typ: x.root.typ, expr: x})
+			continue
+		}
 		steps, rType, unsigned, err := buildSteps(typ, spec)
 		if err != nil {
 			return nil, err
 		}
-		name := ""
-		for s := range steps {
-			if s > 0 {
-				name += "."
-			}
-			name += steps[s].name
-		}
+		name := stepNames("", steps)
 
 		field := Column{
 			Name:     name,
@@ -245,16 +643,229 @@ func newSOMExtractor(data interface{}, colSpecs ...string) (*Extractor, error) {
 	return &ex, nil
 }
 
+// expandWildcards replaces the column specifiers "*" and "X.*" in specs by
+// specifiers for all exported fields of typ respectively of the struct type
+// returned by walk for X in declaration order. Fields for which usable
+// reports false are skipped. The exclusions "-Y" and "-Y.*" in specs drop
+// the specifier Y respectively all specifiers starting with "Y.".
+func expandWildcards(typ reflect.Type, specs []string, walk func(prefix string) (reflect.Type, error), usable func(spec string) bool) ([]string, error) {
+	var expanded, excluded []string
+	for _, spec := range specs {
+		if strings.HasPrefix(spec, "-") &&
+			(strings.HasSuffix(spec, ".*") || !isExpression(spec[1:])) {
+			excluded = append(excluded, spec[1:])
+			continue
+		}
+		if spec != "*" && !strings.HasSuffix(spec, ".*") {
+			expanded = append(expanded, spec)
+			continue
+		}
+		prefix, st := strings.TrimSuffix(spec, "*"), typ
+		if prefix != "" {
+			var err error
+			if st, err = walk(strings.TrimSuffix(prefix, ".")); err != nil {
+				return nil, err
+			}
+			for st.Kind() == reflect.Ptr {
+				st = st.Elem()
+			}
+		}
+		if st.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("export: cannot expand %s: type %s is not a struct",
+				spec, st)
+		}
+		for _, f := range reflect.VisibleFields(st) {
+			if !f.IsExported() {
+				continue
+			}
+			// Skip fields whose name is ambiguous.
+			if g, _ := st.FieldByName(f.Name); len(g.Index) != len(f.Index) {
+				continue
+			}
+			if usable(prefix + f.Name) {
+				expanded = append(expanded, prefix+f.Name)
+			}
+		}
+	}
+	if len(excluded) == 0 {
+		return expanded, nil
+	}
+	kept := expanded[:0]
+outer:
+	for _, spec := range expanded {
+		for _, ex := range excluded {
+			if spec == ex || (strings.HasSuffix(ex, ".*") &&
+				strings.HasPrefix(spec, strings.TrimSuffix(ex, "*"))) {
+				continue outer
+			}
+		}
+		kept = append(kept, spec)
+	}
+	return kept, nil
+}
+
+// newCOSExtractor sets up an unbound Extractor for a columns-of-slices
+// type data of type typ.
+func newCOSExtractor(typ reflect.Type, colSpecs ...string) (*Extractor, error) {
+	ex := Extractor{}
+	walk := func(prefix string) (reflect.Type, error) {
+		elements := strings.SplitN(prefix, ".", 2)
+		sf, ok := typ.FieldByName(elements[0])
+		if !ok || sf.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("export: type %s has no slice field %s",
+				typ, elements[0])
+		}
+		elem := sf.Type.Elem()
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+		}
+		if len(elements) == 1 {
+			return elem, nil
+		}
+		_, elem, err := walkSteps(elem, elements[1])
+		return elem, err
+	}
+	usable := func(spec string) bool {
+		_, err := newCOSExtractor(typ, spec)
+		return err == nil
+	}
+	colSpecs, err := expandWildcards(typ, colSpecs, walk, usable)
+	if err != nil {
+		return nil, err
+	}
+	for _, spec := range colSpecs {
+		if isExpression(spec) {
+			x, err := parseExpr(spec, func(path string) (Column, error) {
+				ex, err := newCOSExtractor(typ, path)
+				if err != nil {
+					return Column{}, err
+				}
+				return ex.Columns[0], nil
+			})
+			if err != nil {
+				return nil, err
+			}
+			ex.Columns = append(ex.Columns, Column{Name: spec, typ: x.root.typ, expr: x})
+			continue
+		}
+		elements := strings.SplitN(spec, ".", 2)
+		sf, ok := typ.FieldByName(elements[0])
+		if !ok || len(sf.Index) != 1 {
+			return nil, fmt.Errorf("export: type %s has no field %s",
+				typ, elements[0])
+		}
+		if sf.Type.Kind() != reflect.Slice {
+			return nil, fmt.Errorf("export: field %s of %s is not a slice",
+				sf.Name, typ)
+		}
+		elem := sf.Type.Elem()
+		indir := 0
+		for elem.Kind() == reflect.Ptr {
+			elem = elem.Elem()
+			indir++
+		}
+		var steps []step
+		var err error
+		if len(elements) == 2 {
+			steps, elem, err = walkSteps(elem, elements[1])
+			if err != nil {
+				return nil, err
+			}
+		}
+		steps, rType, unsigned, err := finalSteps(elem, steps)
+		if err != nil {
+			return nil, err
+		}
+
+		name := stepNames(sf.Name, steps)
+		field := Column{
+			Name:       name,
+			typ:        rType,
+			access:     steps,
+			unsigned:   unsigned,
+			slice:      sf.Index[0],
+			sliceIndir: indir,
+		}
+		ex.Columns = append(ex.Columns, field)
+	}
+
+	return &ex, nil
+}
+
+// bindCOS is the columns-of-slices version of Bind.
+func (e *Extractor) bindCOS(data interface{}) error {
+	v := reflect.ValueOf(data)
+	n := -1
+	check := func(field Column) error {
+		l := v.Field(field.slice).Len()
+		if n >= 0 && l != n {
+			return fmt.Errorf("export: slice %s has length %d, want %d",
+				field.Name, l, n)
+		}
+		n = l
+		return nil
+	}
+	for _, field := range e.Columns {
+		var err error
+		switch {
+		case field.synthetic || field.compute != nil:
+		case field.expr != nil:
+			for _, operand := range field.expr.operands {
+				if err = check(operand); err != nil {
+					break
+				}
+			}
+		default:
+			err = check(field)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if n < 0 {
+		n = 0
+	}
+	e.N = n
+	memos := sharedPrefixes(e.Columns, func(c Column) int { return c.slice })
+	e.memos = memos
+	bind := func(field *Column) {
+		slice := v.Field(field.slice)
+		field.value = compileAccessor(slice, field.sliceIndir, field.access, field.typ)
+		field.bindWalker(memos.walker(field.slice, field.access, slice.Index, field.sliceIndir))
+	}
+	for fn, field := range e.Columns {
+		switch {
+		case field.compute != nil:
+			e.Columns[fn].bindComputed(e.rowFunc())
+		case field.expr != nil:
+			e.Columns[fn].bindExpr(bind)
+		case !field.synthetic:
+			bind(&e.Columns[fn])
+		}
+	}
+	return nil
+}
+
 // bindSOM is the slice-of-measurements version of Bind.
 func (e *Extractor) bindSOM(data interface{}) {
 	v := reflect.ValueOf(data)
 	e.N = v.Len()
+	e.data = v
+	e.row = func(i int) interface{} { return v.Index(i).Interface() }
+	memos := sharedPrefixes(e.Columns, func(Column) int { return 0 })
+	e.memos = memos
+	bind := func(field *Column) {
+		field.value = compileAccessor(v, e.indir, field.access, field.typ)
+		field.bindWalker(memos.walker(0, field.access, v.Index, e.indir))
+	}
 	for fn, field := range e.Columns {
-		access := field.access
-		typ := field.Type()
-		unsigned := field.unsigned
-		e.Columns[fn].value = func(i int) interface{} {
-			return retrieve(v.Index(i), access, e.indir, typ, unsigned)
+		switch {
+		case field.compute != nil:
+			e.Columns[fn].bindComputed(e.row)
+		case field.expr != nil:
+			e.Columns[fn].bindExpr(bind)
+		case !field.synthetic && !field.mapKey:
+			bind(&e.Columns[fn])
 		}
 	}
 }
@@ -267,11 +878,14 @@ func superType(t reflect.Type) Type {
 	case reflect.Bool:
 		return Bool
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
-		reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		reflect.Uint8, reflect.Uint16, reflect.Uint32:
 		if isDuration(t) {
 			return Duration
 		}
 		return Int
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		// Values above math.MaxInt64 do not fit into an int64.
+		return Uint
 	case reflect.String:
 		return String
 	case reflect.Float32, reflect.Float64:
@@ -282,6 +896,10 @@ func superType(t reflect.Type) Type {
 		if isTime(t) {
 			return Time
 		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Bytes
+		}
 	}
 	return NA
 }
@@ -296,19 +914,63 @@ func isDuration(x reflect.Type) bool {
 var (
 	errorInterface    = reflect.TypeOf((*error)(nil)).Elem()
 	stringerInterface = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
+
+	textMarshalerInterface = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerInterface = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
 )
 
+// marshalText returns the text of the encoding.TextMarshaler v; a nil v
+// (of interface type) is NA.
+func marshalText(v interface{}) (interface{}, error) {
+	m, ok := v.(encoding.TextMarshaler)
+	if !ok {
+		return nil, nil
+	}
+	text, err := m.MarshalText()
+	return string(text), err
+}
+
+// marshalJSON returns the JSON text of the json.Marshaler v or the
+// unquoted string if it is a JSON string; a nil v (of interface type) is
+// NA.
+func marshalJSON(v interface{}) (interface{}, error) {
+	m, ok := v.(json.Marshaler)
+	if !ok {
+		return nil, nil
+	}
+	text, err := m.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	var s string
+	if json.Unmarshal(text, &s) == nil {
+		return s, nil
+	}
+	return string(text), nil
+}
+
 // -------------------------------------------------------------------------
 // Steps and accessing fields/methods
 
 // step describes one step during the way down the type hierarchy.
 type step struct {
-	name    string        // the name of this element
-	indir   int           // number of ptr-indirections to take before a type is reached
-	method  reflect.Value // the function to call, if zero: not a fn call but a field access
-	field   int           // field number if method is zero
-	mayFail bool          // for methods which return (result, error)
+	name    string          // the name of this element
+	indir   int             // number of ptr-indirections to take before a type is reached
+	method  reflect.Value   // the function to call, if zero: not a fn call but a field access
+	args    []reflect.Value // additional arguments to the method call
+	field   int             // field number if method is zero
+	mayFail bool            // for methods which return (result, error)
+	ptrRecv bool            // for methods with a pointer receiver
+	key     reflect.Value   // the map key to look up, if valid
+
+	// promoted is the index sequence of a field promoted from an
+	// embedded struct.
+	promoted []int
 	// typ     reflect.Type
+
+	// convert, if non-nil, converts the value (e.g. with a registered
+	// converter) instead of a field access or method call.
+	convert func(v interface{}) (interface{}, error)
 }
 
 func (s step) isMethodCall() bool { return s.method.IsValid() }
@@ -317,46 +979,193 @@ func (s step) isMethodCall() bool { return s.method.IsValid() }
 // The Type of the final element is returend and whether the final element
 // has to be converted first.
 func buildSteps(typ reflect.Type, elem string) ([]step, Type, bool, error) {
+	steps, typ, err := walkSteps(typ, elem)
+	if err != nil {
+		return nil, NA, false, err
+	}
+	return finalSteps(typ, steps)
+}
+
+// stepNames appends the names of steps to name (separated by dots
+// except for map keys) to form a column name.
+func stepNames(name string, steps []step) string {
+	for _, s := range steps {
+		switch {
+		case s.convert != nil:
+		case s.key.IsValid() || name == "":
+			name += s.name
+		default:
+			name += "." + s.name
+		}
+	}
+	return name
+}
+
+// walkSteps constructs the steps to access elem in typ and returns the
+// type of the accessed element.
+func walkSteps(typ reflect.Type, elem string) ([]step, reflect.Type, error) {
 	var steps []step
-	elements := strings.Split(elem, ".")
+	elements, err := splitSpec(elem)
+	if err != nil {
+		return nil, typ, err
+	}
 	for _, cur := range elements {
+		cur, keys, err := splitKeys(cur)
+		if err != nil {
+			return nil, typ, err
+		}
 		var s step
-		var err error
-		if strings.HasSuffix(cur, "()") {
-			cur = cur[:len(cur)-2]
-			s, typ, err = methodStep(cur, typ)
+		if i := strings.Index(cur, "("); i >= 0 && strings.HasSuffix(cur, ")") {
+			s, typ, err = methodStep(cur[:i], cur[i+1:len(cur)-1], typ)
 			if err != nil {
-				return nil, NA, false, err
+				return nil, typ, err
 			}
 		} else {
 			s, typ, err = fieldStep(cur, typ)
 			if err != nil {
-				return nil, NA, false, err
+				return nil, typ, err
 			}
 		}
 		steps = append(steps, s)
+		for _, key := range keys {
+			s, typ, err = keyStep(key, typ)
+			if err != nil {
+				return nil, typ, err
+			}
+			steps = append(steps, s)
+		}
+	}
+	return steps, typ, nil
+}
+
+// splitKeys splits the element elem of a column specifier like
+// `Labels["region"]` into the field or method and the map keys.
+func splitKeys(elem string) (string, []string, error) {
+	i := strings.Index(elem, "[")
+	if i < 0 || strings.Contains(elem[:i], `"`) {
+		return elem, nil, nil
+	}
+	base, rest := elem[:i], elem[i:]
+	var keys []string
+	for rest != "" {
+		end := -1
+		var quote byte
+		for j := 1; j < len(rest) && end < 0; j++ {
+			c := rest[j]
+			switch {
+			case quote != 0:
+				if c == '\\' && quote != '`' {
+					j++
+				} else if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '`' || c == '\'':
+				quote = c
+			case c == ']':
+				end = j
+			}
+		}
+		if rest[0] != '[' || end < 0 {
+			return "", nil, fmt.Errorf("export: malformed map key in %s", elem)
+		}
+		keys = append(keys, strings.TrimSpace(rest[1:end]))
+		rest = rest[end+1:]
+	}
+	if base == "" {
+		return "", nil, fmt.Errorf("export: missing map in %s", elem)
+	}
+	return base, keys, nil
+}
+
+// keyStep constructs the step to look up the Go literal key in the map
+// type typ.
+func keyStep(key string, typ reflect.Type) (step, reflect.Type, error) {
+	if typ.Kind() != reflect.Map {
+		return step{}, typ, fmt.Errorf("export: type %s is not a map", typ)
+	}
+	k, err := parseArg(key, typ.Key())
+	if err != nil {
+		return step{}, typ, fmt.Errorf("export: bad key %s for %s: %v", key, typ, err)
+	}
+	typ = typ.Elem()
+	indir := 0
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+		indir++
+	}
+	s := step{
+		name:  "[" + key + "]",
+		key:   k,
+		indir: indir,
 	}
+	return s, typ, nil
+}
 
+// finalSteps checks that typ, the type reached by steps, is usable as
+// final element and appends a conversion step if needed. The Type of the
+// final element is returend and whether the final element is unsigned.
+func finalSteps(typ reflect.Type, steps []step) ([]step, Type, bool, error) {
+	if c, ok := lookupType(typ); ok {
+		convert := c.convert
+		s := step{
+			name:    typ.String(),
+			convert: func(v interface{}) (interface{}, error) { return convert(v), nil },
+		}
+		return append(steps, s), c.typ, false, nil
+	}
 	finalType := superType(typ)
 	unsigned := false
+	if finalType == Bytes && (typ.Implements(stringerInterface) ||
+		typ.Implements(textMarshalerInterface) || typ.Implements(jsonMarshalerInterface)) {
+		// Named byte slices like net.IP are better represented
+		// by their textual form.
+		finalType = NA
+	}
 
 	if finalType == NA {
+		if s, elem, ok := nullableStep(typ); ok {
+			return finalSteps(elem, append(steps, s))
+		}
+
 		// Maybe typ implements fmt.Stringer in which case we
-		// append an extra String method step.
-		if typ.Implements(stringerInterface) {
+		// append an extra String method step. Types implementing
+		// encoding.TextMarshaler or json.Marshaler get a converting
+		// step.
+		switch {
+		case typ.Implements(stringerInterface):
 			m, _ := typ.MethodByName("String")
 			s := step{
 				name:   "String",
 				method: m.Func,
 			}
 			steps = append(steps, s)
-		} else {
+		case typ.Kind() != reflect.Interface && reflect.PtrTo(typ).Implements(stringerInterface):
+			m, _ := reflect.PtrTo(typ).MethodByName("String")
+			steps = append(steps, step{
+				name:    "String",
+				method:  m.Func,
+				ptrRecv: true,
+			})
+		case typ.Implements(textMarshalerInterface):
+			steps = append(steps, step{
+				name:    "MarshalText",
+				mayFail: true,
+				convert: marshalText,
+			})
+		case typ.Implements(jsonMarshalerInterface):
+			steps = append(steps, step{
+				name:    "MarshalJSON",
+				mayFail: true,
+				convert: marshalJSON,
+			})
+		default:
 			return steps, NA, false,
-				fmt.Errorf("export: cannot use type %T", typ)
+				fmt.Errorf("export: cannot use type %s", typ)
 		}
+		finalType = String
 	} else if finalType == Int {
 		switch typ.Kind() {
-		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
 			unsigned = true
 		}
 	}
@@ -370,16 +1179,10 @@ func fieldStep(fieldName string, typ reflect.Type) (step, reflect.Type, error) {
 		return step{}, typ, fmt.Errorf("export: type %s is not a struct", typ)
 	}
 
-	var fn int = -1
-	var field reflect.StructField
-	for i := 0; i < typ.NumField(); i++ {
-		if typ.Field(i).Name == fieldName {
-			fn = i
-			field = typ.Field(i)
-			break
-		}
-	}
-	if fn == -1 {
+	// FieldByName resolves fields promoted from embedded structs
+	// according to the selector rules of Go.
+	field, ok := typ.FieldByName(fieldName)
+	if !ok {
 		return step{}, typ, fmt.Errorf("export: type %s has no field %s",
 			typ, fieldName)
 	}
@@ -392,19 +1195,139 @@ func fieldStep(fieldName string, typ reflect.Type) (step, reflect.Type, error) {
 	}
 	s := step{
 		name:  fieldName,
-		field: fn,
+		field: field.Index[0],
 		indir: indir,
 	}
+	if len(field.Index) > 1 {
+		s.promoted = field.Index
+	}
 	return s, typ, nil
 }
 
-// methodStep tries to construct step on typ with the given methodName.
+// splitSpec splits the column specifier spec at the dots which are not part
+// of a method argument list.
+func splitSpec(spec string) ([]string, error) {
+	var elements []string
+	start, depth := 0, 0
+	var quote byte
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case c == '.' && depth == 0:
+			elements = append(elements, spec[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 || depth != 0 {
+		return nil, fmt.Errorf("export: malformed column specifier %s", spec)
+	}
+	return append(elements, spec[start:]), nil
+}
+
+// splitArgs splits the argument list list at the commas which are not part
+// of a quoted string.
+func splitArgs(list string) []string {
+	if strings.TrimSpace(list) == "" {
+		return nil
+	}
+	var args []string
+	start := 0
+	var quote byte
+	for i := 0; i < len(list); i++ {
+		c := list[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == ',':
+			args = append(args, strings.TrimSpace(list[start:i]))
+			start = i + 1
+		}
+	}
+	return append(args, strings.TrimSpace(list[start:]))
+}
+
+// parseArg parses the Go literal lit as a method argument of type typ.
+// Durations may be given as quoted strings like "1h30m" too.
+func parseArg(lit string, typ reflect.Type) (reflect.Value, error) {
+	v := reflect.New(typ).Elem()
+	var err error
+	switch typ.Kind() {
+	case reflect.Bool:
+		var b bool
+		b, err = strconv.ParseBool(lit)
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var i int64
+		if isDuration(typ) && strings.HasPrefix(lit, `"`) {
+			var d time.Duration
+			if lit, err = strconv.Unquote(lit); err == nil {
+				d, err = time.ParseDuration(lit)
+			}
+			i = int64(d)
+		} else {
+			i, err = strconv.ParseInt(lit, 0, typ.Bits())
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var u uint64
+		u, err = strconv.ParseUint(lit, 0, typ.Bits())
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		f, err = strconv.ParseFloat(lit, typ.Bits())
+		v.SetFloat(f)
+	case reflect.String:
+		var s string
+		s, err = strconv.Unquote(lit)
+		v.SetString(s)
+	default:
+		err = fmt.Errorf("unsupported type %s", typ)
+	}
+	return v, err
+}
+
+// methodStep tries to construct step on typ with the given methodName
+// called with the comma separated Go literals in argList as arguments.
 // It looks for methods with signatures like
-//   func(elemtype) [bool,int,string,float,time]
+//   func(elemtype, args...) [bool,int,string,float,time]
 // or
-//   func(elemtype) ([bool,int,string,float,time], error)
-func methodStep(methodName string, typ reflect.Type) (step, reflect.Type, error) {
+//   func(elemtype, args...) ([bool,int,string,float,time], error)
+func methodStep(methodName, argList string, typ reflect.Type) (step, reflect.Type, error) {
+	lits := splitArgs(argList)
+	if isTime(typ) && len(lits) == 0 {
+		if fn, ok := timeDerivations[methodName]; ok {
+			s := step{
+				name:   methodName,
+				method: reflect.ValueOf(fn),
+			}
+			return s, reflect.TypeOf(""), nil
+		}
+	}
+
 	m, ok := typ.MethodByName(methodName)
+	ptrRecv := false
+	if !ok && typ.Kind() != reflect.Interface {
+		m, ok = reflect.PtrTo(typ).MethodByName(methodName)
+		ptrRecv = true
+	}
 	if !ok {
 		return step{}, typ,
 			fmt.Errorf("export: no method %s in %s", methodName, typ)
@@ -412,10 +1335,19 @@ func methodStep(methodName string, typ reflect.Type) (step, reflect.Type, error)
 
 	mt := m.Type
 	numOut := mt.NumOut()
-	if mt.NumIn() != 1 || (numOut != 1 && numOut != 2) {
+	if mt.IsVariadic() || mt.NumIn() != 1+len(lits) || (numOut != 1 && numOut != 2) {
 		return step{}, typ, fmt.Errorf("export: cannot use method %s of %s",
 			methodName, typ)
 	}
+	var args []reflect.Value
+	for i, lit := range lits {
+		arg, err := parseArg(lit, mt.In(1+i))
+		if err != nil {
+			return step{}, typ, fmt.Errorf("export: bad argument %s to method %s of %s: %v",
+				lit, methodName, typ, err)
+		}
+		args = append(args, arg)
+	}
 	mayFail := false
 	if numOut == 2 {
 		if mt.Out(1).Kind() == reflect.Interface &&
@@ -427,27 +1359,75 @@ func methodStep(methodName string, typ reflect.Type) (step, reflect.Type, error)
 		}
 	}
 	typ = mt.Out(0)
+	name := methodName
+	if len(lits) > 0 {
+		name += "(" + strings.Join(lits, ", ") + ")"
+	}
 	s := step{
-		name:    methodName,
+		name:    name,
 		method:  m.Func,
+		args:    args,
 		mayFail: mayFail,
+		ptrRecv: ptrRecv,
 	}
 	return s, typ, nil
 }
 
+// timeDerivations are pseudo methods on time.Time which derive groupings
+// from a time. They take precedence over real methods of time.Time with
+// the same name.
+var timeDerivations = map[string]func(time.Time) string{
+	"ISOWeek": isoWeek,
+	"Quarter": quarter,
+}
+
+// isoWeek formats the ISO 8601 week of t like "2024-W07".
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// quarter formats the quarter of t like "2024Q1".
+func quarter(t time.Time) string {
+	return fmt.Sprintf("%04dQ%d", t.Year(), (int(t.Month())+2)/3)
+}
+
 // access drills down in v according to the given steps.
 // Any nil pointer dereferenceing and method calls resulting in an non nil
 // error result in an error beeing returned.
 func access(v reflect.Value, steps []step) (reflect.Value, error) {
 	for _, s := range steps {
+		if s.convert != nil {
+			x, err := s.convert(v.Interface())
+			if err != nil {
+				return v, methodError{s.name, err}
+			}
+			if x == nil {
+				return v, fmt.Errorf("no value for %s", s.name)
+			}
+			v = reflect.ValueOf(x)
+			continue
+		}
+
 		// Step down in field or method.
 		if s.method.IsValid() {
-			// TODO: methods on pointers?
-			z := s.method.Call([]reflect.Value{v})
+			if s.ptrRecv {
+				v = addr(v)
+			}
+			z := s.method.Call(append([]reflect.Value{v}, s.args...))
 			if s.mayFail && z[1].Interface() != nil {
-				return v, fmt.Errorf("method call failed on %s", s.name)
+				return v, methodError{s.name, z[1].Interface().(error)}
 			}
 			v = z[0]
+		} else if s.key.IsValid() {
+			if v = v.MapIndex(s.key); !v.IsValid() {
+				return v, fmt.Errorf("no key %s", s.name)
+			}
+		} else if s.promoted != nil {
+			var err error
+			if v, err = v.FieldByIndexErr(s.promoted); err != nil {
+				return v, fmt.Errorf("nil embedded pointer on %s", s.name)
+			}
 		} else {
 			v = v.Field(s.field)
 		}
@@ -465,8 +1445,20 @@ func access(v reflect.Value, steps []step) (reflect.Value, error) {
 	return v, nil
 }
 
+// addr returns a pointer to v. If v is not addressable (e.g. the result of
+// a method call) the pointer points to a copy of v.
+func addr(v reflect.Value) reflect.Value {
+	if v.CanAddr() {
+		return v.Addr()
+	}
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+	return p
+}
+
 // retrieve decends v according to steps and returns the last value
-// either as bool, int64, float64, complex128, string, time.Time or time.Duration
+// either as bool, int64, float64, complex128, string, time.Time, time.Duration,
+// []byte or uint64.
 // indir is the primary number of indirections to take.
 // If no value was found due to nil pointers or method failures
 // nil is returned.
@@ -482,6 +1474,33 @@ func retrieve(v reflect.Value, steps []step, indir int, typ Type, unsigned bool)
 	if err != nil {
 		return nil
 	}
+	return canonical(res, typ, unsigned)
+}
+
+// mayFail reports whether one of steps calls a method returning an error.
+func mayFail(steps []step) bool {
+	for _, s := range steps {
+		if s.mayFail {
+			return true
+		}
+	}
+	return false
+}
+
+// methodError is the error of a failing method call during access.
+type methodError struct {
+	name string
+	err  error
+}
+
+func (e methodError) Error() string {
+	return fmt.Sprintf("method call failed on %s: %v", e.name, e.err)
+}
+
+// canonical returns res as the Go type used to represent values of typ:
+// bool, int64, float64, complex128, string, time.Time, time.Duration,
+// []byte or uint64.
+func canonical(res reflect.Value, typ Type, unsigned bool) interface{} {
 	switch typ {
 	case Bool:
 		return res.Bool()
@@ -501,6 +1520,13 @@ func retrieve(v reflect.Value, steps []step, indir int, typ Type, unsigned bool)
 		return res.Interface()
 	case Duration:
 		return time.Duration(res.Int())
+	case Bytes:
+		if res.IsNil() {
+			return nil
+		}
+		return res.Bytes()
+	case Uint:
+		return res.Uint()
 	}
 	return nil
 }
@@ -6,9 +6,12 @@ package export
 
 import (
 	"bytes"
+	"encoding"
 	"encoding/csv"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"math"
 	"reflect"
 	"strings"
@@ -286,6 +289,35 @@ func TestBind(t *testing.T) {
 	}
 }
 
+func TestAddIndexColumn(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddIndexColumn("Row", 1)
+	buf := &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(buf)}.Dump(extractor, DefaultFormat)
+	if got, want := buf.String(), "Row,S\n1,Hello\n2,World\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	extractor.Bind(table[2:])
+	if extractor.N != 2 || extractor.Columns[0].value(1) != int64(2) ||
+		extractor.Columns[1].value(1) != "A Lot" {
+		t.Errorf("Bad rebinding: N=%d", extractor.N)
+	}
+
+	cos, err := NewExtractor(Frame{X: []float64{7, 8}}, "X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	cos.AddIndexColumn("Index", 0)
+	cos.Bind(Frame{X: []float64{1, 2, 3}})
+	if cos.N != 3 || cos.Columns[0].value(2) != int64(2) {
+		t.Errorf("Bad rebinding of COS extractor: N=%d", cos.N)
+	}
+}
+
 func TestPointerFields(t *testing.T) {
 	type P struct{ A *int }
 	i, j := 1, 2
@@ -573,8 +605,8 @@ func TestRVecDumper(t *testing.T) {
 I <- c(12, 14, 14, 16)
 F <- c(3.14149, 2.71828, NA, 6.02214e+23)
 S <- c("Hello", "World", "Go", "A Lot")
-T <- c(as.POSIXct("2000-01-02 16:20:30"), as.POSIXct("2000-01-02 04:20:30"), as.POSIXct("2000-01-02 16:20:30"), as.POSIXct("2009-12-28 10:45:00"))
-D <- c(3000000000, 9000000, 0, 30000000000000)
+T <- c(as.POSIXct("2000-01-02 15:20:30", tz="UTC"), as.POSIXct("2000-01-02 03:20:30", tz="UTC"), as.POSIXct("2000-01-02 15:20:30", tz="UTC"), as.POSIXct("2009-12-28 09:45:00", tz="UTC"))
+D <- as.difftime(c(3, 0.009, 0, 30000), units = "secs")
 C <- c((3.0999999+4.19999981i), (0+9i), (0+0i), Inf)
 body.data <- data.frame(B, I, F, S, T, D, C)
 `
@@ -584,10 +616,674 @@ body.data <- data.frame(B, I, F, S, T, D, C)
 		Writer:    buf,
 		DataFrame: "body.data",
 	}
-	d.Dump(extractor, RFormat)
+	utc := RFormat
+	utc.TimeLoc = time.UTC
+	d.Dump(extractor, utc)
 	got := buf.String()
 
 	if got != want {
 		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
 	}
+	buf.Reset()
+	d.Dump(extractor, utc.WithBools(BoolYesNo))
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestRVecDumperFactors(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "I", "B")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[1].Name = "2 I"
+	extractor.Columns[2].Name = "if"
+	extractor.AddIndexColumn("x", 1)
+	extractor.AddIndexColumn("x", 0)
+
+	want := `x <- c(0, 1, 2, 3)
+x.1 <- c(1, 2, 3, 4)
+S <- factor(c("Hello", "World", "Go", "A Lot"))
+X2.I <- factor(c(12, 14, 14, 16), levels = c("16", "14", "12"), ordered = TRUE)
+if. <- c(TRUE, TRUE, FALSE, FALSE)
+df <- data.frame(x, x.1, S, X2.I, if.)
+`
+	buf := &bytes.Buffer{}
+	d := RVecDumper{
+		Writer:    buf,
+		DataFrame: "df",
+		Factors:   map[string][]string{"S": nil, "2 I": {"16", "14", "12"}},
+	}
+	d.Dump(extractor, RFormat)
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	d.Frame, d.StringsAsFactors = RTibble, true
+	d.Factors = map[string][]string{"if": {"TRUE"}}
+	extractor.Columns = extractor.Columns[2:]
+	d.Dump(extractor, RFormat)
+	want = `S <- factor(c("Hello", "World", "Go", "A Lot"))
+X2.I <- c(12, 14, 14, 16)
+if. <- factor(c(TRUE, TRUE, FALSE, FALSE), levels = c("TRUE"), ordered = TRUE)
+df <- tibble::tibble(S, X2.I, if.)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+	buf.Reset()
+	d.Frame, d.StringsAsFactors, d.Factors = RDataTable, false, nil
+	d.Dump(extractor, RFormat)
+	if got := buf.String(); !strings.HasPrefix(got, "S <- c(") ||
+		!strings.HasSuffix(got, "df <- data.table::data.table(S, X2.I, if.)\n") {
+		t.Errorf("Got:\n%s", got)
+	}
+
+	buf.Reset()
+	extractor, _ = NewExtractor(table, "T")
+	tokyo := time.FixedZone("Asia/Tokyo", 9*3600)
+	d = RVecDumper{Writer: buf}
+	d.Dump(extractor, Format{TimeFmt: RFormat.TimeFmt, TimeLoc: tokyo})
+	if got := buf.String(); !strings.HasPrefix(got, `T <- c(as.POSIXct("2000-01-03 00:20:30", tz="Asia/Tokyo"), `) {
+		t.Errorf("Got %s", got)
+	}
+	buf.Reset()
+	d.EpochTimes = true
+	d.Dump(extractor, RFormat)
+	want = `T <- structure(c(946826430, 946783230, 946826430, 1261993500), class = c("POSIXct", "POSIXt"), tzone = "")
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	for name, want := range map[string]string{
+		"": "X", "a_b": "a_b", "_a": "X_a", ".a": ".a", ".2": "X.2",
+		"Größe": "Größe", "a-b()": "a.b..", "NA": "NA.", "x.1": "x.1",
+	} {
+		if got := rName(name); got != want {
+			t.Errorf("rName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestTimeDerivations(t *testing.T) {
+	data := []struct{ T time.Time }{
+		{time.Date(2024, 2, 14, 12, 0, 0, 0, time.UTC)},
+		{time.Date(2021, 1, 1, 12, 0, 0, 0, time.UTC)}, // ISO week 53 of 2020
+		{time.Date(2019, 12, 30, 12, 0, 0, 0, time.UTC)},
+		{time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	extractor, err := NewExtractor(data, "T.ISOWeek()", "T.Quarter()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if extractor.Columns[0].Type() != String || extractor.Columns[1].Type() != String {
+		t.Fatalf("Got types %s and %s, want String",
+			extractor.Columns[0].Type(), extractor.Columns[1].Type())
+	}
+
+	want := [][2]string{
+		{"2024-W07", "2024Q1"},
+		{"2020-W53", "2021Q1"},
+		{"2020-W01", "2019Q4"},
+		{"2024-W40", "2024Q4"},
+	}
+	for i, w := range want {
+		week := extractor.Columns[0].Print(DefaultFormat, i)
+		quarter := extractor.Columns[1].Print(DefaultFormat, i)
+		if week != w[0] || quarter != w[1] {
+			t.Errorf("%d: Got %s %s, want %s %s", i, week, quarter, w[0], w[1])
+		}
+	}
+}
+
+type Frame struct {
+	X     []float64
+	Label []string
+	P     []*TT
+	Other int
+}
+
+func TestCOSExtractor(t *testing.T) {
+	fl := 2.5
+	frame := Frame{
+		X:     []float64{1, 2, 3},
+		Label: []string{"a", "b", "c"},
+		P:     []*TT{&TT{C: 1}, nil, &TT{C: 3, CP: &fl}},
+	}
+	extractor, err := NewExtractor(frame, "Label", "X", "P.C", "P.CP", "P.F().E")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if extractor.N != 3 {
+		t.Fatalf("Got N=%d, want 3", extractor.N)
+	}
+
+	want := `Label,X,P.C,P.CP,P.F.E
+a,1,1,,Hello
+b,2,,,
+c,3,3,2.5,Hello
+`
+	buf := &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(buf)}.Dump(extractor, DefaultFormat)
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	extractor.Bind(Frame{X: []float64{7}, Label: []string{"z"}, P: []*TT{nil}})
+	if extractor.N != 1 || extractor.Columns[1].value(0).(float64) != 7 {
+		t.Errorf("Bad rebinding: N=%d", extractor.N)
+	}
+}
+
+func TestCOSExtractorErrors(t *testing.T) {
+	frame := Frame{X: []float64{1, 2}, Label: []string{"a"}}
+	for i, specs := range [][]string{
+		{"X", "Label"}, // different length
+		{"Other"},      // not a slice
+		{"Missing"},    // no such field
+		{"P"},          // unusable type
+		{"P.X"},        // no such field
+	} {
+		if _, err := NewExtractor(frame, specs...); err == nil {
+			t.Errorf("%d: Missing error for %v", i, specs)
+		}
+	}
+
+	extractor, err := NewExtractor(Frame{}, "X", "Label")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Missing panic")
+		}
+	}()
+	extractor.Bind(frame)
+}
+
+func TestProgress(t *testing.T) {
+	data := make([]struct{ A int }, 25)
+	extractor, err := NewExtractor(data, "A")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var got []string
+	extractor.Progress = func(done, total int) {
+		got = append(got, fmt.Sprintf("%d/%d", done, total))
+	}
+	extractor.ProgressInterval = 10
+
+	for _, tc := range []struct {
+		dumper Dumper
+		want   string
+	}{
+		{MarkdownDumper{Writer: io.Discard}, "[10/25 20/25 25/25]"},
+		{RVecDumper{Writer: io.Discard}, "[25/25]"},
+		{JSONDumper{Writer: io.Discard, Columnar: true}, "[25/25]"},
+		{ParquetDumper{Writer: io.Discard, RowGroupSize: 8}, "[16/25 24/25 25/25]"},
+		{ParquetDumper{Writer: io.Discard, RowGroupSize: 20}, "[20/25 25/25]"},
+	} {
+		got = nil
+		if err := tc.dumper.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("%T: Unexpected error: %s", tc.dumper, err)
+		}
+		if s := fmt.Sprint(got); s != tc.want {
+			t.Errorf("%T: Got %s, want %s", tc.dumper, s, tc.want)
+		}
+	}
+
+	extractor.Bind(data[:0])
+	got = nil
+	MarkdownDumper{Writer: io.Discard}.Dump(extractor, DefaultFormat)
+	if len(got) != 0 {
+		t.Errorf("Got %v for empty data", got)
+	}
+}
+
+func TestColumnRender(t *testing.T) {
+	extractor, err := NewExtractor(table[:3], "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	labels := map[int64]string{12: "twelve", 14: "fourteen"}
+	extractor.Columns[1].Render = func(v interface{}) string { return labels[v.(int64)] }
+
+	if got := extractor.Columns[1].Print(PreciseFormat, 0); got != `"twelve"` {
+		t.Errorf("Got %s", got)
+	}
+	buf := &bytes.Buffer{}
+	if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `{"S":"Hello","I":"twelve"}
+{"S":"World","I":"fourteen"}
+{"S":"Go","I":"fourteen"}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if extractor.Columns[1].Type() != Int {
+		t.Errorf("Column type changed to %s", extractor.Columns[1].Type())
+	}
+}
+
+type point struct{ X, Y int }
+
+func (p point) String() string { return fmt.Sprintf("(%d,%d)", p.X, p.Y) }
+
+type code struct{ c string }
+
+func (c code) MarshalText() ([]byte, error) {
+	if c.c == "" {
+		return nil, someError
+	}
+	return []byte("#" + c.c), nil
+}
+
+type blob struct{ n int }
+
+func (b blob) MarshalJSON() ([]byte, error) {
+	if b.n < 0 {
+		return []byte(`"negative"`), nil
+	}
+	return []byte(fmt.Sprintf(`{"n":%d}`, b.n)), nil
+}
+
+func TestMarshalerFallbacks(t *testing.T) {
+	type R struct {
+		P point
+		C code
+		B blob
+	}
+	data := []R{{point{1, 2}, code{"a"}, blob{3}}, {point{}, code{}, blob{-1}}}
+	extractor, err := NewExtractor(data, "P", "C", "B")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for i, field := range extractor.Columns {
+		if field.Type() != String {
+			t.Errorf("Column %d %s: Got type %s", i, field.Name, field.Type())
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	errs, _ := DumpErrors(DelimitedDumper{Writer: buf, Comma: ';'}, extractor, DefaultFormat)
+	want := `P.String;C;B
+(1,2);#a;"{""n"":3}"
+(0,0);;negative
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+	if len(errs) != 1 || errs[0].Column != "C" {
+		t.Errorf("Got errors %v", errs)
+	}
+
+	// Nil marshalers of interface type are NA.
+	type I struct {
+		T encoding.TextMarshaler
+		J json.Marshaler
+	}
+	nilData := []I{{code{"b"}, blob{1}}, {}}
+	extractor, err = NewExtractor(nilData, "T", "J")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf.Reset()
+	errs, err = DumpErrors(DelimitedDumper{Writer: buf}, extractor, DefaultFormat)
+	if got, want := buf.String(), "T,J\n#b,\"{\"\"n\"\":1}\"\n,\n"; err != nil || len(errs) != 0 || got != want {
+		t.Errorf("Got %q (%v, %v), want %q", got, err, errs, want)
+	}
+
+	if _, err := NewExtractor([]struct{ M map[int]int }{}, "M"); err == nil ||
+		err.Error() != "export: cannot use type map[int]int" {
+		t.Errorf("Got error %v", err)
+	}
+}
+
+func TestUintColumns(t *testing.T) {
+	type Counter struct {
+		N uint64
+		M uint32
+	}
+	data := []Counter{{math.MaxUint64, math.MaxUint32}, {7, 8}}
+	extractor, err := NewExtractor(data, "N", "M")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if typ := extractor.Columns[0].Type(); typ != Uint {
+		t.Errorf("N: Got type %s", typ)
+	}
+	if typ := extractor.Columns[1].Type(); typ != Int {
+		t.Errorf("M: Got type %s", typ)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `{"N":18446744073709551615,"M":4294967295}
+{"N":7,"M":8}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+
+	// The binary and gob formats keep the values.
+	for _, dumper := range []Dumper{BinaryDumper{Writer: buf}, GobDumper{Writer: buf}} {
+		buf.Reset()
+		if err := dumper.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("%T: Unexpected error: %s", dumper, err)
+		}
+		var read *Extractor
+		if _, ok := dumper.(BinaryDumper); ok {
+			read, err = NewBinaryExtractor(buf.Bytes())
+		} else {
+			read, err = NewGobExtractor(buf)
+		}
+		if err != nil {
+			t.Fatalf("%T: Unexpected error: %s", dumper, err)
+		}
+		if got := read.Columns[0].value(0); got != uint64(math.MaxUint64) {
+			t.Errorf("%T: Got %v", dumper, got)
+		}
+	}
+}
+
+func TestMethodArguments(t *testing.T) {
+	extractor, err := NewExtractor(ss, `T.Format("2006.01.02")`, `T.Add("90m").Hour()`,
+		"ExtraArg(7)", `T.Round(3600000000000).Hour()`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var names []string
+	for _, field := range extractor.Columns {
+		names = append(names, field.Name)
+	}
+	want := []string{`T.Format("2006.01.02")`, `T.Add("90m").Hour`, "ExtraArg(7)", "T.Round(3600000000000).Hour"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("Got names %q, want %q", names, want)
+	}
+	for c, want := range []interface{}{"2000.01.02", int64(16), int64(12), int64(15)} {
+		if got := extractor.Columns[c].value(0); got != want {
+			t.Errorf("Column %d: Got %v, want %v", c, got, want)
+		}
+	}
+
+	for _, spec := range []string{"ExtraArg()", "ExtraArg(1, 2)", `ExtraArg("7")`,
+		"ExtraArg(1.5)", `T.Format("2006`, "T.Format(2006)", "ExtraArg(7"} {
+		if _, err := NewExtractor(ss, spec); err == nil {
+			t.Errorf("Missing error for %s", spec)
+		}
+	}
+}
+
+type tally struct {
+	N   int
+	Sub tallyLabel
+}
+
+type tallyLabel struct{ L string }
+
+func (t *tally) Inc() int            { t.N++; return t.N }
+func (t tally) Copy() tally          { return t }
+func (l *tallyLabel) String() string { return "<" + l.L + ">" }
+
+func TestPointerReceivers(t *testing.T) {
+	data := []tally{{1, tallyLabel{"a"}}, {5, tallyLabel{"b"}}}
+	extractor, err := NewExtractor(data, "Inc()", "Sub", "Copy().Inc()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if typ := extractor.Columns[1].Type(); typ != String {
+		t.Errorf("Got type %s for Sub", typ)
+	}
+	if got := extractor.Columns[0].value(1); got != int64(6) || data[1].N != 6 {
+		t.Errorf("Got %v, data %d; want call on slice element", got, data[1].N)
+	}
+	if got := extractor.Columns[1].value(0); got != "<a>" {
+		t.Errorf("Got %v", got)
+	}
+	// The result of Copy is not addressable: Inc is called on a copy.
+	if got := extractor.Columns[2].value(1); got != int64(7) || data[1].N != 6 {
+		t.Errorf("Got %v, data %d; want call on copy", got, data[1].N)
+	}
+}
+
+func TestMapKeys(t *testing.T) {
+	type Measurement struct {
+		Value  float64
+		Labels map[string]string
+		Counts map[int]*int
+	}
+	three := 3
+	data := []Measurement{
+		{1.5, map[string]string{"region": "eu", "a.b": "x"}, map[int]*int{1: &three, 2: nil}},
+		{2.5, nil, nil},
+	}
+	extractor, err := NewExtractor(data, `Labels["region"]`, `Labels["a.b"]`,
+		"Counts[1]", "Counts[2]", `Labels["zone"]`)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := extractor.Columns[0].Name; got != `Labels["region"]` {
+		t.Errorf("Got name %s", got)
+	}
+	for c, want := range []interface{}{"eu", "x", int64(3), nil, nil} {
+		if got := extractor.Columns[c].value(0); got != want {
+			t.Errorf("Column %d: Got %v, want %v", c, got, want)
+		}
+		if got := extractor.Columns[c].value(1); got != nil {
+			t.Errorf("Column %d: Got %v for nil map", c, got)
+		}
+	}
+
+	for _, spec := range []string{`Labels[region]`, `Labels["region"`, `Value["x"]`,
+		`Counts["1"]`, `["x"]`} {
+		if _, err := NewExtractor(data, spec); err == nil {
+			t.Errorf("Missing error for %s", spec)
+		}
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	one := 1
+	data := []T{{A: 5, AP: &one, B: TT{C: 2.5}}}
+	for i, tc := range []struct {
+		data  interface{}
+		specs []string
+		want  []string
+	}{
+		{data, []string{"*"}, []string{"A", "AP", "APP"}},
+		{data, []string{"B.*", "A"}, []string{"B.C", "B.CP", "A"}},
+		{Frame{}, []string{"*"}, []string{"X", "Label"}},
+		{Frame{}, []string{"P.*"}, []string{"P.C", "P.CP"}},
+		{data, []string{"*", "-AP", "B.*"}, []string{"A", "APP", "B.C", "B.CP"}},
+		{data, []string{"-B.*", "*", "B.*"}, []string{"A", "AP", "APP"}},
+		{Frame{}, []string{"*", "-X", "P.C", "-Other"}, []string{"Label", "P.C"}},
+	} {
+		extractor, err := NewExtractor(tc.data, tc.specs...)
+		if err != nil {
+			t.Errorf("%d: Unexpected error: %s", i, err)
+			continue
+		}
+		var names []string
+		for _, field := range extractor.Columns {
+			names = append(names, field.Name)
+		}
+		if !reflect.DeepEqual(names, tc.want) {
+			t.Errorf("%d: Got %q, want %q", i, names, tc.want)
+		}
+	}
+
+	for _, spec := range []string{"A.*", "X.*"} {
+		if _, err := NewExtractor(data, spec); err == nil {
+			t.Errorf("Missing error for %s", spec)
+		}
+	}
+}
+
+func TestComputedColumn(t *testing.T) {
+	type Diamond struct {
+		Price float64
+		Carat float64
+	}
+	data := []Diamond{{3000, 1.5}, {500, 0}, {1000, 0.5}}
+	extractor, err := NewExtractor(data, "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddComputedColumn("PerCarat", Float, func(row interface{}) (interface{}, error) {
+		d := row.(Diamond)
+		if d.Carat == 0 {
+			return nil, errors.New("no carat")
+		}
+		return d.Price / d.Carat, nil
+	})
+	extractor.AddComputedColumn("Bad", Int, func(row interface{}) (interface{}, error) {
+		return "x", nil
+	})
+
+	buf := &bytes.Buffer{}
+	errs, err := DumpErrors(DelimitedDumper{Writer: buf}, extractor, DefaultFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := buf.String(), "Price,PerCarat,Bad\n3000,2000,\n500,,\n1000,2000,\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if len(errs) != 4 || errs[1].Column != "PerCarat" {
+		t.Errorf("Got errors %v", errs)
+	}
+
+	// Rebinding recomputes the column.
+	extractor.Bind(data[:1])
+	if got := extractor.Columns[1].value(0); got != 2000.0 || extractor.N != 1 {
+		t.Errorf("Got %v after rebinding", got)
+	}
+
+	// For a struct of slices the row index is passed.
+	frame := Frame{X: []float64{1, 2}, Label: []string{"a", "b"}}
+	extractor, err = NewExtractor(frame, "X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddComputedColumn("Twice", Float, func(row interface{}) (interface{}, error) {
+		return 2 * frame.X[row.(int)], nil
+	})
+	if got := extractor.Columns[1].value(1); got != 4.0 {
+		t.Errorf("COS: Got %v", got)
+	}
+}
+
+type Base struct {
+	ID   int
+	Name string
+}
+
+func (b Base) Tag() string { return fmt.Sprintf("#%d", b.ID) }
+
+type Meta struct {
+	Note string
+	Name string
+}
+
+type Item struct {
+	Base
+	*Meta
+	Price float64
+}
+
+func TestEmbeddedPromotion(t *testing.T) {
+	data := []Item{
+		{Base{1, "a"}, &Meta{"n", "m"}, 2.5},
+		{Base{2, "b"}, nil, 3.5},
+	}
+	extractor, err := NewExtractor(data, "ID", "Tag()", "Note", "Base.Name", "Meta.Name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for c, want := range []interface{}{int64(1), "#1", "n", "a", "m"} {
+		if got := extractor.Columns[c].value(0); got != want {
+			t.Errorf("Column %d: Got %v, want %v", c, got, want)
+		}
+	}
+	if got := extractor.Columns[2].value(1); got != nil {
+		t.Errorf("Got %v for nil embedded pointer", got)
+	}
+	if extractor.Columns[0].Name != "ID" {
+		t.Errorf("Got name %s", extractor.Columns[0].Name)
+	}
+
+	// Name is ambiguous.
+	if _, err := NewExtractor(data, "Name"); err == nil {
+		t.Errorf("Missing error for ambiguous field")
+	}
+	extractor, err = NewExtractor(data, "*")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var names []string
+	for _, field := range extractor.Columns {
+		names = append(names, field.Name)
+	}
+	if want := []string{"ID", "Note", "Price"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Got %q, want %q", names, want)
+	}
+}
+
+func TestAppendAndFlush(t *testing.T) {
+	type Sample struct {
+		Name  string
+		Value int
+	}
+	data := make([]Sample, 1, 10)
+	data[0] = Sample{"a", 1}
+	extractor, err := NewExtractor(data, "Name", "Value")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddIndexColumn("Row", 0)
+	buf := &bytes.Buffer{}
+	first, rest := DelimitedDumper{Writer: buf}, DelimitedDumper{Writer: buf, OmitHeader: true}
+
+	if err := extractor.Flush(first, rest, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := extractor.Append([]Sample{{"b", 2}, {"c", 3}}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := extractor.Append(Sample{"d", 4}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if extractor.N != 4 {
+		t.Errorf("Got N=%d, want 4", extractor.N)
+	}
+	if data[:2][1].Name != "" {
+		t.Errorf("Append modified the bound slice")
+	}
+	extractor.Flush(first, rest, DefaultFormat)
+	extractor.Flush(first, rest, DefaultFormat)
+	want := "Row,Name,Value\n0,a,1\n1,b,2\n2,c,3\n3,d,4\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	// Bind starts over.
+	extractor.Bind(data)
+	buf.Reset()
+	extractor.Flush(first, rest, DefaultFormat)
+	if got, want := buf.String(), "Row,Name,Value\n0,a,1\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	if err := extractor.Append(3); err == nil {
+		t.Errorf("Missing error for wrong type")
+	}
+	cos, _ := NewExtractor(Frame{X: []float64{1}, Label: []string{"a"}}, "X")
+	if err := cos.Append(Frame{}); err == nil {
+		t.Errorf("Missing error for COS data")
+	}
 }
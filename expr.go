@@ -0,0 +1,434 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// expr is an arithmetic or string expression over columns used as a
+// column specifier like "Price/Carat" or `Cut + " / " + Color`.
+type expr struct {
+	root     *exprNode
+	operands []Column // operands are the columns used in the expression.
+}
+
+// exprNode is a node in the syntax tree of an expression.
+type exprNode struct {
+	op      byte        // '+', '-', '*', '/', 'n' for negation or 'c' for conv; 0 for leaves
+	x, y    *exprNode   // the arguments of op
+	typ     Type        // the type of the result
+	value   interface{} // the value of a constant leaf
+	operand int         // index of the column of a non-constant leaf or -1
+
+	// conv converts the value of x for op 'c'; errors result in NA.
+	conv func(v interface{}) (interface{}, error)
+}
+
+// isExpression reports whether the column specifier spec is an expression,
+// i.e. it contains an operator or a literal outside of method arguments
+// and map keys.
+func isExpression(spec string) bool {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.ContainsAny(spec[:1], "(\"`0123456789") {
+		return spec != ""
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(spec); i++ {
+		c := spec[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote != '`' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '`' || c == '\'':
+			quote = c
+		case c == '(' || c == '[':
+			depth++
+		case c == ')' || c == ']':
+			depth--
+		case depth == 0 && strings.IndexByte("+-*/", c) >= 0:
+			return true
+		}
+	}
+	return false
+}
+
+// exprToken is a token of an expression: An operator, a parenthesis, a
+// string ('s') or number ('n') literal or the path ('p') of an operand.
+type exprToken struct {
+	kind byte
+	text string
+}
+
+// tokenizeExpr splits spec into tokens.
+func tokenizeExpr(spec string) ([]exprToken, error) {
+	var tokens []exprToken
+	for i := 0; i < len(spec); {
+		c := spec[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("+-*/()", c) >= 0:
+			tokens = append(tokens, exprToken{kind: c, text: spec[i : i+1]})
+			i++
+		case c == '"' || c == '`':
+			j := i + 1
+			for ; j < len(spec) && spec[j] != c; j++ {
+				if spec[j] == '\\' && c == '"' {
+					j++
+				}
+			}
+			if j >= len(spec) {
+				return nil, fmt.Errorf("export: unterminated string in %s", spec)
+			}
+			s, err := strconv.Unquote(spec[i : j+1])
+			if err != nil {
+				return nil, fmt.Errorf("export: bad string %s in %s", spec[i:j+1], spec)
+			}
+			tokens = append(tokens, exprToken{kind: 's', text: s})
+			i = j + 1
+		case c >= '0' && c <= '9':
+			j := i + 1
+			for ; j < len(spec); j++ {
+				d := spec[j]
+				if !(d >= '0' && d <= '9' || d == '.' || d == 'e' || d == 'E' ||
+					(d == '-' || d == '+') && (spec[j-1] == 'e' || spec[j-1] == 'E')) {
+					break
+				}
+			}
+			tokens = append(tokens, exprToken{kind: 'n', text: spec[i:j]})
+			i = j
+		default:
+			// An operand path which may contain method arguments and
+			// map keys with arbitrary content.
+			j, depth := i, 0
+			var quote byte
+		path:
+			for ; j < len(spec); j++ {
+				d := spec[j]
+				switch {
+				case quote != 0:
+					if d == '\\' && quote != '`' {
+						j++
+					} else if d == quote {
+						quote = 0
+					}
+				case d == '"' || d == '`' || d == '\'':
+					quote = d
+				case d == '(' || d == '[':
+					depth++
+				case (d == ')' || d == ']') && depth > 0:
+					depth--
+				case depth == 0 && strings.IndexByte("+-*/() \t", d) >= 0:
+					break path
+				}
+			}
+			tokens = append(tokens, exprToken{kind: 'p', text: spec[i:j]})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// exprParser is a recursive descent parser for expressions.
+type exprParser struct {
+	spec   string
+	tokens []exprToken
+	pos    int
+	build  func(path string) (Column, error) // build constructs an operand
+	expr   *expr
+}
+
+// parseExpr parses the expression spec whose operands are constructed by
+// build.
+func parseExpr(spec string, build func(path string) (Column, error)) (*expr, error) {
+	tokens, err := tokenizeExpr(spec)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{spec: spec, tokens: tokens, build: build, expr: &expr{}}
+	root, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.errorf("unexpected %s", p.tokens[p.pos].text)
+	}
+	p.expr.root = root
+	return p.expr, nil
+}
+
+func (p *exprParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("export: bad expression %s: %s", p.spec, fmt.Sprintf(format, args...))
+}
+
+// next returns the kind of the next token or 0 at the end.
+func (p *exprParser) next() byte {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos].kind
+	}
+	return 0
+}
+
+// sum parses terms combined by + and -.
+func (p *exprParser) sum() (*exprNode, error) {
+	x, err := p.term()
+	for err == nil && (p.next() == '+' || p.next() == '-') {
+		op := p.next()
+		p.pos++
+		var y *exprNode
+		if y, err = p.term(); err == nil {
+			x, err = p.binary(op, x, y)
+		}
+	}
+	return x, err
+}
+
+// term parses factors combined by * and /.
+func (p *exprParser) term() (*exprNode, error) {
+	x, err := p.factor()
+	for err == nil && (p.next() == '*' || p.next() == '/') {
+		op := p.next()
+		p.pos++
+		var y *exprNode
+		if y, err = p.factor(); err == nil {
+			x, err = p.binary(op, x, y)
+		}
+	}
+	return x, err
+}
+
+// factor parses a negation, a parenthesized expression, a literal or an
+// operand.
+func (p *exprParser) factor() (*exprNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, p.errorf("unexpected end")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+	switch t.kind {
+	case '-':
+		x, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		switch x.typ {
+		case Int, Float, Duration:
+			return &exprNode{op: 'n', x: x, typ: x.typ, operand: -1}, nil
+		}
+		return nil, p.errorf("cannot negate %s", x.typ)
+	case '(':
+		x, err := p.sum()
+		if err != nil {
+			return nil, err
+		}
+		if p.next() != ')' {
+			return nil, p.errorf("missing )")
+		}
+		p.pos++
+		return x, nil
+	case 's':
+		return &exprNode{typ: String, value: t.text, operand: -1}, nil
+	case 'n':
+		if i, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return &exprNode{typ: Int, value: i, operand: -1}, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf("bad number %s", t.text)
+		}
+		return &exprNode{typ: Float, value: f, operand: -1}, nil
+	case 'p':
+		c, err := p.build(t.text)
+		if err != nil {
+			return nil, err
+		}
+		p.expr.operands = append(p.expr.operands, c)
+		return &exprNode{typ: c.Type(), operand: len(p.expr.operands) - 1}, nil
+	}
+	return nil, p.errorf("unexpected %s", t.text)
+}
+
+// binary returns the node for x op y after checking the types.
+func (p *exprParser) binary(op byte, x, y *exprNode) (*exprNode, error) {
+	typ := NA
+	numeric := func(t Type) bool { return t == Int || t == Uint || t == Float }
+	switch {
+	case numeric(x.typ) && numeric(y.typ):
+		switch {
+		case op == '/':
+			typ = Float
+		case x.typ == y.typ:
+			typ = x.typ
+		default:
+			typ = Float
+		}
+	case op == '+' && x.typ == String && y.typ == String:
+		typ = String
+	case (op == '+' || op == '-') && x.typ == Duration && y.typ == Duration:
+		typ = Duration
+	case op == '-' && x.typ == Time && y.typ == Time:
+		typ = Duration
+	case (op == '+' || op == '-') && x.typ == Time && y.typ == Duration,
+		op == '+' && x.typ == Duration && y.typ == Time:
+		typ = Time
+	case op == '*' && (x.typ == Duration && y.typ == Int || x.typ == Int && y.typ == Duration):
+		typ = Duration
+	case op == '/' && x.typ == Duration && y.typ == Duration:
+		typ = Float
+	}
+	if typ == NA {
+		return nil, p.errorf("cannot apply %c to %s and %s", op, x.typ, y.typ)
+	}
+	return &exprNode{op: op, x: x, y: y, typ: typ, operand: -1}, nil
+}
+
+// eval computes the value of n for row i with the given operands.
+// NA values propagate: Any NA argument yields NA.
+func (n *exprNode) eval(operands []Column, i int) interface{} {
+	switch {
+	case n.op == 0 && n.operand < 0:
+		return n.value
+	case n.op == 0:
+		return operands[n.operand].value(i)
+	}
+	x := n.x.eval(operands, i)
+	if x == nil {
+		return nil
+	}
+	if n.op == 'c' {
+		if y, err := n.conv(x); err == nil {
+			return y
+		}
+		return nil
+	}
+	if n.op == 'n' {
+		switch v := x.(type) {
+		case int64:
+			return -v
+		case float64:
+			return -v
+		case time.Duration:
+			return -v
+		}
+		return nil
+	}
+	y := n.y.eval(operands, i)
+	if y == nil {
+		return nil
+	}
+
+	switch n.typ {
+	case Int:
+		a, b := x.(int64), y.(int64)
+		switch n.op {
+		case '+':
+			return a + b
+		case '-':
+			return a - b
+		case '*':
+			return a * b
+		}
+	case Uint:
+		a, b := x.(uint64), y.(uint64)
+		switch n.op {
+		case '+':
+			return a + b
+		case '-':
+			return a - b
+		case '*':
+			return a * b
+		}
+	case String:
+		return x.(string) + y.(string)
+	case Time:
+		if d, ok := x.(time.Duration); ok {
+			return y.(time.Time).Add(d)
+		}
+		if n.op == '-' {
+			return x.(time.Time).Add(-y.(time.Duration))
+		}
+		return x.(time.Time).Add(y.(time.Duration))
+	case Duration:
+		switch a := x.(type) {
+		case time.Time:
+			return a.Sub(y.(time.Time))
+		case int64:
+			return time.Duration(a) * y.(time.Duration)
+		case time.Duration:
+			switch b := y.(type) {
+			case int64:
+				return a * time.Duration(b)
+			case time.Duration:
+				if n.op == '-' {
+					return a - b
+				}
+				return a + b
+			}
+		}
+	case Float:
+		a, b := toFloat(x), toFloat(y)
+		switch n.op {
+		case '+':
+			return a + b
+		case '-':
+			return a - b
+		case '*':
+			return a * b
+		case '/':
+			return a / b
+		}
+	}
+	return nil
+}
+
+// toFloat converts the canonical numeric value v to a float64.
+func toFloat(v interface{}) float64 {
+	switch x := v.(type) {
+	case int64:
+		return float64(x)
+	case uint64:
+		return float64(x)
+	case float64:
+		return x
+	case time.Duration:
+		return float64(x)
+	}
+	return 0
+}
+
+// bindExpr sets the value and fail functions of the expression column c
+// after binding copies of its operands with bind.
+func (c *Column) bindExpr(bind func(operand *Column)) {
+	operands := append([]Column(nil), c.expr.operands...)
+	for k := range operands {
+		bind(&operands[k])
+	}
+	root := c.expr.root
+	c.value = func(i int) interface{} { return root.eval(operands, i) }
+	c.fail = nil
+	for k := range operands {
+		if operands[k].fail != nil {
+			c.fail = func(i int) error {
+				for _, o := range operands {
+					if o.fail != nil {
+						if err := o.fail(i); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"testing"
+	"time"
+)
+
+type gem struct {
+	Cut, Color string
+	Price      int
+	Carat      float64
+	X, Y, Z    *int
+	Bought     time.Time
+	Held       time.Duration
+}
+
+func TestExpressions(t *testing.T) {
+	two, three := 2, 3
+	data := []gem{
+		{"Ideal", "E", 326, 0.25, &two, &three, &two, time1, time.Hour},
+		{"Good", "J", 500, 0.5, nil, &three, &two, time2, time.Minute},
+	}
+	for i, tc := range []struct {
+		spec string
+		typ  Type
+		want []interface{}
+	}{
+		{"Price/Carat", Float, []interface{}{1304.0, 1000.0}},
+		{"X*Y*Z", Int, []interface{}{int64(12), nil}},
+		{`Cut + " / " + Color`, String, []interface{}{"Ideal / E", "Good / J"}},
+		{"-(Price - 26) * 2", Int, []interface{}{int64(-600), int64(-948)}},
+		{"Price + Carat*4", Float, []interface{}{327.0, 502.0}},
+		{"2 * (Held + Held)", Duration, []interface{}{4 * time.Hour, 4 * time.Minute}},
+		{"Bought + Held - Bought", Duration, []interface{}{time.Hour, time.Minute}},
+		{`Bought.Format("2006.01.02") + "-" + Cut`, String,
+			[]interface{}{"2000.01.02-Ideal", "2000.01.02-Good"}},
+		{"Held / 1.5", NA, nil},
+	} {
+		extractor, err := NewExtractor(data, tc.spec)
+		if tc.typ == NA {
+			if err == nil {
+				t.Errorf("%d: Missing error for %s", i, tc.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: Unexpected error: %s", i, err)
+			continue
+		}
+		field := extractor.Columns[0]
+		if field.Name != tc.spec || field.Type() != tc.typ {
+			t.Errorf("%d: Got %s of type %s", i, field.Name, field.Type())
+		}
+		for r, want := range tc.want {
+			if got := field.value(r); got != want {
+				t.Errorf("%d: Row %d got %v, want %v", i, r, got, want)
+			}
+		}
+	}
+
+	for _, spec := range []string{"Cut * 2", "Price +", "(Price", `"abc`,
+		"Price + Unknown", "-Cut + Cut"} {
+		if _, err := NewExtractor(data, "Price", spec); err == nil {
+			t.Errorf("Missing error for %s", spec)
+		}
+	}
+}
+
+func TestExpressionsCOS(t *testing.T) {
+	frame := Frame{X: []float64{1, 2}, Label: []string{"a", "b"}}
+	extractor, err := NewExtractor(frame, `Label + ":"`, "X*X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := extractor.Columns[0].value(1); got != "b:" {
+		t.Errorf("Got %v", got)
+	}
+	if got := extractor.Columns[1].value(1); got != 4.0 {
+		t.Errorf("Got %v", got)
+	}
+
+	frame.X = frame.X[:1]
+	if err := extractor.bindCOS(frame); err == nil {
+		t.Errorf("Missing error for different slice lengths")
+	}
+}
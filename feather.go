@@ -0,0 +1,442 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// ArrowDumper dumps the values in the Apache Arrow IPC file format, also
+// known as Feather version 2, which can be read e.g. by the R package arrow
+// (read_feather) or by pandas (read_feather).
+//
+// All fields are nullable with NA values stored as nulls. Bools are stored
+// as Bool, Ints as Int64, Uints as UInt64, Floats as Float64, Strings as
+// Utf8, Times as Timestamp with microsecond resolution in UTC, Durations as
+// Duration in nanoseconds and Bytes as Binary. Complex values are formated
+// as Utf8 strings.
+type ArrowDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// Stream selects the Arrow IPC streaming format instead of the
+	// file format.
+	Stream bool
+
+	// BatchSize is the maximal number of rows in one record batch.
+	// The default is 65536 rows.
+	BatchSize int
+}
+
+// Arrow metadata constants.
+const (
+	arrowV5 = 4 // MetadataVersion
+
+	arrowHeaderSchema      = 1 // MessageHeader union
+	arrowHeaderRecordBatch = 3
+
+	arrowInt           = 2 // Type union
+	arrowFloatingPoint = 3
+	arrowBinary        = 4
+	arrowUtf8          = 5
+	arrowBool          = 6
+	arrowTimestamp     = 10
+	arrowDuration      = 18
+
+	arrowDouble      = 2 // Precision
+	arrowMicrosecond = 2 // TimeUnit
+	arrowNanosecond  = 3
+)
+
+// arrowBlock locates a record batch in an Arrow file.
+type arrowBlock struct {
+	offset, metaLength, bodyLength int64
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is used only for complex values.
+func (d ArrowDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	batchSize := d.BatchSize
+	if batchSize <= 0 {
+		batchSize = 65536
+	}
+
+	w := &countingWriter{w: d.Writer}
+	if !d.Stream {
+		if _, err := io.WriteString(w, "ARROW1\x00\x00"); err != nil {
+			return err
+		}
+	}
+	schema := arrowSchema(e)
+	msg := &fbTable{}
+	msg.scalar(0, 2, arrowV5)
+	msg.scalar(1, 1, arrowHeaderSchema)
+	msg.child(2, schema)
+	msg.scalar(3, 8, 0)
+	if _, err := writeArrowMessage(w, msg, nil); err != nil {
+		return err
+	}
+
+	var blocks []arrowBlock
+	for start := 0; start < e.N; start += batchSize {
+		end := start + batchSize
+		if end > e.N {
+			end = e.N
+		}
+		offset := w.n
+		block, err := writeArrowBatch(w, e, format, start, end)
+		if err != nil {
+			return err
+		}
+		block.offset = offset
+		blocks = append(blocks, block)
+		e.progress(start, end)
+	}
+
+	// End-of-stream marker.
+	if _, err := w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}); err != nil {
+		return err
+	}
+	if d.Stream {
+		return nil
+	}
+
+	data := make([]byte, 0, 24*len(blocks))
+	for _, b := range blocks {
+		data = binary.LittleEndian.AppendUint64(data, uint64(b.offset))
+		data = binary.LittleEndian.AppendUint64(data, uint64(b.metaLength))
+		data = binary.LittleEndian.AppendUint64(data, uint64(b.bodyLength))
+	}
+	footer := &fbTable{}
+	footer.scalar(0, 2, arrowV5)
+	footer.child(1, schema)
+	footer.child(3, fbStructs{n: len(blocks), data: data})
+	fb := fbFinish(footer)
+	fb = binary.LittleEndian.AppendUint32(fb, uint32(len(fb)))
+	_, err = w.Write(append(fb, "ARROW1"...))
+	return err
+}
+
+// arrowSchema returns the Schema table for e.
+func arrowSchema(e *Extractor) *fbTable {
+	fields := make(fbTables, len(e.Columns))
+	for i, field := range e.Columns {
+		typ := &fbTable{}
+		var typeType uint64
+		switch field.Type() {
+		case Bool:
+			typeType = arrowBool
+		case Int:
+			typeType = arrowInt
+			typ.scalar(0, 4, 64)
+			typ.scalar(1, 1, 1)
+		case Uint:
+			typeType = arrowInt
+			typ.scalar(0, 4, 64)
+			typ.scalar(1, 1, 0)
+		case Float:
+			typeType = arrowFloatingPoint
+			typ.scalar(0, 2, arrowDouble)
+		case Time:
+			typeType = arrowTimestamp
+			typ.scalar(0, 2, arrowMicrosecond)
+			typ.child(1, fbString("UTC"))
+		case Duration:
+			typeType = arrowDuration
+			typ.scalar(0, 2, arrowNanosecond)
+		case Bytes:
+			typeType = arrowBinary
+		default:
+			typeType = arrowUtf8
+		}
+		f := &fbTable{}
+		f.child(0, fbString(field.Name))
+		f.scalar(1, 1, 1) // nullable
+		f.scalar(2, 1, typeType)
+		f.child(3, typ)
+		f.child(5, fbTables{})
+		fields[i] = f
+	}
+	schema := &fbTable{}
+	schema.scalar(0, 2, 0) // little endian
+	schema.child(1, fields)
+	return schema
+}
+
+// writeArrowBatch writes the rows start to end of e as a record batch.
+func writeArrowBatch(w io.Writer, e *Extractor, format Format, start, end int) (arrowBlock, error) {
+	n := end - start
+	var body, nodes, buffers []byte
+	addBuffer := func(buf []byte) {
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(buf)))
+		body = append(body, buf...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for _, field := range e.Columns {
+		validity := make([]byte, (n+7)/8)
+		var values, data []byte
+		offsets := []byte{0, 0, 0, 0}
+		if field.Type() == Bool {
+			values = make([]byte, (n+7)/8)
+		}
+		nulls := 0
+		for r := start; r < end; r++ {
+			i := r - start
+			v := field.value(r)
+			if v != nil {
+				validity[i/8] |= 1 << uint(i%8)
+			} else {
+				nulls++
+			}
+			switch field.Type() {
+			case Bool:
+				if b, _ := v.(bool); b {
+					values[i/8] |= 1 << uint(i%8)
+				}
+			case Int:
+				x, _ := v.(int64)
+				values = binary.LittleEndian.AppendUint64(values, uint64(x))
+			case Uint:
+				x, _ := v.(uint64)
+				values = binary.LittleEndian.AppendUint64(values, x)
+			case Float:
+				x, _ := v.(float64)
+				values = binary.LittleEndian.AppendUint64(values, math.Float64bits(x))
+			case Time:
+				var x int64
+				if t, ok := v.(time.Time); ok {
+					x = t.UnixMicro()
+				}
+				values = binary.LittleEndian.AppendUint64(values, uint64(x))
+			case Duration:
+				x, _ := v.(time.Duration)
+				values = binary.LittleEndian.AppendUint64(values, uint64(x))
+			default:
+				switch x := v.(type) {
+				case string:
+					data = append(data, x...)
+				case []byte:
+					data = append(data, x...)
+				case complex128:
+					data = append(data, format.Complex(x)...)
+				}
+				offsets = binary.LittleEndian.AppendUint32(offsets, uint32(len(data)))
+			}
+		}
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(n))
+		nodes = binary.LittleEndian.AppendUint64(nodes, uint64(nulls))
+		addBuffer(validity)
+		switch field.Type() {
+		case Bool, Int, Uint, Float, Time, Duration:
+			addBuffer(values)
+		default:
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+	}
+
+	batch := &fbTable{}
+	batch.scalar(0, 8, uint64(n))
+	batch.child(1, fbStructs{n: len(e.Columns), data: nodes})
+	batch.child(2, fbStructs{n: len(buffers) / 16, data: buffers})
+	msg := &fbTable{}
+	msg.scalar(0, 2, arrowV5)
+	msg.scalar(1, 1, arrowHeaderRecordBatch)
+	msg.child(2, batch)
+	msg.scalar(3, 8, uint64(len(body)))
+	metaLength, err := writeArrowMessage(w, msg, body)
+	return arrowBlock{metaLength: metaLength, bodyLength: int64(len(body))}, err
+}
+
+// writeArrowMessage writes the encapsulated message msg followed by body
+// and returns the length of the metadata including prefix and padding.
+func writeArrowMessage(w io.Writer, msg *fbTable, body []byte) (int64, error) {
+	fb := fbFinish(msg)
+	for (8+len(fb))%8 != 0 {
+		fb = append(fb, 0)
+	}
+	prefix := []byte{0xff, 0xff, 0xff, 0xff}
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(fb)))
+	if _, err := w.Write(append(prefix, fb...)); err != nil {
+		return 0, err
+	}
+	_, err := w.Write(body)
+	return int64(8 + len(fb)), err
+}
+
+// -------------------------------------------------------------------------
+// A minimal FlatBuffers encoder. Objects are laid out front to back:
+// A table is preceded by its vtable and followed by the objects it
+// references.
+
+// fbTable is a FlatBuffers table.
+type fbTable struct {
+	slots []fbSlot
+}
+
+// fbSlot is a field of a table: Either a scalar or a reference to a child.
+type fbSlot struct {
+	present bool
+	size    int // 1, 2, 4 or 8 for scalars
+	value   uint64
+	child   interface{} // *fbTable, fbTables, fbString or fbStructs
+}
+
+// fbTables is a vector of tables.
+type fbTables []*fbTable
+
+// fbString is a string.
+type fbString string
+
+// fbStructs is a vector of n structs with 8 byte alignment.
+type fbStructs struct {
+	n    int
+	data []byte
+}
+
+func (t *fbTable) slot(i int) *fbSlot {
+	for len(t.slots) <= i {
+		t.slots = append(t.slots, fbSlot{})
+	}
+	return &t.slots[i]
+}
+
+func (t *fbTable) scalar(i int, size int, value uint64) {
+	*t.slot(i) = fbSlot{present: true, size: size, value: value}
+}
+
+func (t *fbTable) child(i int, child interface{}) {
+	*t.slot(i) = fbSlot{present: true, size: 4, child: child}
+}
+
+// fbFinish returns the FlatBuffers encoding with root table root.
+func fbFinish(root *fbTable) []byte {
+	b := &fbBuilder{buf: make([]byte, 4)}
+	pos := b.object(root)
+	binary.LittleEndian.PutUint32(b.buf, uint32(pos))
+	return b.buf
+}
+
+type fbBuilder struct {
+	buf []byte
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+// patch stores at pos the offset to target.
+func (b *fbBuilder) patch(pos, target int) {
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(target-pos))
+}
+
+// object writes obj and returns its position.
+func (b *fbBuilder) object(obj interface{}) int {
+	switch o := obj.(type) {
+	case *fbTable:
+		return b.table(o)
+	case fbString:
+		b.align(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(o)))
+		b.buf = append(append(b.buf, o...), 0)
+		return pos
+	case fbTables:
+		b.align(4)
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(len(o)))
+		b.buf = append(b.buf, make([]byte, 4*len(o))...)
+		for i, t := range o {
+			b.patch(pos+4+4*i, b.object(t))
+		}
+		return pos
+	case fbStructs:
+		for (len(b.buf)+4)%8 != 0 {
+			b.buf = append(b.buf, 0)
+		}
+		pos := len(b.buf)
+		b.buf = binary.LittleEndian.AppendUint32(b.buf, uint32(o.n))
+		b.buf = append(b.buf, o.data...)
+		return pos
+	}
+	panic("export: bad flatbuffer object")
+}
+
+// table writes the vtable and t and returns the position of t.
+func (b *fbBuilder) table(t *fbTable) int {
+	// Layout the fields ordered by decreasing size after the soffset
+	// to the vtable. The table starts 8 byte aligned.
+	offsets := make([]int, len(t.slots))
+	size := 4
+	for _, s := range []int{8, 4, 2, 1} {
+		for i, slot := range t.slots {
+			if !slot.present || slot.size != s {
+				continue
+			}
+			for size%s != 0 {
+				size++
+			}
+			offsets[i] = size
+			size += s
+		}
+	}
+
+	vtable := 4 + 2*len(t.slots)
+	for (len(b.buf)+vtable)%8 != 0 {
+		b.buf = append(b.buf, 0)
+	}
+	vpos := len(b.buf)
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(vtable))
+	b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(size))
+	for _, off := range offsets {
+		b.buf = binary.LittleEndian.AppendUint16(b.buf, uint16(off))
+	}
+
+	pos := len(b.buf)
+	b.buf = append(b.buf, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(b.buf[pos:], uint32(pos-vpos))
+	for i, slot := range t.slots {
+		if !slot.present || slot.child != nil {
+			continue
+		}
+		p := b.buf[pos+offsets[i]:]
+		switch slot.size {
+		case 1:
+			p[0] = byte(slot.value)
+		case 2:
+			binary.LittleEndian.PutUint16(p, uint16(slot.value))
+		case 4:
+			binary.LittleEndian.PutUint32(p, uint32(slot.value))
+		case 8:
+			binary.LittleEndian.PutUint64(p, slot.value)
+		}
+	}
+	for i, slot := range t.slots {
+		if slot.present && slot.child != nil {
+			b.patch(pos+offsets[i], b.object(slot.child))
+		}
+	}
+	return pos
+}
+
+func init() {
+	RegisterDumper("arrow", func(w io.Writer) Dumper {
+		return ArrowDumper{Writer: w}
+	})
+	RegisterDumper("arrows", func(w io.Writer) Dumper {
+		return ArrowDumper{Writer: w, Stream: true}
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fbRef is a FlatBuffers table at position pos in data.
+type fbRef struct {
+	data []byte
+	pos  int
+}
+
+func fbRoot(data []byte) fbRef {
+	return fbRef{data, int(binary.LittleEndian.Uint32(data))}
+}
+
+// field returns the position of field i or 0 if absent.
+func (r fbRef) field(i int) int {
+	le := binary.LittleEndian
+	vt := r.pos - int(int32(le.Uint32(r.data[r.pos:])))
+	if 4+2*i >= int(le.Uint16(r.data[vt:])) {
+		return 0
+	}
+	off := int(le.Uint16(r.data[vt+4+2*i:]))
+	if off == 0 {
+		return 0
+	}
+	return r.pos + off
+}
+
+func (r fbRef) uint(i, size int) uint64 {
+	p := r.field(i)
+	if p == 0 {
+		return 0
+	}
+	switch size {
+	case 1:
+		return uint64(r.data[p])
+	case 2:
+		return uint64(binary.LittleEndian.Uint16(r.data[p:]))
+	case 4:
+		return uint64(binary.LittleEndian.Uint32(r.data[p:]))
+	}
+	return binary.LittleEndian.Uint64(r.data[p:])
+}
+
+// deref returns the position referenced by field i.
+func (r fbRef) deref(i int) int {
+	p := r.field(i)
+	return p + int(binary.LittleEndian.Uint32(r.data[p:]))
+}
+
+func (r fbRef) table(i int) fbRef { return fbRef{r.data, r.deref(i)} }
+
+func (r fbRef) string(i int) string {
+	p := r.deref(i)
+	n := int(binary.LittleEndian.Uint32(r.data[p:]))
+	return string(r.data[p+4 : p+4+n])
+}
+
+func (r fbRef) tables(i int) []fbRef {
+	p := r.deref(i)
+	n := int(binary.LittleEndian.Uint32(r.data[p:]))
+	var refs []fbRef
+	for j := 0; j < n; j++ {
+		q := p + 4 + 4*j
+		refs = append(refs, fbRef{r.data, q + int(binary.LittleEndian.Uint32(r.data[q:]))})
+	}
+	return refs
+}
+
+// int64s returns the vector of structs of the given number of words
+// in field i as int64s.
+func (r fbRef) int64s(i, words int) []int64 {
+	p := r.deref(i)
+	if (p+4)%8 != 0 {
+		panic("misaligned struct vector")
+	}
+	n := int(binary.LittleEndian.Uint32(r.data[p:]))
+	var ints []int64
+	for q := p + 4; q < p+4+n*8*words; q += 8 {
+		ints = append(ints, int64(binary.LittleEndian.Uint64(r.data[q:])))
+	}
+	return ints
+}
+
+// readArrowMessage decodes the encapsulated message at pos.
+func readArrowMessage(t *testing.T, data []byte, pos int) (msg fbRef, body []byte, next int) {
+	if binary.LittleEndian.Uint32(data[pos:]) != 0xffffffff {
+		t.Fatalf("Missing continuation marker at %d", pos)
+	}
+	n := int(binary.LittleEndian.Uint32(data[pos+4:]))
+	if n == 0 {
+		return fbRef{}, nil, pos + 8
+	}
+	if (pos+8+n)%8 != 0 {
+		t.Errorf("Message at %d not padded", pos)
+	}
+	msg = fbRoot(data[pos+8 : pos+8+n])
+	if v := msg.uint(0, 2); v != arrowV5 {
+		t.Errorf("Got version %d", v)
+	}
+	bodyLen := int(msg.uint(3, 8))
+	body = data[pos+8+n : pos+8+n+bodyLen]
+	return msg, body, pos + 8 + n + bodyLen
+}
+
+// readArrow decodes an Arrow IPC stream starting at pos.
+func readArrow(t *testing.T, data []byte, pos int) ([]string, []uint64, [][]interface{}, []int) {
+	schema, _, pos := readArrowMessage(t, data, pos)
+	if schema.uint(1, 1) != arrowHeaderSchema {
+		t.Fatalf("First message is not a schema")
+	}
+	var names []string
+	var types []uint64
+	for _, f := range schema.table(2).tables(1) {
+		names = append(names, f.string(0))
+		types = append(types, f.uint(2, 1))
+	}
+
+	columns := make([][]interface{}, len(names))
+	var batches []int
+	for {
+		start := pos
+		msg, body, next := readArrowMessage(t, data, pos)
+		pos = next
+		if msg.data == nil {
+			break
+		}
+		batches = append(batches, start)
+		rb := msg.table(2)
+		n := int(rb.uint(0, 8))
+		buffers := rb.int64s(2, 2)
+		buffer := func() []byte {
+			b := body[buffers[0] : buffers[0]+buffers[1]]
+			buffers = buffers[2:]
+			return b
+		}
+		for c, typ := range types {
+			validity := buffer()
+			values := buffer()
+			var data []byte
+			if typ == arrowUtf8 {
+				data = buffer()
+			}
+			for i := 0; i < n; i++ {
+				if validity[i/8]&(1<<uint(i%8)) == 0 {
+					columns[c] = append(columns[c], nil)
+					continue
+				}
+				var v interface{}
+				switch typ {
+				case arrowBool:
+					v = values[i/8]&(1<<uint(i%8)) != 0
+				case arrowFloatingPoint:
+					v = math.Float64frombits(binary.LittleEndian.Uint64(values[8*i:]))
+				case arrowUtf8:
+					v = string(data[binary.LittleEndian.Uint32(values[4*i:]):binary.LittleEndian.Uint32(values[4*i+4:])])
+				default:
+					v = int64(binary.LittleEndian.Uint64(values[8*i:]))
+				}
+				columns[c] = append(columns[c], v)
+			}
+		}
+	}
+	if pos != len(data) && string(data[len(data)-6:]) != "ARROW1" {
+		t.Errorf("Trailing data after end-of-stream")
+	}
+	return names, types, columns, batches
+}
+
+func TestArrowDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, d := range []ArrowDumper{
+		{},
+		{BatchSize: 3},
+		{Stream: true},
+	} {
+		buf := &bytes.Buffer{}
+		d.Writer = buf
+		if err := d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		data := buf.Bytes()
+		pos := 0
+		if !d.Stream {
+			if string(data[:8]) != "ARROW1\x00\x00" || string(data[len(data)-6:]) != "ARROW1" {
+				t.Fatalf("Missing magic")
+			}
+			pos = 8
+		}
+		names, types, columns, batches := readArrow(t, data, pos)
+		if want := []string{"B", "I", "F", "S", "T", "D", "C", "SME"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("Got names %v, want %v", names, want)
+		}
+		if want := []uint64{arrowBool, arrowInt, arrowFloatingPoint, arrowUtf8,
+			arrowTimestamp, arrowDuration, arrowUtf8, arrowUtf8}; !reflect.DeepEqual(types, want) {
+			t.Errorf("Got types %v, want %v", types, want)
+		}
+
+		want := [][]interface{}{
+			{true, true, false, false},
+			{int64(12), int64(14), int64(14), int64(16)},
+			{3.14149, 2.71828, math.NaN(), 6.02214e23},
+			{"Hello", "World", "Go", "A Lot"},
+			{time1.UnixMicro(), time2.UnixMicro(), time1.UnixMicro(), time3.UnixMicro()},
+			{int64(3 * time.Second), int64(9 * time.Millisecond), int64(0), int64(500 * time.Minute)},
+			{"(3.1+4.2i)", "(0+9i)", "(0+0i)", "+∞"},
+			{nil, nil, nil, nil},
+		}
+		for c := range want {
+			if got := fmt.Sprint(columns[c]); got != fmt.Sprint(want[c]) {
+				t.Errorf("Column %s: got %s, want %v", names[c], got, want[c])
+			}
+		}
+
+		if d.Stream {
+			continue
+		}
+		size := int(binary.LittleEndian.Uint32(data[len(data)-10:]))
+		footer := fbRoot(data[len(data)-10-size : len(data)-10])
+		if got := len(footer.table(1).tables(1)); got != len(names) {
+			t.Errorf("Footer schema has %d fields", got)
+		}
+		blocks := footer.int64s(3, 3)
+		if len(blocks) != 3*len(batches) {
+			t.Fatalf("Got %d blocks for %d batches", len(blocks)/3, len(batches))
+		}
+		for i, start := range batches {
+			if blocks[3*i] != int64(start) {
+				t.Errorf("Block %d at %d, batch at %d", i, blocks[3*i], start)
+			}
+		}
+	}
+}
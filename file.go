@@ -0,0 +1,122 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// extensions maps lower case file extensions to format names.
+var extensions = map[string]string{
+	".arrow":   "arrow",
+	".bin":     "binary",
+	".csv":     "csv",
+	".feather": "arrow",
+	".gob":     "gob",
+	".html":    "html",
+	".json":    "json",
+	".jsonl":   "ndjson",
+	".m":       "matlab",
+	".md":      "markdown",
+	".ndjson":  "ndjson",
+	".ods":     "ods",
+	".parquet": "parquet",
+	".py":      "pandas",
+	".r":       "r",
+	".rds":     "rds",
+	".tex":     "latex",
+	".tsv":     "tsv",
+	".txt":     "tab",
+	".xlsx":    "xlsx",
+	".yaml":    "yaml",
+	".yml":     "yaml",
+}
+
+// RegisterExtension makes WriteFileAuto use the Dumper registered under
+// the format name for files with the given extension, e.g. ".parquet".
+// Extensions are matched case insensitive.
+func RegisterExtension(ext string, name string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	extensions[strings.ToLower(ext)] = name
+}
+
+// formatForPath returns the format name and the compression extension
+// (empty for uncompressed files) for the file path.
+func formatForPath(path string) (name string, compression string) {
+	base := strings.ToLower(filepath.Base(path))
+	if ext := filepath.Ext(base); compressionExtensions[ext] != "" {
+		compression = ext
+		base = strings.TrimSuffix(base, ext)
+	}
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return extensions[filepath.Ext(base)], compression
+}
+
+// WriteFileAuto dumps e in the given format to the file path.
+// The Dumper is selected by the extension of path: .csv, .tsv, .txt
+// (TabDumper), .md (Markdown), .R, .json, .ndjson, .jsonl, .html, .tex,
+// .yaml, .xlsx, .ods, .parquet, .arrow, .feather, .gob, .m (MATLAB), .py
+// (pandas), .rds (RDSDumper) and .bin (BinaryDumper) are recognised; more can be
+// added with RegisterExtension. A trailing .gz compresses the file with gzip,
+// a trailing .zst with the compressor registered as "zstd".
+func WriteFileAuto(path string, e *Extractor, format Format) error {
+	factory, err := dumperForPath(path)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, factory, e, format)
+}
+
+// dumperForPath returns the factory for Dumpers writing the format
+// selected by the extension of path, including compression.
+func dumperForPath(path string) (DumperFactory, error) {
+	name, compression := formatForPath(path)
+	if name == "" {
+		return nil, fmt.Errorf("export: unknown file extension in %s", path)
+	}
+	factory := LookupDumper(name)
+	if factory == nil {
+		return nil, fmt.Errorf("export: no dumper registered for format %q", name)
+	}
+	if compression == "" {
+		return factory, nil
+	}
+	if LookupCompressor(compressionExtensions[compression]) == nil {
+		return nil, fmt.Errorf("export: no compressor registered for %q",
+			compressionExtensions[compression])
+	}
+	return func(w io.Writer) Dumper {
+		return CompressedDumper{
+			Writer:      w,
+			Dumper:      factory,
+			Compression: compressionExtensions[compression],
+		}
+	}, nil
+}
+
+// writeFile dumps e in the given format to the file path with the Dumper
+// constructed by factory.
+func writeFile(path string, factory DumperFactory, e *Extractor, format Format) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	buf := bufio.NewWriter(file)
+	err = factory(buf).Dump(e, format)
+	if ferr := buf.Flush(); err == nil {
+		err = ferr
+	}
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
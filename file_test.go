@@ -0,0 +1,73 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatForPath(t *testing.T) {
+	for _, tc := range []struct{ path, name, compression string }{
+		{"out.csv", "csv", ""},
+		{"/some/dir/Data.CSV", "csv", ""},
+		{"out.csv.gz", "csv", ".gz"},
+		{"script.R", "r", ""},
+		{"table.md", "markdown", ""},
+		{"x.jsonl", "ndjson", ""},
+		{"archive.gz", "", ".gz"},
+		{"data.ndjson.zst", "ndjson", ".zst"},
+		{"unknown.xyz", "", ""},
+	} {
+		name, compression := formatForPath(tc.path)
+		if name != tc.name || compression != tc.compression {
+			t.Errorf("%s: got %q %q, want %q %q", tc.path,
+				name, compression, tc.name, tc.compression)
+		}
+	}
+}
+
+func TestWriteFileAuto(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "out.md")
+	if err := WriteFileAuto(path, extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	got, _ := os.ReadFile(path)
+	want := "| S | I |\n| --- | ---: |\n| Hello | 12 |\n| World | 14 |\n"
+	if string(got) != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	path = filepath.Join(dir, "out.csv.gz")
+	if err := WriteFileAuto(path, extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	file, _ := os.Open(path)
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	got, _ = io.ReadAll(gz)
+	if want := "S,I\nHello,12\nWorld,14\n"; string(got) != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	RegisterExtension(".unregistered", "no-such-format")
+	for _, name := range []string{"out.xyz", "out.unregistered", "out.csv.zst"} {
+		if err := WriteFileAuto(filepath.Join(dir, name), extractor, DefaultFormat); err == nil {
+			t.Errorf("%s: Missing error", name)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// Alignment of values in a fixed width column.
+type Alignment int
+
+const (
+	AlignDefault Alignment = iota // Numbers right, everything else left.
+	AlignLeft
+	AlignRight
+)
+
+// FixedWidth describes the layout of one column in a FixedWidthDumper.
+type FixedWidth struct {
+	// Width is the width of the column in runes. Longer values are
+	// truncated. A zero Width uses the width of the longest value
+	// (including the column name unless the header is omitted).
+	Width int
+
+	Align Alignment // Align determines on which side values are padded.
+	Pad   rune      // Pad is the padding character, 0 means space.
+}
+
+// FixedWidthDumper dumps the values in columns of fixed width as used by
+// legacy ingest formats and some scientific codes.
+type FixedWidthDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the line of column names.
+
+	// Columns contains the layout of the columns by index. Columns
+	// without a FixedWidth use the zero value.
+	Columns []FixedWidth
+
+	// Separator is output between two columns.
+	Separator string
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d FixedWidthDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	layout := make([]FixedWidth, len(e.Columns))
+	copy(layout, d.Columns)
+	for i, field := range e.Columns {
+		if layout[i].Align == AlignDefault {
+			layout[i].Align = AlignLeft
+			switch field.Type() {
+			case Int, Uint, Float, Complex, Duration:
+				layout[i].Align = AlignRight
+			}
+		}
+		if layout[i].Pad == 0 {
+			layout[i].Pad = ' '
+		}
+		if layout[i].Width > 0 {
+			continue
+		}
+		if !d.OmitHeader {
+			layout[i].Width = utf8.RuneCountInString(field.Name)
+		}
+		for r := 0; r < e.N; r++ {
+			if n := utf8.RuneCountInString(field.Print(format, r)); n > layout[i].Width {
+				layout[i].Width = n
+			}
+		}
+	}
+
+	w := bufio.NewWriter(d.Writer)
+	line := func(cell func(i int) string) {
+		for i, l := range layout {
+			if i > 0 {
+				w.WriteString(d.Separator)
+			}
+			w.WriteString(l.fit(cell(i)))
+		}
+		w.WriteString("\n")
+	}
+	if !d.OmitHeader {
+		line(func(i int) string { return e.Columns[i].Name })
+	}
+	for r := 0; r < e.N; r++ {
+		line(func(i int) string { return e.Columns[i].Print(format, r) })
+		e.progress(r, r+1)
+	}
+	return w.Flush()
+}
+
+// fit truncates or pads s to the width of f.
+func (f FixedWidth) fit(s string) string {
+	n := utf8.RuneCountInString(s)
+	if n > f.Width {
+		return string([]rune(s)[:f.Width])
+	}
+	pad := strings.Repeat(string(f.Pad), f.Width-n)
+	if f.Align == AlignRight {
+		return pad + s
+	}
+	return s + pad
+}
+
+func init() {
+	RegisterDumper("fixed", func(w io.Writer) Dumper {
+		return FixedWidthDumper{Writer: w, Separator: " "}
+	})
+}
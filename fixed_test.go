@@ -0,0 +1,56 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestFixedWidthDumper(t *testing.T) {
+	data := []struct {
+		Name  string
+		Count int
+		Flag  bool
+	}{{"Zürich", 7, true}, {"Bern", 1234567, false}}
+	extractor, err := NewExtractor(data, "Name", "Count", "Flag")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i, tc := range []struct {
+		d    FixedWidthDumper
+		want string
+	}{
+		{
+			FixedWidthDumper{Separator: "|"},
+			"Name  |  Count|Flag \nZürich|      7|true \nBern  |1234567|false\n",
+		},
+		{
+			FixedWidthDumper{
+				OmitHeader: true,
+				Columns: []FixedWidth{
+					{Width: 4},
+					{Width: 8, Pad: '0'},
+					{Width: 3, Align: AlignRight, Pad: '*'},
+				},
+			},
+			"Züri00000007tru\nBern01234567fal\n",
+		},
+		{
+			FixedWidthDumper{Columns: []FixedWidth{{Align: AlignRight}}, Separator: " "},
+			"  Name   Count Flag \nZürich       7 true \n  Bern 1234567 false\n",
+		},
+	} {
+		buf := &bytes.Buffer{}
+		tc.d.Writer = buf
+		if err := tc.d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("%d: Unexpected error: %s", i, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%d: Got:\n%s\nWant:\n%s", i, got, tc.want)
+		}
+	}
+}
@@ -5,13 +5,25 @@
 package export
 
 import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
 	"fmt"
 	"math"
+	"math/big"
 	"math/cmplx"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
-// A Formater can convert baisc types to strings.
+// A Formater can convert baisc types to strings. A Formater may also
+// have the methods Uint(u uint64) string and Bytes(b []byte) string to
+// format Uint and Bytes values. Otherwise Uints are formatted by Int if
+// they fit into an int64 and as decimal numbers else and Bytes values
+// are formatted by String as lower case hexadecimal digits.
 type Formater interface {
 	Bool(b bool) string
 	Int(i int64) string
@@ -35,20 +47,196 @@ type Format struct {
 	FloatFmt          string // Package fmt style verb for float and complex printing.
 	StringFmt         string // Package fmt style verb for string printing.
 	TimeFmt           string // A package time layout string.
-	DurationFmt       string // Either %s (human redable) or %d (nanoseconds)
+	DurationFmt       string // Either %s (human redable) or %d (nanoseconds), see DurationUnit.
 
 	// TimeLoc is the location in which times are presented.
 	// If a nil TimeLoc is used the times are presented in their
 	// original location.
 	TimeLoc *time.Location
 
+	// TimeUnit, if positive, prints times as the integer number of
+	// TimeUnits since the Unix epoch instead of using TimeFmt, e.g.
+	// Unix seconds for time.Second or Unix milliseconds for
+	// time.Millisecond. TimeUnit must divide or be a multiple of a second.
+	TimeUnit time.Duration
+
 	NARep            string // Representation of a missing value.
 	NaNRep           string // Representation of a floating point NaN.
 	PInfRep, MInfRep string // Positiv and negativ infinite. Complex uses PInf only
+
+	// NonFinite determines how NaN and infinite Float and Complex values
+	// are formatted.
+	NonFinite NonFinitePolicy
+
+	// NumberStyle selects a higher-level style for Int and Float values.
+	NumberStyle NumberStyle
+
+	// CurrencySymbol and CurrencyDigits determine the prefix and the
+	// number of decimals of values in the Currency style.
+	CurrencySymbol string
+	CurrencyDigits int
+
+	// Rounding selects rounding Float values to Precision decimals
+	// (or Precision significant digits if Significant is set) on their
+	// shortest decimal representation instead of formatting them with
+	// FloatFmt. The rounded values are printed in plain decimal notation
+	// with all kept decimals, e.g. "2.68" for 2.675 and RoundHalfUp.
+	// Rounding applies to the Percent, Currency and SI styles too.
+	Rounding    RoundingMode
+	Precision   int
+	Significant bool
+
+	// DurationRound, if positive, rounds durations to a multiple of
+	// DurationRound (halfway values away from zero) before formatting.
+	DurationRound time.Duration
+
+	// DurationUnit, if positive, prints durations as the fractional
+	// number of DurationUnits formated like Float values, e.g. "1.5"
+	// for 1500ms and a DurationUnit of time.Second.
+	DurationUnit time.Duration
+
+	// DurationClock prints durations in clock style HH:MM:SS.mmm with
+	// hours exceeding 24 if needed, e.g. "26:03:04.500". The milliseconds
+	// are omitted if DurationRound is a multiple of a second.
+	DurationClock bool
+
+	// MaxLength, if positive, truncates strings longer than MaxLength
+	// runes to MaxLength-1 runes followed by an ellipsis "…".
+	MaxLength int
+
+	// EscapeControls replaces backslashes, newlines, carriage returns
+	// and tabs in strings by \\, \n, \r and \t to keep each value
+	// on a single line.
+	EscapeControls bool
+
+	// StringQuote determines whether strings are quoted.
+	StringQuote StringQuote
+
+	// BytesEncoding determines how byte slices are encoded to strings
+	// which are then formatted like String values.
+	BytesEncoding BytesEncoding
 }
 
+// BytesEncoding is an encoding of byte slices as text.
+type BytesEncoding int
+
+const (
+	HexBytes    BytesEncoding = iota // Lower case hexadecimal digits.
+	Base64Bytes                      // Standard base64 with padding.
+	RawBytes                         // The bytes as a string.
+)
+
+// StringQuote is a rule to quote strings.
+type StringQuote int
+
+const (
+	// QuoteWithFmt formats strings with StringFmt.
+	QuoteWithFmt StringQuote = iota
+
+	// QuoteAlways quotes all strings in Go syntax, ignoring StringFmt.
+	QuoteAlways
+
+	// QuoteIfNeeded quotes strings in Go syntax which are empty, have
+	// leading or trailing spaces or contain a quote, a backslash, a
+	// comma, a semicolon or a non-printable character; other strings
+	// are printed verbatim.
+	QuoteIfNeeded
+)
+
+// NonFinitePolicy is a rule to format NaN and infinite values.
+type NonFinitePolicy int
+
+const (
+	// NonFiniteReps formats NaN and infinite values with NaNRep, PInfRep
+	// and MInfRep.
+	NonFiniteReps NonFinitePolicy = iota
+
+	// NonFiniteNA formats NaN and infinite values like NA.
+	NonFiniteNA
+
+	// NonFiniteClamp formats infinite values as the largest finite
+	// float64 of the same sign and NaN values like NA.
+	NonFiniteClamp
+)
+
+// RoundingMode is a rule to round decimal numbers.
+type RoundingMode int
+
+const (
+	// NoRounding formats Float values with FloatFmt.
+	NoRounding RoundingMode = iota
+
+	// RoundHalfUp rounds ties away from zero: 2.5 to 3 and -2.5 to -3.
+	RoundHalfUp
+
+	// RoundHalfEven rounds ties to the even neighbour (banker's
+	// rounding): 2.5 to 2 and 3.5 to 4.
+	RoundHalfEven
+)
+
+// NumberStyle is a style of presenting Int and Float values.
+type NumberStyle int
+
+const (
+	// PlainNumber formats the values with IntFmt and FloatFmt.
+	PlainNumber NumberStyle = iota
+
+	// Percent multiplies the values by 100 and appends a "%".
+	Percent
+
+	// Currency prefixes the value rounded to CurrencyDigits decimals
+	// with CurrencySymbol, e.g. "$1234.50" or "-€0.99".
+	Currency
+
+	// SI formats the values with FloatFmt scaled by a power of 1000
+	// with a SI prefix as suffix, e.g. "1.2k" or "3.4M".
+	SI
+)
+
+// siPrefixes are the SI prefixes for 10^-24 to 10^24 in steps of 1000.
+var siPrefixes = []string{"y", "z", "a", "f", "p", "n", "\u00b5", "m", "",
+	"k", "M", "G", "T", "P", "E", "Z", "Y"}
+
 var _ Formater = Format{} // Make sure Format satisfies Formater.
 
+// formatUint formats u with the Uint method of f if it has one.
+func formatUint(f Formater, u uint64) string {
+	if uf, ok := f.(interface{ Uint(u uint64) string }); ok {
+		return uf.Uint(u)
+	}
+	if u <= math.MaxInt64 {
+		return f.Int(int64(u))
+	}
+	return strconv.FormatUint(u, 10)
+}
+
+// formatBytes formats b with the Bytes method of f if it has one.
+func formatBytes(f Formater, b []byte) string {
+	if bf, ok := f.(interface{ Bytes(b []byte) string }); ok {
+		return bf.Bytes(b)
+	}
+	return f.String(hex.EncodeToString(b))
+}
+
+// BoolStyle is a pair of representations of true and false.
+type BoolStyle struct {
+	True, False string
+}
+
+// Common representations of booleans.
+var (
+	BoolTrueFalse = BoolStyle{"true", "false"}
+	BoolOneZero   = BoolStyle{"1", "0"}
+	BoolYesNo     = BoolStyle{"yes", "no"}
+	BoolTF        = BoolStyle{"T", "F"}
+)
+
+// WithBools returns f with TrueRep and FalseRep set according to style.
+func (f Format) WithBools(style BoolStyle) Format {
+	f.TrueRep, f.FalseRep = style.True, style.False
+	return f
+}
+
 func (f Format) Bool(b bool) string {
 	if b {
 		return f.TrueRep
@@ -56,41 +244,400 @@ func (f Format) Bool(b bool) string {
 	return f.FalseRep
 }
 func (f Format) Int(i int64) string {
+	switch f.NumberStyle {
+	case Percent:
+		if i < math.MinInt64/100 || i > math.MaxInt64/100 {
+			return f.bigPercent(big.NewInt(i))
+		}
+		return f.int(i*100) + "%"
+	case Currency, SI:
+		return f.Float(float64(i))
+	}
+	return f.int(i)
+}
+
+// bigPercent formats x as a percentage with IntFmt for integers whose
+// percentage overflows 64 bits.
+func (f Format) bigPercent(x *big.Int) string {
+	return fmt.Sprintf(f.IntFmt, x.Mul(x, big.NewInt(100))) + "%"
+}
+
+// int formats i with IntFmt. Like all formatting with the package fmt
+// style verbs of f the common verbs are handled by package strconv
+// directly as fmt.Sprintf is much slower.
+func (f Format) int(i int64) string {
+	if f.IntFmt == "%d" {
+		return strconv.FormatInt(i, 10)
+	}
 	return fmt.Sprintf(f.IntFmt, i)
 }
+func (f Format) Uint(u uint64) string {
+	switch f.NumberStyle {
+	case Percent:
+		if u > math.MaxUint64/100 {
+			return f.bigPercent(new(big.Int).SetUint64(u))
+		}
+		return f.uint(u*100) + "%"
+	case Currency, SI:
+		return f.Float(float64(u))
+	}
+	return f.uint(u)
+}
+
+// uint formats u with IntFmt.
+func (f Format) uint(u uint64) string {
+	if f.IntFmt == "%d" {
+		return strconv.FormatUint(u, 10)
+	}
+	return fmt.Sprintf(f.IntFmt, u)
+}
 func (f Format) Float(x float64) string {
 	switch {
+	case math.IsNaN(x) && f.NonFinite != NonFiniteReps:
+		return f.NA()
 	case math.IsNaN(x):
 		return f.NaNRep
-	case math.IsInf(x, -1):
+	case !math.IsInf(x, 0):
+	case f.NonFinite == NonFiniteNA:
+		return f.NA()
+	case f.NonFinite == NonFiniteClamp:
+		x = clamp(x)
+	case x < 0:
 		return f.MInfRep
-	case math.IsInf(x, +1):
-		return f.PInfRep
 	default:
-		return fmt.Sprintf(f.FloatFmt, x)
+		return f.PInfRep
+	}
+	switch f.NumberStyle {
+	case Percent:
+		return f.number(x*100) + "%"
+	case Currency:
+		s := strconv.FormatFloat(x, 'f', f.CurrencyDigits, 64)
+		if f.Rounding != NoRounding {
+			s = roundDecimal(x, f.Rounding, f.CurrencyDigits, false)
+		}
+		if strings.HasPrefix(s, "-") {
+			return "-" + f.CurrencySymbol + s[1:]
+		}
+		return f.CurrencySymbol + s
+	case SI:
+		return f.si(x)
 	}
+	return f.number(x)
 }
+
+// number formats x according to Rounding or FloatFmt.
+func (f Format) number(x float64) string {
+	if f.Rounding != NoRounding {
+		return roundDecimal(x, f.Rounding, f.Precision, f.Significant)
+	}
+	if verb, prec, ok := floatVerb(f.FloatFmt); ok {
+		return strconv.FormatFloat(x, verb, prec, 64)
+	}
+	return fmt.Sprintf(f.FloatFmt, x)
+}
+
+// appendValue appends the non-NA canonical value val formatted by f to dst
+// for the cases handled by package strconv in the methods of f and reports
+// whether it did so.
+func (f Format) appendValue(dst []byte, val interface{}) ([]byte, bool) {
+	plain := f.NumberStyle == PlainNumber
+	switch v := val.(type) {
+	case bool:
+		return append(dst, f.Bool(v)...), true
+	case int64:
+		if plain && f.IntFmt == "%d" {
+			return strconv.AppendInt(dst, v, 10), true
+		}
+	case uint64:
+		if plain && f.IntFmt == "%d" {
+			return strconv.AppendUint(dst, v, 10), true
+		}
+	case float64:
+		if verb, prec, ok := floatVerb(f.FloatFmt); ok && plain && f.Rounding == NoRounding &&
+			!math.IsNaN(v) && !math.IsInf(v, 0) {
+			return strconv.AppendFloat(dst, v, verb, prec, 64), true
+		}
+	case string:
+		if f.StringQuote == QuoteWithFmt && f.MaxLength <= 0 && !f.EscapeControls {
+			switch f.StringFmt {
+			case "%s", "%v":
+				return append(dst, v...), true
+			case "%q":
+				return strconv.AppendQuote(dst, v), true
+			}
+		}
+	case time.Time:
+		if f.TimeUnit > 0 {
+			return strconv.AppendInt(dst, unixTime(v, f.TimeUnit), 10), true
+		}
+		if f.TimeLoc != nil {
+			v = v.In(f.TimeLoc)
+		}
+		return v.AppendFormat(dst, f.TimeFmt), true
+	case time.Duration:
+		if f.DurationFmt == "%d" && f.DurationRound <= 0 && f.DurationUnit <= 0 && !f.DurationClock {
+			return strconv.AppendInt(dst, int64(v), 10), true
+		}
+	}
+	return dst, false
+}
+
+// floatVerb parses the verbs %e, %f and %g with an optional precision like
+// "%.4g" for which strconv.FormatFloat formats finite values exactly like
+// package fmt. The precision is -1 for the shortest representation.
+func floatVerb(format string) (verb byte, prec int, ok bool) {
+	if len(format) < 2 || format[0] != '%' {
+		return 0, 0, false
+	}
+	verb = format[len(format)-1]
+	if verb != 'e' && verb != 'f' && verb != 'g' {
+		return 0, 0, false
+	}
+	switch spec := format[1 : len(format)-1]; {
+	case spec == "" && verb == 'g':
+		return verb, -1, true
+	case spec == "":
+		return verb, 6, true
+	case spec[0] == '.' && len(spec) > 1 && len(spec) <= 3:
+		prec, err := strconv.Atoi(spec[1:])
+		return verb, prec, err == nil && prec >= 0
+	}
+	return 0, 0, false
+}
+
+// si formats x in the SI style.
+func (f Format) si(x float64) string {
+	if x == 0 {
+		return f.number(x)
+	}
+	p := int(math.Floor(math.Log10(math.Abs(x)) / 3))
+	for {
+		if p < -8 {
+			p = -8
+		} else if p > 8 {
+			p = 8
+		}
+		s := f.number(x / math.Pow(1000, float64(p)))
+		// Rounding may yield e.g. "1000" for 999999.
+		if m, err := strconv.ParseFloat(s, 64); p < 8 && err == nil && math.Abs(m) >= 1000 {
+			p++
+			continue
+		}
+		return s + siPrefixes[p+8]
+	}
+}
+
+// roundDecimal rounds the finite x on its shortest decimal representation
+// to prec decimals (or prec significant digits) and formats the result in
+// plain decimal notation.
+func roundDecimal(x float64, mode RoundingMode, prec int, significant bool) string {
+	// Digits d and exponent e of x = 0.d * 10^e.
+	mantissa, exp, _ := strings.Cut(strconv.FormatFloat(math.Abs(x), 'e', -1, 64), "e")
+	digits := []byte(strings.Replace(mantissa, ".", "", 1))
+	e, _ := strconv.Atoi(exp)
+	e++
+	if x == 0 {
+		digits, e = nil, 1
+	}
+
+	keep := e + prec // number of digits to keep
+	if significant {
+		if prec < 1 {
+			prec = 1
+		}
+		keep = prec
+	}
+	if keep < 0 {
+		digits, keep = nil, 0
+	}
+	if keep < len(digits) {
+		up := digits[keep] > '5' || (digits[keep] == '5' && len(strings.Trim(string(digits[keep+1:]), "0")) > 0)
+		if digits[keep] == '5' && !up {
+			// An exact tie.
+			switch mode {
+			case RoundHalfUp:
+				up = true
+			case RoundHalfEven:
+				up = keep > 0 && (digits[keep-1]-'0')%2 == 1
+			}
+		}
+		digits = digits[:keep]
+		if up {
+			i := keep - 1
+			for ; i >= 0 && digits[i] == '9'; i-- {
+				digits[i] = '0'
+			}
+			if i >= 0 {
+				digits[i]++
+			} else {
+				digits = append([]byte{'1'}, digits...)
+				e++
+			}
+		}
+	}
+
+	decimals := prec
+	if significant {
+		decimals = prec - e
+		if decimals < 0 {
+			decimals = 0
+		}
+	}
+	// Pad digits to cover the integer part and all decimals.
+	if e <= 0 {
+		digits = append(bytes.Repeat([]byte{'0'}, 1-e), digits...)
+		e = 1
+	}
+	for len(digits) < e+decimals {
+		digits = append(digits, '0')
+	}
+	s := string(digits[:e])
+	if decimals > 0 {
+		s += "." + string(digits[e:e+decimals])
+	}
+	if x < 0 && strings.Trim(s, "0.") != "" {
+		s = "-" + s
+	}
+	return s
+}
+
 func (f Format) String(s string) string {
+	s = f.truncate(s)
+	if f.EscapeControls {
+		s = controlEscaper.Replace(s)
+	}
+	switch f.StringQuote {
+	case QuoteAlways:
+		return strconv.Quote(s)
+	case QuoteIfNeeded:
+		if needsQuotes(s) {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+	switch f.StringFmt {
+	case "%s", "%v":
+		return s
+	case "%q":
+		return strconv.Quote(s)
+	}
 	return fmt.Sprintf(f.StringFmt, s)
 }
+
+var controlEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+// truncate shortens s to MaxLength runes.
+func (f Format) truncate(s string) string {
+	if f.MaxLength <= 0 || utf8.RuneCountInString(s) <= f.MaxLength {
+		return s
+	}
+	runes := []rune(s)
+	return string(runes[:f.MaxLength-1]) + "\u2026"
+}
+
+// needsQuotes reports whether s must be quoted in the QuoteIfNeeded style.
+func needsQuotes(s string) bool {
+	if s == "" || strings.TrimSpace(s) != s {
+		return true
+	}
+	for _, r := range s {
+		if strings.ContainsRune("\"\\,;", r) || !unicode.IsPrint(r) {
+			return true
+		}
+	}
+	return false
+}
 func (f Format) Time(t time.Time) string {
+	if f.TimeUnit > 0 {
+		return strconv.FormatInt(unixTime(t, f.TimeUnit), 10)
+	}
 	if f.TimeLoc != nil {
 		t = t.In(f.TimeLoc)
 	}
 	return t.Format(f.TimeFmt)
 }
 func (f Format) Duration(d time.Duration) string {
+	if f.DurationRound > 0 {
+		d = d.Round(f.DurationRound)
+	}
+	switch {
+	case f.DurationUnit > 0:
+		return f.number(float64(d) / float64(f.DurationUnit))
+	case f.DurationClock:
+		return f.clock(d)
+	}
+	switch f.DurationFmt {
+	case "%s", "%v":
+		return d.String()
+	case "%d":
+		return strconv.FormatInt(int64(d), 10)
+	}
 	return fmt.Sprintf(f.DurationFmt, d)
 }
+
+// unixTime returns t as the number of units since the Unix epoch.
+func unixTime(t time.Time, unit time.Duration) int64 {
+	if unit >= time.Second {
+		n, u := t.Unix(), int64(unit/time.Second)
+		if n < 0 && n%u != 0 {
+			return n/u - 1
+		}
+		return n / u
+	}
+	return t.Unix()*int64(time.Second/unit) + int64(t.Nanosecond())/int64(unit)
+}
+
+// clock formats d in the DurationClock style.
+func (f Format) clock(d time.Duration) string {
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	ms := d / time.Millisecond
+	s := fmt.Sprintf("%s%02d:%02d:%02d", sign, ms/3600000, ms/60000%60, ms/1000%60)
+	if f.DurationRound <= 0 || f.DurationRound%time.Second != 0 {
+		s += fmt.Sprintf(".%03d", ms%1000)
+	}
+	return s
+}
+func (f Format) Bytes(b []byte) string {
+	return f.String(f.encode(b))
+}
+
+// encode encodes b according to BytesEncoding.
+func (f Format) encode(b []byte) string {
+	switch f.BytesEncoding {
+	case Base64Bytes:
+		return base64.StdEncoding.EncodeToString(b)
+	case RawBytes:
+		return string(b)
+	}
+	return hex.EncodeToString(b)
+}
 func (f Format) Complex(c complex128) string {
 	switch {
+	case cmplx.IsNaN(c) && f.NonFinite != NonFiniteReps:
+		return f.NA()
 	case cmplx.IsNaN(c):
 		return f.NaNRep
-	case cmplx.IsInf(c):
-		return f.PInfRep
+	case !cmplx.IsInf(c):
+	case f.NonFinite == NonFiniteNA:
+		return f.NA()
+	case f.NonFinite == NonFiniteClamp:
+		c = complex(clamp(real(c)), clamp(imag(c)))
 	default:
-		return fmt.Sprintf(f.FloatFmt, c)
+		return f.PInfRep
 	}
+	return fmt.Sprintf(f.FloatFmt, c)
+}
+
+// clamp returns the largest finite float64 with the sign of x if x is
+// infinite and x otherwise.
+func clamp(x float64) float64 {
+	if math.IsInf(x, 0) {
+		return math.Copysign(math.MaxFloat64, x)
+	}
+	return x
 }
 func (f Format) NA() string {
 	return f.NARep
@@ -131,7 +678,8 @@ var PreciseFormat = Format{
 }
 
 // RFormat contains formating options usefull if you want to
-// read the generated dumps into R.
+// read the generated dumps into R. RVecDumper dumps the times as POSIXct
+// in the time zone of TimeLoc and the durations as difftime seconds.
 var RFormat = Format{
 	TrueRep:     "TRUE",
 	FalseRep:    "FALSE",
@@ -140,7 +688,7 @@ var RFormat = Format{
 	StringFmt:   "%q",
 	TimeFmt:     `as.POSIXct("2006-01-02 15:04:05")`,
 	DurationFmt: "%d",
-	TimeLoc:     time.Local, // I have no idea how timezones work in R. Sorry.
+	TimeLoc:     time.Local,
 	NARep:       "NA",
 	NaNRep:      "NA",
 	PInfRep:     "Inf",
@@ -0,0 +1,496 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestNumberStyles(t *testing.T) {
+	percent, currency, si := DefaultFormat, DefaultFormat, DefaultFormat
+	percent.NumberStyle = Percent
+	currency.NumberStyle = Currency
+	currency.CurrencySymbol, currency.CurrencyDigits = "$", 2
+	si.NumberStyle, si.FloatFmt = SI, "%.3g"
+
+	for i, tc := range []struct {
+		f    Format
+		v    interface{}
+		want string
+	}{
+		{percent, 0.125, "12.5%"},
+		{percent, int64(3), "300%"},
+		{percent, int64(math.MaxInt64), "922337203685477580700%"},
+		{percent, int64(math.MinInt64), "-922337203685477580800%"},
+		{percent, uint64(math.MaxUint64), "1844674407370955161500%"},
+		{percent, uint64(math.MaxUint64 / 100), "18446744073709551600%"},
+		{percent, math.NaN(), ""},
+		{currency, 1234.5, "$1234.50"},
+		{currency, -0.987, "-$0.99"},
+		{currency, int64(7), "$7.00"},
+		{si, 1234.0, "1.23k"},
+		{si, int64(-3400000), "-3.4M"},
+		{si, 0.0123, "12.3m"},
+		{si, 999999.0, "1M"},
+		{si, 0.0, "0"},
+		{si, 5.0, "5"},
+		{si, 2e30, "2e+06Y"},
+		{si, math.Inf(1), "+∞"},
+	} {
+		var got string
+		switch v := tc.v.(type) {
+		case int64:
+			got = tc.f.Int(v)
+		case uint64:
+			got = tc.f.Uint(v)
+		case float64:
+			got = tc.f.Float(v)
+		}
+		if got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestColumnFormat(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	f := DefaultFormat
+	f.NumberStyle, f.CurrencySymbol, f.CurrencyDigits = Currency, "€", 1
+	extractor.Columns[1].Format = &f
+
+	buf := &bytes.Buffer{}
+	if err := (JSONDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `[
+{"S":"Hello","F":"€3.1"},
+{"S":"World","F":"€2.7"}
+]
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
+
+func TestRounding(t *testing.T) {
+	for i, tc := range []struct {
+		x           float64
+		mode        RoundingMode
+		prec        int
+		significant bool
+		want        string
+	}{
+		{2.675, RoundHalfUp, 2, false, "2.68"},
+		{2.665, RoundHalfEven, 2, false, "2.66"},
+		{2.675, RoundHalfEven, 2, false, "2.68"},
+		{2.5, RoundHalfEven, 0, false, "2"},
+		{3.5, RoundHalfEven, 0, false, "4"},
+		{-2.5, RoundHalfUp, 0, false, "-3"},
+		{0.5, RoundHalfEven, 0, false, "0"},
+		{0.5, RoundHalfUp, 0, false, "1"},
+		{2.5000001, RoundHalfEven, 0, false, "3"},
+		{9.995, RoundHalfUp, 2, false, "10.00"},
+		{0.004, RoundHalfUp, 1, false, "0.0"},
+		{-0.004, RoundHalfUp, 2, false, "0.00"},
+		{0.0123, RoundHalfUp, 3, false, "0.012"},
+		{12, RoundHalfUp, 2, false, "12.00"},
+		{0, RoundHalfUp, 1, false, "0.0"},
+		{1234.5, RoundHalfUp, 3, true, "1230"},
+		{0.012345, RoundHalfEven, 3, true, "0.0123"},
+		{9.99, RoundHalfUp, 2, true, "10"},
+		{1.5e21, RoundHalfUp, 0, false, "1500000000000000000000"},
+	} {
+		if got := roundDecimal(tc.x, tc.mode, tc.prec, tc.significant); got != tc.want {
+			t.Errorf("%d: roundDecimal(%g) = %q, want %q", i, tc.x, got, tc.want)
+		}
+	}
+
+	f := DefaultFormat
+	f.Rounding, f.Precision = RoundHalfEven, 1
+	if got := f.Float(0.25); got != "0.2" {
+		t.Errorf("Float: Got %q", got)
+	}
+	f.NumberStyle, f.CurrencySymbol, f.CurrencyDigits = Currency, "$", 2
+	if got := f.Float(-1.005); got != "-$1.00" {
+		t.Errorf("Currency: Got %q", got)
+	}
+	f.NumberStyle = Percent
+	if got := f.Float(0.1234); got != "12.3%" {
+		t.Errorf("Percent: Got %q", got)
+	}
+}
+
+func TestWithBools(t *testing.T) {
+	for _, tc := range []struct {
+		style   BoolStyle
+		yes, no string
+	}{
+		{BoolTrueFalse, "true", "false"},
+		{BoolOneZero, "1", "0"},
+		{BoolYesNo, "yes", "no"},
+		{BoolTF, "T", "F"},
+	} {
+		f := DefaultFormat.WithBools(tc.style)
+		if f.Bool(true) != tc.yes || f.Bool(false) != tc.no {
+			t.Errorf("Got %s/%s, want %s/%s", f.Bool(true), f.Bool(false), tc.yes, tc.no)
+		}
+	}
+	if DefaultFormat.TrueRep != "true" {
+		t.Errorf("DefaultFormat modified")
+	}
+}
+
+func TestDurationStyles(t *testing.T) {
+	d := 26*time.Hour + 3*time.Minute + 4*time.Second + 500*time.Millisecond + 700*time.Microsecond
+
+	seconds := DefaultFormat
+	seconds.DurationUnit, seconds.FloatFmt = time.Second, "%g"
+	millis := seconds
+	millis.DurationUnit, millis.DurationRound = time.Millisecond, time.Millisecond
+	millis.FloatFmt = "%.0f"
+	clock := DefaultFormat
+	clock.DurationClock = true
+	rounded := clock
+	rounded.DurationRound = time.Second
+	hours := DefaultFormat
+	hours.DurationUnit, hours.Rounding, hours.Precision = time.Hour, RoundHalfUp, 2
+
+	for i, tc := range []struct {
+		f    Format
+		d    time.Duration
+		want string
+	}{
+		{DefaultFormat, d, "26h3m4.5007s"},
+		{seconds, d, "93784.5007"},
+		{seconds, -1500 * time.Millisecond, "-1.5"},
+		{millis, d, "93784501"},
+		{clock, d, "26:03:04.500"},
+		{clock, -90 * time.Second, "-00:01:30.000"},
+		{rounded, d, "26:03:05"},
+		{hours, 90 * time.Minute, "1.50"},
+	} {
+		if got := tc.f.Duration(tc.d); got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestNonFinite(t *testing.T) {
+	na := PreciseFormat
+	na.NARep, na.NonFinite = "NULL", NonFiniteNA
+	clamped := na
+	clamped.NonFinite = NonFiniteClamp
+	inf, nan := math.Inf(1), math.NaN()
+
+	for i, tc := range []struct {
+		f    Format
+		x    interface{}
+		want string
+	}{
+		{PreciseFormat, inf, PreciseFormat.PInfRep},
+		{PreciseFormat, -inf, PreciseFormat.MInfRep},
+		{PreciseFormat, nan, PreciseFormat.NaNRep},
+		{na, inf, "NULL"},
+		{na, -inf, "NULL"},
+		{na, nan, "NULL"},
+		{na, 1.5, "1.5"},
+		{na, complex(inf, 1), "NULL"},
+		{clamped, inf, "1.7976931348623157e+308"},
+		{clamped, -inf, "-1.7976931348623157e+308"},
+		{clamped, nan, "NULL"},
+		{clamped, complex(1, -inf), "(1-1.7976931348623157e+308i)"},
+		{clamped, complex(nan, 1), "NULL"},
+	} {
+		var got string
+		switch x := tc.x.(type) {
+		case float64:
+			got = tc.f.Float(x)
+		case complex128:
+			got = tc.f.Complex(x)
+		}
+		if got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestTimeUnit(t *testing.T) {
+	tm := time.Date(2009, 11, 10, 23, 0, 0, 123456789, time.UTC)
+	before := time.Date(1969, 12, 31, 23, 59, 58, 500000000, time.UTC)
+	for i, tc := range []struct {
+		unit time.Duration
+		t    time.Time
+		want string
+	}{
+		{time.Second, tm, "1257894000"},
+		{time.Millisecond, tm, "1257894000123"},
+		{time.Microsecond, tm, "1257894000123456"},
+		{time.Nanosecond, tm, "1257894000123456789"},
+		{time.Hour, tm, "349415"},
+		{time.Second, before, "-2"},
+		{time.Millisecond, before, "-1500"},
+		{time.Minute, before, "-1"},
+	} {
+		f := DefaultFormat
+		f.TimeUnit = tc.unit
+		if got := f.Time(tc.t); got != tc.want {
+			t.Errorf("%d: Got %s, want %s", i, got, tc.want)
+		}
+	}
+
+	f := PreciseFormat
+	f.TimeUnit = time.Second
+	if got := (jsonFormat{f}).Time(tm); got != "1257894000" {
+		t.Errorf("JSON: Got %s", got)
+	}
+}
+
+func TestColumnTimeLoc(t *testing.T) {
+	extractor, err := NewExtractor(table[:1], "T", "T")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	utc := DefaultFormat
+	utc.TimeLoc, utc.TimeFmt = time.UTC, time.RFC3339
+	extractor.Columns[1].Format = &utc
+	tokyo := DefaultFormat
+	tokyo.TimeLoc = time.FixedZone("JST", 9*3600)
+
+	buf := &bytes.Buffer{}
+	if err := (DelimitedDumper{Writer: buf, Comma: ','}).Dump(extractor, tokyo); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := buf.String(), "T,T\n2000-01-03T00:20:30,2000-01-02T15:20:30Z\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
+
+func TestStringQuoting(t *testing.T) {
+	escape := DefaultFormat
+	escape.EscapeControls = true
+	short := DefaultFormat
+	short.MaxLength = 5
+	always := DefaultFormat
+	always.StringQuote = QuoteAlways
+	needed := escape
+	needed.StringQuote = QuoteIfNeeded
+
+	for i, tc := range []struct {
+		f       Format
+		s, want string
+	}{
+		{DefaultFormat, "a\tb\nc", "a\tb\nc"},
+		{escape, "a\tb\nc\\d\r", `a\tb\nc\\d\r`},
+		{short, "Hello", "Hello"},
+		{short, "Hello World", "Hell…"},
+		{short, "Grüße!", "Grüß…"},
+		{always, "Hello", `"Hello"`},
+		{always, `say "hi"`, `"say \"hi\""`},
+		{needed, "Hello", "Hello"},
+		{needed, "", `""`},
+		{needed, " x", `" x"`},
+		{needed, "a,b", `"a,b"`},
+		{needed, "a\nb", `"a\\nb"`},
+	} {
+		if got := tc.f.String(tc.s); got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+
+	if got := (jsonFormat{short}).String("Hello World"); got != `"Hell…"` {
+		t.Errorf("JSON: Got %s", got)
+	}
+}
+
+type packet struct {
+	Data []byte
+	Addr net.IP
+}
+
+func TestBytesColumns(t *testing.T) {
+	packets := []packet{
+		{[]byte("Hi\x00"), net.IPv4(10, 0, 0, 1)},
+		{nil, net.IPv4(10, 0, 0, 2)},
+	}
+	extractor, err := NewExtractor(packets, "Data", "Addr")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[1].Name = "Addr"
+	if typ := extractor.Columns[0].Type(); typ != Bytes {
+		t.Errorf("Data: Got type %s", typ)
+	}
+	if typ := extractor.Columns[1].Type(); typ != String {
+		t.Errorf("Addr: Got type %s", typ)
+	}
+
+	b64 := DefaultFormat
+	b64.BytesEncoding = Base64Bytes
+	raw := DefaultFormat
+	raw.BytesEncoding = RawBytes
+	for i, tc := range []struct {
+		f    Format
+		want string
+	}{
+		{DefaultFormat, "Data,Addr\n486900,10.0.0.1\n,10.0.0.2\n"},
+		{b64, "Data,Addr\nSGkA,10.0.0.1\n,10.0.0.2\n"},
+		{raw, "Data,Addr\nHi\x00,10.0.0.1\n,10.0.0.2\n"},
+	} {
+		buf := &bytes.Buffer{}
+		if err := (DelimitedDumper{Writer: buf}).Dump(extractor, tc.f); err != nil {
+			t.Fatalf("%d: Unexpected error: %s", i, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%d: Got %q, want %q", i, got, tc.want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (JSONDumper{Writer: buf}).Dump(extractor, b64); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); !bytes.Contains(buf.Bytes(), []byte(`{"Data":"SGkA","Addr":"10.0.0.1"}`)) {
+		t.Errorf("JSON: Got %s", got)
+	}
+
+	// Roundtrip through the gob format.
+	buf.Reset()
+	if err := (GobDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	read, err := NewGobExtractor(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := read.Columns[0].value(0); !bytes.Equal(got.([]byte), packets[0].Data) {
+		t.Errorf("Gob: Got %v", got)
+	}
+	if got := read.Columns[0].value(1); got != nil {
+		t.Errorf("Gob: Got %v for NA", got)
+	}
+
+	// Roundtrip through the binary format.
+	buf.Reset()
+	if err := (BinaryDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	read, err = NewBinaryExtractor(buf.Bytes())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if typ := read.Columns[0].Type(); typ != Bytes {
+		t.Errorf("Binary: Got type %s", typ)
+	}
+	if got := read.Columns[0].value(0); !bytes.Equal(got.([]byte), packets[0].Data) {
+		t.Errorf("Binary: Got %v", got)
+	}
+	if got := read.Columns[0].value(1); got != nil {
+		t.Errorf("Binary: Got %v for NA", got)
+	}
+}
+
+func TestFastVerbs(t *testing.T) {
+	floats := []float64{0, math.Copysign(0, -1), 1, -2.5, 1.0 / 3, 123456789.125,
+		1e21, 1e-7, 6.02214076e23, math.MaxFloat64, math.SmallestNonzeroFloat64}
+	for _, verb := range []string{"%g", "%e", "%f", "%.4g", "%.0f", "%.2f", "%.12e", "%.3g"} {
+		f := Format{FloatFmt: verb}
+		for _, x := range floats {
+			if got, want := f.Float(x), fmt.Sprintf(verb, x); got != want {
+				t.Errorf("%s of %v: got %q, want %q", verb, x, got, want)
+			}
+		}
+	}
+	for _, verb := range []string{"%5g", "%+.2f", "%.g", "%x"} {
+		if _, _, ok := floatVerb(verb); ok {
+			t.Errorf("Fast path for %s", verb)
+		}
+	}
+
+	f := Format{IntFmt: "%d", StringFmt: "%q", DurationFmt: "%d"}
+	if got := f.Int(math.MinInt64) + f.Uint(math.MaxUint64); got != "-922337203685477580818446744073709551615" {
+		t.Errorf("Got %s", got)
+	}
+	if got := f.String("a\"\x00é"); got != fmt.Sprintf("%q", "a\"\x00é") {
+		t.Errorf("Got %s", got)
+	}
+	if got := f.Duration(-time.Second) + DefaultFormat.Duration(90*time.Minute); got != "-10000000001h30m0s" {
+		t.Errorf("Got %s", got)
+	}
+}
+
+func TestAppendTo(t *testing.T) {
+	type row struct {
+		B bool
+		I int
+		U uint64
+		F float64
+		S string
+		T time.Time
+		D time.Duration
+		X []byte
+		P *int
+	}
+	data := []row{
+		{true, -12, 1 << 63, 2.5, `a "b"`, time1, 1500 * time.Millisecond, []byte("x"), nil},
+		{false, 0, 0, math.NaN(), "", time2, -time.Hour, nil, nil},
+		{false, 7, 3, math.Inf(-1), "c\nd", time1, 0, []byte{}, nil},
+	}
+	extractor, err := NewExtractor(data, "B", "I", "U", "F", "S", "T", "D", "X", "P")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	percent := PreciseFormat
+	percent.NumberStyle = Percent
+	unix := DefaultFormat
+	unix.TimeUnit, unix.DurationFmt, unix.StringQuote = time.Millisecond, "%d", QuoteIfNeeded
+	for k, f := range []Formater{DefaultFormat, PreciseFormat, percent, unix, jsonFormat{DefaultFormat}} {
+		for _, field := range extractor.Columns {
+			for r := range data {
+				want := "<" + field.Print(f, r)
+				if got := string(field.AppendTo([]byte("<"), f, r)); got != want {
+					t.Errorf("%d: column %s row %d: got %q, want %q", k, field.Name, r, got, want)
+				}
+			}
+		}
+	}
+}
+
+// plainFormater is a Formater with only the required methods.
+type plainFormater struct{}
+
+func (plainFormater) Bool(b bool) string              { return fmt.Sprint(b) }
+func (plainFormater) Int(i int64) string              { return fmt.Sprint(i) }
+func (plainFormater) Float(f float64) string          { return fmt.Sprint(f) }
+func (plainFormater) Complex(c complex128) string     { return fmt.Sprint(c) }
+func (plainFormater) String(s string) string          { return "<" + s + ">" }
+func (plainFormater) Time(t time.Time) string         { return fmt.Sprint(t) }
+func (plainFormater) Duration(d time.Duration) string { return fmt.Sprint(d) }
+func (plainFormater) NA() string                      { return "-" }
+
+func TestPlainFormater(t *testing.T) {
+	data := []struct {
+		B []byte
+		U uint64
+	}{{[]byte{1, 0xab}, 7}, {nil, math.MaxUint64}}
+	extractor, err := NewExtractor(data, "B", "U")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for r, want := range [][]string{{"<01ab>", "7"}, {"-", "18446744073709551615"}} {
+		for c, w := range want {
+			if got := extractor.Columns[c].Print(plainFormater{}, r); got != w {
+				t.Errorf("Row %d column %d: got %q, want %q", r, c, got, w)
+			}
+		}
+	}
+}
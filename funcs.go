@@ -0,0 +1,19 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+// FuncColumn returns a column named name of type typ whose i'th value is
+// value(i). The values must be the canonical values of typ (bool, int64,
+// uint64, float64, complex128, string, time.Time, time.Duration or []byte)
+// or nil for NA. FuncColumn is used by the code generated by exportgen.
+func FuncColumn(name string, typ Type, value func(i int) interface{}) Column {
+	return Column{Name: name, typ: typ, value: value}
+}
+
+// NewFuncExtractor returns an Extractor for n rows with the given columns
+// constructed by FuncColumn. The returned Extractor cannot be rebound.
+func NewFuncExtractor(n int, columns ...Column) *Extractor {
+	return &Extractor{N: n, Columns: columns}
+}
@@ -0,0 +1,30 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewFuncExtractor(t *testing.T) {
+	names := []string{"a", "b"}
+	extractor := NewFuncExtractor(len(names),
+		FuncColumn("Name", String, func(i int) interface{} { return names[i] }),
+		FuncColumn("Len", Int, func(i int) interface{} {
+			if i == 1 {
+				return nil
+			}
+			return int64(len(names[i]))
+		}),
+	)
+	buf := &bytes.Buffer{}
+	if err := (DelimitedDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := buf.String(), "Name,Len\na,1\nb,\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+}
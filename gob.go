@@ -0,0 +1,171 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"time"
+)
+
+// The gob stream written by GobDumper consists of a gobSchema followed by
+// one []gobValue per row.
+type gobSchema struct {
+	Names []string
+	Types []Type
+	N     int
+}
+
+// gobValue holds one value; only the field matching the column type is set.
+type gobValue struct {
+	NA bool
+	B  bool
+	I  int64
+	F  float64
+	C  complex128
+	S  string
+	T  time.Time
+	D  time.Duration
+	Y  []byte
+	U  uint64
+}
+
+// GobDumper dumps the values as a self-describing stream of package
+// encoding/gob values which can be read back by NewGobExtractor without
+// loss of information (except time locations which are reduced to their
+// offset).
+type GobDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is ignored as the values are stored in binary.
+func (d GobDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	enc := gob.NewEncoder(d.Writer)
+	schema := gobSchema{N: e.N}
+	for _, field := range e.Columns {
+		schema.Names = append(schema.Names, field.Name)
+		schema.Types = append(schema.Types, field.Type())
+	}
+	if err := enc.Encode(schema); err != nil {
+		return err
+	}
+	row := make([]gobValue, len(e.Columns))
+	for r := 0; r < e.N; r++ {
+		for c, field := range e.Columns {
+			row[c] = gobValue{}
+			switch v := field.value(r).(type) {
+			case nil:
+				row[c].NA = true
+			case bool:
+				row[c].B = v
+			case int64:
+				row[c].I = v
+			case float64:
+				row[c].F = v
+			case complex128:
+				row[c].C = v
+			case string:
+				row[c].S = v
+			case time.Time:
+				row[c].T = v
+			case time.Duration:
+				row[c].D = v
+			case []byte:
+				row[c].Y = v
+			case uint64:
+				row[c].U = v
+			}
+		}
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+		e.progress(r, r+1)
+	}
+	return nil
+}
+
+// NewGobExtractor reads the stream written by a GobDumper from r and
+// returns an Extractor for the values. All values are kept in memory.
+// The returned Extractor cannot be rebound.
+func NewGobExtractor(r io.Reader) (*Extractor, error) {
+	dec := gob.NewDecoder(r)
+	var schema gobSchema
+	if err := dec.Decode(&schema); err != nil {
+		return nil, err
+	}
+	if len(schema.Names) != len(schema.Types) || schema.N < 0 {
+		return nil, fmt.Errorf("export: malformed gob schema")
+	}
+	for c, typ := range schema.Types {
+		// NA is the type of columns without any values.
+		if typ > Uint {
+			return nil, fmt.Errorf("export: bad type %d of gob column %d", typ, c)
+		}
+	}
+	// The rows are appended as decoded: N is not trusted for allocations.
+	values := make([][]interface{}, len(schema.Names))
+	for i := 0; i < schema.N; i++ {
+		var row []gobValue
+		if err := dec.Decode(&row); err != nil {
+			return nil, err
+		}
+		if len(row) != len(schema.Names) {
+			return nil, fmt.Errorf("export: row %d has %d values, want %d",
+				i, len(row), len(schema.Names))
+		}
+		for c, v := range row {
+			var val interface{}
+			if !v.NA {
+				switch schema.Types[c] {
+				case Bool:
+					val = v.B
+				case Int:
+					val = v.I
+				case Float:
+					val = v.F
+				case Complex:
+					val = v.C
+				case String:
+					val = v.S
+				case Time:
+					val = v.T
+				case Duration:
+					val = v.D
+				case Bytes:
+					if v.Y == nil {
+						v.Y = []byte{} // gob does not distinguish nil and empty slices
+					}
+					val = v.Y
+				case Uint:
+					val = v.U
+				}
+			}
+			values[c] = append(values[c], val)
+		}
+	}
+
+	ex := &Extractor{N: schema.N}
+	for c, name := range schema.Names {
+		col := values[c]
+		ex.Columns = append(ex.Columns, Column{
+			Name:  name,
+			typ:   schema.Types[c],
+			value: func(i int) interface{} { return col[i] },
+		})
+	}
+	return ex, nil
+}
+
+func init() {
+	RegisterDumper("gob", func(w io.Writer) Dumper {
+		return GobDumper{Writer: w}
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/gob"
+	"testing"
+)
+
+func TestGobRoundtrip(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (GobDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data := buf.Bytes()
+	read, err := NewGobExtractor(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for c, field := range read.Columns {
+		if field.Name != extractor.Columns[c].Name || field.Type() != extractor.Columns[c].Type() {
+			t.Errorf("Column %d: got %s %s", c, field.Name, field.Type())
+		}
+	}
+
+	want, got := &bytes.Buffer{}, &bytes.Buffer{}
+	CSVDumper{Writer: csv.NewWriter(want)}.Dump(extractor, PreciseFormat)
+	CSVDumper{Writer: csv.NewWriter(got)}.Dump(read, PreciseFormat)
+	if got.String() != want.String() {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	if _, err := NewGobExtractor(bytes.NewReader(data[:len(data)-5])); err == nil {
+		t.Errorf("Missing error for truncated data")
+	}
+}
+
+func TestGobExtractorMalformed(t *testing.T) {
+	for i, schema := range []gobSchema{
+		{Names: []string{"A"}, Types: []Type{Int}, N: 1 << 40},
+		{Names: []string{"A"}, Types: []Type{Uint + 1}, N: 1},
+		{Names: []string{"A"}, Types: []Type{Int, Int}},
+	} {
+		buf := &bytes.Buffer{}
+		if err := gob.NewEncoder(buf).Encode(schema); err != nil {
+			t.Fatalf("%d: Unexpected error: %s", i, err)
+		}
+		if _, err := NewGobExtractor(buf); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BigQueryDumper inserts the values into a BigQuery table via the BigQuery
+// REST API. The Client must add authentication to the requests, e.g. a
+// client from golang.org/x/oauth2/google.
+//
+// Column names are turned into valid BigQuery field names. The field types
+// are derived from the column types: BOOLEAN, INTEGER, FLOAT, STRING and
+// TIMESTAMP; Durations are INTEGER nanoseconds and Complex values STRINGs
+// formatted according to the format passed to Dump.
+type BigQueryDumper struct {
+	Client *http.Client // Client used to issue the requests.
+
+	Project, Dataset, Table string // Table to insert into.
+
+	// CreateTable creates the table with a schema derived from the
+	// columns first. An already existing table is not an error.
+	CreateTable bool
+
+	// BatchSize is the number of rows sent per insert request.
+	// The default is 500 rows.
+	BatchSize int
+
+	// Endpoint is the base URL of the API, the default is
+	// "https://bigquery.googleapis.com/bigquery/v2".
+	Endpoint string
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d BigQueryDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	endpoint := d.Endpoint
+	if endpoint == "" {
+		endpoint = "https://bigquery.googleapis.com/bigquery/v2"
+	}
+	tables := endpoint + "/projects/" + url.PathEscape(d.Project) +
+		"/datasets/" + url.PathEscape(d.Dataset) + "/tables"
+	names := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		names[i] = bigqueryName(field.Name)
+	}
+
+	if d.CreateTable {
+		var fields []map[string]string
+		for i, field := range e.Columns {
+			fields = append(fields, map[string]string{
+				"name": names[i],
+				"type": bigqueryType(field.Type()),
+				"mode": "NULLABLE",
+			})
+		}
+		table := map[string]interface{}{
+			"tableReference": map[string]string{
+				"projectId": d.Project,
+				"datasetId": d.Dataset,
+				"tableId":   d.Table,
+			},
+			"schema": map[string]interface{}{"fields": fields},
+		}
+		err := googleCall(d.Client, "POST", tables, table, nil)
+		if ge, ok := err.(googleError); err != nil && (!ok || ge.code != http.StatusConflict) {
+			return err
+		}
+	}
+
+	batchSize := d.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	insert := tables + "/" + url.PathEscape(d.Table) + "/insertAll"
+	for start := 0; start < e.N; start += batchSize {
+		end := start + batchSize
+		if end > e.N {
+			end = e.N
+		}
+		rows := make([]map[string]interface{}, 0, end-start)
+		for r := start; r < end; r++ {
+			row := map[string]interface{}{}
+			for i, field := range e.Columns {
+				if v := bigqueryValue(field.value(r), format); v != nil {
+					row[names[i]] = v
+				}
+			}
+			rows = append(rows, map[string]interface{}{"json": row})
+		}
+		var result struct {
+			InsertErrors []struct {
+				Index  int
+				Errors []struct{ Message string }
+			}
+		}
+		if err := googleCall(d.Client, "POST", insert, map[string]interface{}{"rows": rows}, &result); err != nil {
+			return err
+		}
+		if len(result.InsertErrors) > 0 {
+			ie := result.InsertErrors[0]
+			msg := ""
+			if len(ie.Errors) > 0 {
+				msg = ie.Errors[0].Message
+			}
+			return fmt.Errorf("export: cannot insert row %d: %s", start+ie.Index, msg)
+		}
+		e.progress(start, end)
+	}
+	return nil
+}
+
+// bigqueryName replaces all characters not allowed in BigQuery field
+// names by underscores.
+func bigqueryName(name string) string {
+	id := []byte(name)
+	for i, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			id[i] = '_'
+		}
+	}
+	if len(id) == 0 {
+		return "_"
+	}
+	return string(id)
+}
+
+// bigqueryType returns the BigQuery field type for a column of type t.
+func bigqueryType(t Type) string {
+	switch t {
+	case Bool:
+		return "BOOLEAN"
+	case Int, Duration:
+		return "INTEGER"
+	case Uint:
+		return "NUMERIC" // INTEGER is a signed 64 bit integer
+	case Float:
+		return "FLOAT"
+	case Time:
+		return "TIMESTAMP"
+	case Bytes:
+		return "BYTES" // encoding/json encodes []byte in base64
+	}
+	return "STRING"
+}
+
+// bigqueryValue returns the canonical value v as a JSON value accepted by
+// BigQuery. NA values are nil.
+func bigqueryValue(v interface{}, format Format) interface{} {
+	switch x := v.(type) {
+	case float64:
+		switch {
+		case math.IsNaN(x):
+			return "NaN"
+		case math.IsInf(x, 1):
+			return "Infinity"
+		case math.IsInf(x, -1):
+			return "-Infinity"
+		}
+	case complex128:
+		return format.Complex(x)
+	case time.Time:
+		return x.UTC().Format(time.RFC3339Nano)
+	case time.Duration:
+		return int64(x)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	}
+	return v
+}
+
+// SheetsDumper writes the values into a range of a Google Sheets
+// spreadsheet via the Sheets REST API. The Client must add authentication
+// to the requests, e.g. a client from golang.org/x/oauth2/google.
+//
+// Bools and finite numbers are written as such, everything else as text
+// formatted according to the format passed to Dump. The values are not
+// parsed by Sheets.
+type SheetsDumper struct {
+	Client *http.Client // Client used to issue the requests.
+
+	SpreadsheetID string // SpreadsheetID identifies the spreadsheet.
+
+	// Range is the A1 notation of the upper left cell or the range to
+	// write to, e.g. "Sheet1!A1".
+	Range string
+
+	OmitHeader bool // OmitHeader suppresses the row of column names.
+
+	// Endpoint is the base URL of the API, the default is
+	// "https://sheets.googleapis.com/v4".
+	Endpoint string
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d SheetsDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	endpoint := d.Endpoint
+	if endpoint == "" {
+		endpoint = "https://sheets.googleapis.com/v4"
+	}
+	var values [][]interface{}
+	if !d.OmitHeader {
+		row := make([]interface{}, len(e.Columns))
+		for i, field := range e.Columns {
+			row[i] = field.Name
+		}
+		values = append(values, row)
+	}
+	for r := 0; r < e.N; r++ {
+		row := make([]interface{}, len(e.Columns))
+		for i, field := range e.Columns {
+			switch v := field.value(r).(type) {
+			case bool, int64, uint64:
+				row[i] = v
+			case float64:
+				if math.IsNaN(v) || math.IsInf(v, 0) {
+					row[i] = format.Float(v)
+				} else {
+					row[i] = v
+				}
+			default:
+				row[i] = field.Print(format, r)
+			}
+		}
+		values = append(values, row)
+	}
+	u := endpoint + "/spreadsheets/" + url.PathEscape(d.SpreadsheetID) +
+		"/values/" + url.PathEscape(d.Range) + "?valueInputOption=RAW"
+	body := map[string]interface{}{
+		"range":          d.Range,
+		"majorDimension": "ROWS",
+		"values":         values,
+	}
+	if err := googleCall(d.Client, "PUT", u, body, nil); err != nil {
+		return err
+	}
+	e.progress(0, e.N)
+	return nil
+}
+
+// googleError is the error of a request answered with an error status.
+type googleError struct {
+	code int
+	msg  string
+}
+
+func (e googleError) Error() string { return e.msg }
+
+// googleCall sends body as JSON to u and decodes the JSON response into
+// result if result is not nil.
+func googleCall(client *http.Client, method, u string, body, result interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return googleError{resp.StatusCode, fmt.Sprintf("export: %s %s: %s: %s",
+			method, u, resp.Status, strings.TrimSpace(string(msg)))}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,145 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// apiRecorder records the requests to a fake Google API.
+type apiRecorder struct {
+	requests []string // method, escaped path and query
+	bodies   []string
+	status   map[string]int // status to answer for a path, default 200
+	response string
+}
+
+func (a *apiRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := r.Method + " " + r.URL.EscapedPath()
+	if r.URL.RawQuery != "" {
+		req += "?" + r.URL.RawQuery
+	}
+	a.requests = append(a.requests, req)
+	a.bodies = append(a.bodies, string(body))
+	if code := a.status[r.URL.Path]; code != 0 {
+		http.Error(w, "failed", code)
+		return
+	}
+	w.Write([]byte(a.response))
+}
+
+func TestBigQueryDumper(t *testing.T) {
+	data := []struct {
+		Name string
+		N    *int
+		F    float64
+		T    time.Time
+		D    time.Duration
+	}{
+		{"a", new(int), 1.5, time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC), time.Second},
+		{"b", nil, -1, time.Date(2001, 1, 2, 3, 4, 5, 0, time.UTC), 0},
+		{"c", nil, 0, time.Date(2002, 1, 2, 3, 4, 5, 0, time.UTC), 0},
+	}
+	extractor, err := NewExtractor(data, "Name", "N", "F", "T", "D")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	api := &apiRecorder{
+		status:   map[string]int{"/projects/p/datasets/ds/tables": http.StatusConflict},
+		response: "{}",
+	}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	d := BigQueryDumper{
+		Client:      server.Client(),
+		Project:     "p",
+		Dataset:     "ds",
+		Table:       "t",
+		CreateTable: true,
+		BatchSize:   2,
+		Endpoint:    server.URL,
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	wantRequests := []string{
+		"POST /projects/p/datasets/ds/tables",
+		"POST /projects/p/datasets/ds/tables/t/insertAll",
+		"POST /projects/p/datasets/ds/tables/t/insertAll",
+	}
+	if got := strings.Join(api.requests, "\n"); got != strings.Join(wantRequests, "\n") {
+		t.Fatalf("Got requests\n%s", got)
+	}
+	var table struct {
+		Schema struct{ Fields []map[string]string }
+	}
+	json.Unmarshal([]byte(api.bodies[0]), &table)
+	var types []string
+	for _, f := range table.Schema.Fields {
+		types = append(types, f["name"]+":"+f["type"])
+	}
+	if got := strings.Join(types, " "); got != "Name:STRING N:INTEGER F:FLOAT T:TIMESTAMP D:INTEGER" {
+		t.Errorf("Got schema %s", got)
+	}
+	want := `{"rows":[{"json":{"D":1000000000,"F":1.5,"N":0,"Name":"a","T":"2000-01-02T03:04:05Z"}},` +
+		`{"json":{"D":0,"F":-1,"Name":"b","T":"2001-01-02T03:04:05Z"}}]}`
+	if api.bodies[1] != want {
+		t.Errorf("Got insert\n%s\nwant\n%s", api.bodies[1], want)
+	}
+
+	api.requests = nil
+	api.response = `{"insertErrors":[{"index":0,"errors":[{"message":"bad"}]}]}`
+	d.CreateTable = false
+	if err := d.Dump(extractor, DefaultFormat); err == nil || !strings.Contains(err.Error(), "row 0: bad") {
+		t.Errorf("Got error %v", err)
+	}
+
+	api.status["/projects/p/datasets/ds/tables/t/insertAll"] = http.StatusForbidden
+	if err := d.Dump(extractor, DefaultFormat); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Got error %v", err)
+	}
+}
+
+func TestSheetsDumper(t *testing.T) {
+	data := []struct {
+		Name string
+		N    *int
+		B    bool
+	}{{"a", new(int), true}, {"b", nil, false}}
+	extractor, err := NewExtractor(data, "Name", "N", "B")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	api := &apiRecorder{response: "{}"}
+	server := httptest.NewServer(api)
+	defer server.Close()
+
+	d := SheetsDumper{
+		Client:        server.Client(),
+		SpreadsheetID: "id",
+		Range:         "Data!B2",
+		Endpoint:      server.URL,
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := api.requests[0]; got != "PUT /spreadsheets/id/values/Data%21B2?valueInputOption=RAW" {
+		t.Errorf("Got request %s", got)
+	}
+	want := `{"majorDimension":"ROWS","range":"Data!B2","values":[["Name","N","B"],["a",0,true],["b","",false]]}`
+	if api.bodies[0] != want {
+		t.Errorf("Got\n%s\nwant\n%s", api.bodies[0], want)
+	}
+}
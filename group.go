@@ -0,0 +1,292 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Grouping is the result of GroupBy which is turned into aggregated rows
+// by Aggregate.
+type Grouping struct {
+	e    *Extractor
+	keys []int // indices of the key columns in e.Columns
+	err  error
+}
+
+// GroupBy groups the rows of e by the values of the columns with the given
+// names. Errors like unknown column names are reported by Aggregate.
+func (e *Extractor) GroupBy(names ...string) *Grouping {
+	keys, err := e.columnIndices(names)
+	return &Grouping{e: e, keys: keys, err: err}
+}
+
+// Aggregator computes a column of the aggregated rows from the values of
+// a column in each group. NA values (and NaNs) are ignored; the result is
+// NA if a group has no other values.
+type Aggregator struct {
+	Name   string // Name is the name of the result column.
+	Column string // Column is the name of the aggregated column.
+
+	kind string  // "count", "sum", "mean", "min", "max" or "quantile"
+	q    float64 // the probability of quantiles
+}
+
+// Count returns an Aggregator for the number of rows of each group in a
+// column named "count".
+func Count() Aggregator { return Aggregator{Name: "count", kind: "count"} }
+
+// Sum returns an Aggregator for the sum of the Int, Uint, Float or
+// Duration values of column named like "sum(Price)".
+func Sum(column string) Aggregator { return newAggregator("sum", column) }
+
+// Mean returns an Aggregator for the arithmetic mean of the Int, Uint,
+// Float or Duration values of column named like "mean(Price)". The mean of
+// Durations is a Duration, all others are Floats.
+func Mean(column string) Aggregator { return newAggregator("mean", column) }
+
+// Min returns an Aggregator for the smallest value of column named like
+// "min(Price)". All types but Complex can be used.
+func Min(column string) Aggregator { return newAggregator("min", column) }
+
+// Max returns an Aggregator for the largest value of column named like
+// "max(Price)". All types but Complex can be used.
+func Max(column string) Aggregator { return newAggregator("max", column) }
+
+// Quantile returns an Aggregator for the q-quantile (0 <= q <= 1) of the
+// Int, Uint, Float or Duration values of column named like
+// "quantile(Price,0.9)". The quantile is interpolated linearly between the
+// sorted values like type 7 of R's quantile function; it is a Duration for
+// Durations and a Float otherwise.
+func Quantile(column string, q float64) Aggregator {
+	a := newAggregator("quantile", column)
+	a.Name = fmt.Sprintf("quantile(%s,%g)", column, q)
+	a.q = q
+	return a
+}
+
+func newAggregator(kind, column string) Aggregator {
+	return Aggregator{Name: kind + "(" + column + ")", Column: column, kind: kind}
+}
+
+// resultType returns the type of the result of a for values of type typ.
+func (a Aggregator) resultType(typ Type) (Type, error) {
+	numeric := typ == Int || typ == Uint || typ == Float || typ == Duration
+	switch {
+	case a.kind == "count":
+		return Int, nil
+	case a.kind == "sum" && numeric:
+		return typ, nil
+	case (a.kind == "mean" || a.kind == "quantile") && numeric:
+		if a.kind == "quantile" && !(a.q >= 0 && a.q <= 1) {
+			return NA, fmt.Errorf("export: bad quantile %g", a.q)
+		}
+		if typ == Duration {
+			return Duration, nil
+		}
+		return Float, nil
+	case (a.kind == "min" || a.kind == "max") && typ != Complex:
+		return typ, nil
+	}
+	return NA, fmt.Errorf("export: cannot compute %s of %s column %s", a.kind, typ, a.Column)
+}
+
+// Aggregate returns an Extractor with one row per group: The key columns
+// followed by one column per aggregator. The rows are sorted by the keys
+// in ascending order with NA keys last. The values of the returned
+// Extractor are computed once and kept in memory, it cannot be rebound.
+// Values of failing method calls are NA.
+func (g *Grouping) Aggregate(aggs ...Aggregator) (*Extractor, error) {
+	if g.err != nil {
+		return nil, g.err
+	}
+	e := g.e
+	sources := make([]int, len(aggs))
+	types := make([]Type, len(aggs))
+	for i, a := range aggs {
+		sources[i] = -1
+		typ := NA
+		if a.kind != "count" {
+			idx, err := e.columnIndices([]string{a.Column})
+			if err != nil {
+				return nil, err
+			}
+			sources[i], typ = idx[0], e.Columns[idx[0]].Type()
+		}
+		var err error
+		if types[i], err = a.resultType(typ); err != nil {
+			return nil, err
+		}
+	}
+
+	// Collect the rows of each group.
+	var keyValues [][]interface{}
+	var groups [][]int
+	index := map[string]int{}
+	for r := 0; r < e.N; r++ {
+		values, key := e.rowKey(g.keys, r)
+		gi, ok := index[key]
+		if !ok {
+			gi = len(groups)
+			index[key] = gi
+			keyValues = append(keyValues, values)
+			groups = append(groups, nil)
+		}
+		groups[gi] = append(groups[gi], r)
+	}
+	order := make([]int, len(groups))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return keyLessValues(keyValues[order[i]], keyValues[order[j]])
+	})
+
+	result := &Extractor{N: len(groups)}
+	column := func(name string, typ Type, values []interface{}) {
+		result.Columns = append(result.Columns, Column{
+			Name:  name,
+			typ:   typ,
+			value: func(i int) interface{} { return values[i] },
+		})
+	}
+	for k, c := range g.keys {
+		values := make([]interface{}, len(groups))
+		for i, gi := range order {
+			values[i] = keyValues[gi][k]
+		}
+		column(e.Columns[c].Name, e.Columns[c].Type(), values)
+	}
+	for a, agg := range aggs {
+		values := make([]interface{}, len(groups))
+		for i, gi := range order {
+			if agg.kind == "count" {
+				values[i] = int64(len(groups[gi]))
+				continue
+			}
+			var group []interface{}
+			for _, r := range groups[gi] {
+				if v := e.Columns[sources[a]].value(r); !isNA(v) {
+					group = append(group, v)
+				}
+			}
+			values[i] = agg.apply(group)
+		}
+		column(agg.Name, types[a], values)
+	}
+	return result, nil
+}
+
+// rowKey returns the values of the columns cols in row r of e with NaNs
+// replaced by NA and a string identifying these values.
+func (e *Extractor) rowKey(cols []int, r int) ([]interface{}, string) {
+	values := make([]interface{}, len(cols))
+	parts := make([]string, len(cols))
+	for k, c := range cols {
+		values[k] = e.Columns[c].value(r)
+		if !isNA(values[k]) {
+			parts[k] = fmt.Sprintf("%v", values[k])
+		} else {
+			values[k] = nil
+			parts[k] = "\x00NA"
+		}
+	}
+	return values, strings.Join(parts, "\x00")
+}
+
+// keyLessValues reports whether the key values a sort before b: Ascending
+// by the first differing value with NA last.
+func keyLessValues(a, b []interface{}) bool {
+	for k := range a {
+		switch {
+		case a[k] == nil && b[k] == nil:
+			continue
+		case a[k] == nil || b[k] == nil:
+			return b[k] == nil
+		}
+		if c := compareValues(a[k], b[k]); c != 0 {
+			return c < 0
+		}
+	}
+	return false
+}
+
+// apply computes the aggregate of the non-NA canonical values.
+func (a Aggregator) apply(values []interface{}) interface{} {
+	if len(values) == 0 {
+		return nil
+	}
+	switch a.kind {
+	case "sum":
+		return sumValues(values)
+	case "mean":
+		if d, ok := sumValues(values).(time.Duration); ok {
+			return d / time.Duration(len(values))
+		}
+		sum := 0.0
+		for _, v := range values {
+			sum += toFloat(v)
+		}
+		return sum / float64(len(values))
+	case "min", "max":
+		best := values[0]
+		for _, v := range values[1:] {
+			if c := compareValues(v, best); c < 0 && a.kind == "min" || c > 0 && a.kind == "max" {
+				best = v
+			}
+		}
+		return best
+	case "quantile":
+		x := make([]float64, len(values))
+		for i, v := range values {
+			x[i] = toFloat(v)
+		}
+		sort.Float64s(x)
+		h := a.q * float64(len(x)-1)
+		lo := math.Floor(h)
+		q := x[int(lo)]
+		if int(lo) < len(x)-1 {
+			q += (h - lo) * (x[int(lo)+1] - x[int(lo)])
+		}
+		if _, ok := values[0].(time.Duration); ok {
+			return time.Duration(math.Round(q))
+		}
+		return q
+	}
+	return nil
+}
+
+// sumValues returns the sum of the numeric canonical values of one type.
+func sumValues(values []interface{}) interface{} {
+	switch values[0].(type) {
+	case int64:
+		var sum int64
+		for _, v := range values {
+			sum += v.(int64)
+		}
+		return sum
+	case uint64:
+		var sum uint64
+		for _, v := range values {
+			sum += v.(uint64)
+		}
+		return sum
+	case time.Duration:
+		var sum time.Duration
+		for _, v := range values {
+			sum += v.(time.Duration)
+		}
+		return sum
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += toFloat(v)
+	}
+	return sum
+}
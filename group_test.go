@@ -0,0 +1,72 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestGroupBy(t *testing.T) {
+	one := 1
+	data := []gem{
+		{Cut: "Ideal", Color: "E", Price: 300, Carat: 0.5, Held: time.Hour},
+		{Cut: "Good", Color: "J", Price: 500, Carat: 1, X: &one, Held: 3 * time.Hour},
+		{Cut: "Ideal", Color: "E", Price: 400, Carat: 1.5},
+		{Cut: "Ideal", Color: "D", Price: 100, Carat: 0.25},
+		{Cut: "Ideal", Color: "E", Price: 800, Carat: 2, X: &one},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Color", "Price", "Carat", "X", "Held")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	groups, err := extractor.GroupBy("Cut", "Color").Aggregate(
+		Count(), Sum("Price"), Mean("Carat"), Min("Price"), Max("Color"),
+		Quantile("Price", 0.5), Sum("X"), Mean("Held"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf}.Dump(groups, DefaultFormat)
+	want := `Cut,Color,count,sum(Price),mean(Carat),min(Price),max(Color),"quantile(Price,0.5)",sum(X),mean(Held)
+Good,J,1,500,1,500,J,500,1,3h0m0s
+Ideal,D,1,100,0.25,100,D,100,,0s
+Ideal,E,3,1500,1.333,300,E,400,1,20m0s
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+	wantTypes := []Type{String, String, Int, Int, Float, Int, String, Float, Int, Duration}
+	for i, field := range groups.Columns {
+		if field.Type() != wantTypes[i] {
+			t.Errorf("Column %s has type %s, want %s", field.Name, field.Type(), wantTypes[i])
+		}
+	}
+
+	// NA keys form a group sorted last; interpolated quantiles.
+	groups, err = extractor.GroupBy("X").Aggregate(Count(), Quantile("Price", 0.25))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if groups.N != 2 || groups.Columns[0].value(1) != nil ||
+		groups.Columns[1].value(1) != int64(3) || groups.Columns[2].value(1) != 200.0 {
+		t.Errorf("Got %d groups, last %v %v %v", groups.N, groups.Columns[0].value(1),
+			groups.Columns[1].value(1), groups.Columns[2].value(1))
+	}
+
+	for i, aggs := range [][]Aggregator{
+		{Sum("Cut")},
+		{Mean("Weight")},
+		{Quantile("Price", 1.5)},
+	} {
+		if _, err := extractor.GroupBy("Cut").Aggregate(aggs...); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+	if _, err := extractor.GroupBy("Clarity").Aggregate(Count()); err == nil {
+		t.Errorf("Missing error for unknown key column")
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// contentTypes maps format names to the media types of their output.
+var contentTypes = map[string]string{
+	"arrow":    "application/vnd.apache.arrow.file",
+	"arrows":   "application/vnd.apache.arrow.stream",
+	"csv":      "text/csv; charset=utf-8",
+	"html":     "text/html; charset=utf-8",
+	"json":     "application/json",
+	"latex":    "application/x-latex",
+	"markdown": "text/markdown; charset=utf-8",
+	"ndjson":   "application/x-ndjson",
+	"ods":      "application/vnd.oasis.opendocument.spreadsheet",
+	"parquet":  "application/vnd.apache.parquet",
+	"tab":      "text/plain; charset=utf-8",
+	"tsv":      "text/tab-separated-values; charset=utf-8",
+	"xlsx":     "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	"yaml":     "application/yaml",
+}
+
+// ExportHandler returns a handler which serves the data bound to e in the
+// given format. The output format is selected from the registered names
+// by the query parameter format (e.g. ?format=json) or else by the Accept
+// header of the request. If names is empty the formats "csv", "json" and
+// "html" are offered. The first name is the default for requests without
+// preference.
+//
+// The Dumper writes directly to the response. Concurrent requests dump e
+// concurrently which is safe as Dumps do not modify e; e must not be
+// rebound while requests are served.
+func ExportHandler(e *Extractor, format Format, names ...string) http.Handler {
+	if len(names) == 0 {
+		names = []string{"csv", "json", "html"}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := ""
+		if q := r.URL.Query().Get("format"); q != "" {
+			for _, n := range names {
+				if n == q {
+					name = n
+				}
+			}
+			if name == "" {
+				http.Error(w, "unsupported format "+strconv.Quote(q), http.StatusBadRequest)
+				return
+			}
+		} else if name = negotiate(r.Header.Get("Accept"), names); name == "" {
+			http.Error(w, "no acceptable format, available: "+strings.Join(names, ", "),
+				http.StatusNotAcceptable)
+			return
+		}
+		factory := LookupDumper(name)
+		if factory == nil {
+			http.Error(w, "no dumper registered for format "+strconv.Quote(name),
+				http.StatusInternalServerError)
+			return
+		}
+
+		if ct := contentTypes[name]; ct != "" {
+			w.Header().Set("Content-Type", ct)
+		}
+		w.Header().Add("Vary", "Accept")
+		cw := &countingWriter{w: w}
+		if err := factory(cw).Dump(e, format); err != nil && cw.n == 0 {
+			w.Header().Del("Content-Type")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// negotiate returns the first of the format names with the highest
+// quality in the Accept header accept or "" if none is acceptable.
+func negotiate(accept string, names []string) string {
+	if strings.TrimSpace(accept) == "" {
+		return names[0]
+	}
+	type mediaRange struct {
+		typ string
+		q   float64
+	}
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mr := mediaRange{typ: strings.ToLower(strings.TrimSpace(params[0])), q: 1}
+		for _, p := range params[1:] {
+			if k, v, ok := strings.Cut(strings.TrimSpace(p), "="); ok && k == "q" {
+				if q, err := strconv.ParseFloat(v, 64); err == nil {
+					mr.q = q
+				}
+			}
+		}
+		if mr.q > 0 {
+			ranges = append(ranges, mr)
+		}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	for _, mr := range ranges {
+		for _, name := range names {
+			ct, _, _ := strings.Cut(contentTypes[name], ";")
+			major, _, _ := strings.Cut(ct, "/")
+			if mr.typ == "*/*" || mr.typ == ct || mr.typ == major+"/*" {
+				return name
+			}
+		}
+	}
+	return ""
+}
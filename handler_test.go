@@ -0,0 +1,89 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestExportHandler(t *testing.T) {
+	data := []struct{ S string }{{"a"}, {"b"}}
+	extractor, err := NewExtractor(data, "S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	handler := ExportHandler(extractor, DefaultFormat)
+
+	for i, tc := range []struct {
+		url, accept string
+		code        int
+		ct, body    string
+	}{
+		{"/", "", 200, "text/csv; charset=utf-8", "S\na\nb\n"},
+		{"/?format=json", "text/html", 200, "application/json", `[` + "\n" + `{"S":"a"},` + "\n" + `{"S":"b"}` + "\n]\n"},
+		{"/", "text/html,application/xhtml+xml;q=0.9", 200, "text/html; charset=utf-8", "<table>"},
+		{"/", "text/csv;q=0.5, application/json", 200, "application/json", "["},
+		{"/", "application/*", 200, "application/json", "["},
+		{"/", "*/*", 200, "text/csv; charset=utf-8", "S\n"},
+		{"/", "image/png", http.StatusNotAcceptable, "", "no acceptable format"},
+		{"/", "text/csv;q=0", http.StatusNotAcceptable, "", "no acceptable format"},
+		{"/?format=xlsx", "", http.StatusBadRequest, "", "unsupported format"},
+	} {
+		req := httptest.NewRequest("GET", tc.url, nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept", tc.accept)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.code {
+			t.Errorf("%d: Got status %d, want %d", i, rec.Code, tc.code)
+		}
+		if tc.ct != "" && rec.Header().Get("Content-Type") != tc.ct {
+			t.Errorf("%d: Got content type %q", i, rec.Header().Get("Content-Type"))
+		}
+		if !strings.HasPrefix(rec.Body.String(), tc.body) {
+			t.Errorf("%d: Got body %q", i, rec.Body.String())
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	ExportHandler(extractor, DefaultFormat, "markdown").ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if got := rec.Body.String(); got != "| S |\n| --- |\n| a |\n| b |\n" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestExportHandlerParallel(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "BME()", "T")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	handler := ExportHandler(extractor, DefaultFormat, "csv", "json")
+	get := func(url string) string {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", url, nil))
+		return rec.Body.String()
+	}
+	want := map[string]string{"/": get("/"), "/?format=json": get("/?format=json")}
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		url := "/"
+		if i%2 == 1 {
+			url = "/?format=json"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := get(url); got != want[url] {
+				t.Errorf("%s: Got %q, want %q", url, got, want[url])
+			}
+		}()
+	}
+	wg.Wait()
+}
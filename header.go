@@ -0,0 +1,164 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// NameCase is a letter case convention for column names.
+type NameCase int
+
+const (
+	// KeepCase leaves the names unchanged.
+	KeepCase NameCase = iota
+
+	// SnakeCase joins the lower case words of a name with underscores,
+	// e.g. "Order.CreatedAt" becomes "order_created_at".
+	SnakeCase
+
+	// CamelCase joins the capitalized words of a name, e.g.
+	// "order_created_at" becomes "OrderCreatedAt".
+	CamelCase
+)
+
+// HeaderPolicy describes how column names are transformed to suit a
+// target like a SQL database, R or BigQuery. The transformations are
+// applied in the order of the fields.
+type HeaderPolicy struct {
+	// StripParens removes the "()" of method calls, e.g. in the names
+	// of expression columns.
+	StripParens bool
+
+	// Case converts the names to a letter case. Words are separated by
+	// characters other than letters and digits and by changes from lower
+	// to upper case like in "createdAt" or "HTTPServer".
+	Case NameCase
+
+	// Identifier replaces characters other than letters, digits and
+	// underscores by underscores and prepends an underscore to names
+	// starting with a digit, which makes the names valid identifiers
+	// in SQL and BigQuery.
+	Identifier bool
+
+	// Prefix and Suffix are added to each name.
+	Prefix, Suffix string
+
+	// Unique, if not empty, makes duplicate names unique by appending
+	// Unique and a counter starting at 2, e.g. "name_2" for "_".
+	Unique string
+}
+
+// Common header policies.
+var (
+	// SQLHeaders are snake case identifiers as preferred by SQL
+	// databases.
+	SQLHeaders = HeaderPolicy{StripParens: true, Case: SnakeCase, Identifier: true, Unique: "_"}
+
+	// BigQueryHeaders are valid BigQuery column names keeping the
+	// letter case.
+	BigQueryHeaders = HeaderPolicy{StripParens: true, Identifier: true, Unique: "_"}
+)
+
+// Name returns name transformed by p, without making it unique. It can be
+// used as the Header of a DelimitedDumper.
+func (p HeaderPolicy) Name(name string) string {
+	if p.StripParens {
+		name = strings.ReplaceAll(name, "()", "")
+	}
+	switch p.Case {
+	case SnakeCase:
+		words := nameWords(name)
+		for i, w := range words {
+			words[i] = strings.ToLower(w)
+		}
+		name = strings.Join(words, "_")
+	case CamelCase:
+		words := nameWords(name)
+		for i, w := range words {
+			r := []rune(strings.ToLower(w))
+			r[0] = unicode.ToUpper(r[0])
+			words[i] = string(r)
+		}
+		name = strings.Join(words, "")
+	}
+	if p.Identifier {
+		name = strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+				return r
+			}
+			return '_'
+		}, name)
+		if name == "" || unicode.IsDigit([]rune(name)[0]) {
+			name = "_" + name
+		}
+	}
+	return p.Prefix + name + p.Suffix
+}
+
+// Names returns the names transformed by p and made unique if requested.
+func (p HeaderPolicy) Names(names []string) []string {
+	out := make([]string, len(names))
+	seen := map[string]bool{}
+	for i, name := range names {
+		out[i] = p.Name(name)
+		seen[out[i]] = true
+	}
+	if p.Unique == "" {
+		return out
+	}
+	used := map[string]bool{}
+	for i, name := range out {
+		for k := 2; used[out[i]]; k++ {
+			if candidate := name + p.Unique + strconv.Itoa(k); !seen[candidate] {
+				out[i], seen[candidate] = candidate, true
+			}
+		}
+		used[out[i]] = true
+	}
+	return out
+}
+
+// ApplyHeaderPolicy renames the columns of e according to p.
+func (e *Extractor) ApplyHeaderPolicy(p HeaderPolicy) {
+	names := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		names[i] = field.Name
+	}
+	for i, name := range p.Names(names) {
+		e.Columns[i].Name = name
+	}
+}
+
+// nameWords splits name into words at characters other than letters and
+// digits and before an upper case letter following a lower case letter or
+// digit or starting a word after an acronym, e.g. "HTTPServer".
+func nameWords(name string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words, word = append(words, string(word)), nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(word) > 0 {
+			prev := word[len(word)-1]
+			next := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && next) {
+				words, word = append(words, string(word)), nil
+			}
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
@@ -0,0 +1,68 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNameWords(t *testing.T) {
+	for name, want := range map[string][]string{
+		"Order.CreatedAt": {"Order", "Created", "At"},
+		"HTTPServer":      {"HTTP", "Server"},
+		"user_id":         {"user", "id"},
+		"Stay().Start":    {"Stay", "Start"},
+		"Area2D":          {"Area2", "D"},
+		"  ":              nil,
+		"Größe der Stadt": {"Größe", "der", "Stadt"},
+	} {
+		if got := nameWords(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestHeaderPolicy(t *testing.T) {
+	names := []string{"Order.CreatedAt", "Score()*2", "order_created_at", "HTTPServer", "1st", "x", "x", "x_2"}
+	for _, tc := range []struct {
+		name   string
+		policy HeaderPolicy
+		want   []string
+	}{
+		{"Keep", HeaderPolicy{}, names},
+		{"Snake", HeaderPolicy{Case: SnakeCase},
+			[]string{"order_created_at", "score_2", "order_created_at", "http_server", "1st", "x", "x", "x_2"}},
+		{"Camel", HeaderPolicy{Case: CamelCase, StripParens: true},
+			[]string{"OrderCreatedAt", "Score2", "OrderCreatedAt", "HttpServer", "1st", "X", "X", "X2"}},
+		{"Affixes", HeaderPolicy{StripParens: true, Prefix: "c_", Suffix: "!"},
+			[]string{"c_Order.CreatedAt!", "c_Score*2!", "c_order_created_at!", "c_HTTPServer!", "c_1st!", "c_x!", "c_x!", "c_x_2!"}},
+		{"SQL", SQLHeaders,
+			[]string{"order_created_at", "score_2", "order_created_at_2", "http_server", "_1st", "x", "x_3", "x_2"}},
+		{"BigQuery", BigQueryHeaders,
+			[]string{"Order_CreatedAt", "Score_2", "order_created_at", "HTTPServer", "_1st", "x", "x_3", "x_2"}},
+		{"R", HeaderPolicy{StripParens: true, Unique: "."},
+			[]string{"Order.CreatedAt", "Score*2", "order_created_at", "HTTPServer", "1st", "x", "x.2", "x_2"}},
+	} {
+		if got := tc.policy.Names(names); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestApplyHeaderPolicy(t *testing.T) {
+	extractor, err := NewExtractor(ss, "F", "I", "I*2", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.ApplyHeaderPolicy(SQLHeaders)
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf, Header: HeaderPolicy{Prefix: "s."}.Name}.Dump(extractor, DefaultFormat)
+	want := "s.f,s.i,s.i_2,s.f_2"
+	if got := bytes.SplitN(buf.Bytes(), []byte("\n"), 2)[0]; string(got) != want {
+		t.Errorf("Got header %q, want %q", got, want)
+	}
+}
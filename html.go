@@ -0,0 +1,78 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"strings"
+)
+
+// HTMLDumper dumps the values as a HTML table.
+type HTMLDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the thead section.
+
+	// Class is the CSS class of the table element.
+	Class string
+
+	// TypeClass maps the type of a column to the CSS class used for the
+	// th and td elements of this column.
+	TypeClass map[Type]string
+
+	// NAClass is an additional CSS class for td elements with NA values.
+	NAClass string
+}
+
+// Dump implements the Dump method of a Dumper.
+// The formated values are HTML escaped.
+func (d HTMLDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(d.Writer)
+	w.WriteString("<table" + htmlClass(d.Class) + ">\n")
+	if !d.OmitHeader {
+		w.WriteString("<thead>\n<tr>")
+		for _, field := range e.Columns {
+			w.WriteString("<th" + htmlClass(d.TypeClass[field.Type()]) + ">" +
+				html.EscapeString(field.Name) + "</th>")
+		}
+		w.WriteString("</tr>\n</thead>\n")
+	}
+	w.WriteString("<tbody>\n")
+	for r := 0; r < e.N; r++ {
+		w.WriteString("<tr>")
+		for _, field := range e.Columns {
+			val := field.value(r)
+			class := d.TypeClass[field.Type()]
+			if d.NAClass != "" && val == nil {
+				class = strings.TrimSpace(class + " " + d.NAClass)
+			}
+			w.WriteString("<td" + htmlClass(class) + ">" +
+				html.EscapeString(field.printValue(format, val)) + "</td>")
+		}
+		w.WriteString("</tr>\n")
+		e.progress(r, r+1)
+	}
+	w.WriteString("</tbody>\n</table>\n")
+	return w.Flush()
+}
+
+// htmlClass returns a class attribute for the given class.
+func htmlClass(class string) string {
+	if class == "" {
+		return ""
+	}
+	return ` class="` + html.EscapeString(class) + `"`
+}
+
+func init() {
+	RegisterDumper("html", func(w io.Writer) Dumper {
+		return HTMLDumper{Writer: w}
+	})
+}
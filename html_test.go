@@ -0,0 +1,70 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestHTMLDumper(t *testing.T) {
+	data := []struct {
+		S string
+		I *int
+	}{{"<b>&", nil}, {"x", new(int)}}
+	extractor, err := NewExtractor(data, "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	d := HTMLDumper{
+		Writer:    buf,
+		Class:     "data",
+		TypeClass: map[Type]string{Int: "num"},
+		NAClass:   "na",
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `<table class="data">
+<thead>
+<tr><th>S</th><th class="num">I</th></tr>
+</thead>
+<tbody>
+<tr><td>&lt;b&gt;&amp;</td><td class="num na"></td></tr>
+<tr><td>x</td><td class="num">0</td></tr>
+</tbody>
+</table>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	HTMLDumper{Writer: buf, OmitHeader: true}.Dump(extractor, DefaultFormat)
+	want = `<table>
+<tbody>
+<tr><td>&lt;b&gt;&amp;</td><td></td></tr>
+<tr><td>x</td><td>0</td></tr>
+</tbody>
+</table>
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	v := 1.5
+	calls := []measurement{{Value: &v}, {}, {Value: &v}}
+	extractor, err = NewExtractor(calls, "Check()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	materializeCalls = 0
+	HTMLDumper{Writer: buf, NAClass: "na"}.Dump(extractor, DefaultFormat)
+	if materializeCalls != len(calls) {
+		t.Errorf("Check called %d times for %d rows", materializeCalls, len(calls))
+	}
+}
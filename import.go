@@ -0,0 +1,212 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// Importer reads delimited text with a header line into a slice of
+// structs. It is the reverse of dumping with a DelimitedDumper.
+type Importer struct {
+	Reader io.Reader // Reader provides the delimited text.
+	Comma  rune      // Comma is the field delimiter, ',' if 0.
+
+	// TimeLayout is the package time layout of Time values. If empty
+	// time.RFC3339Nano and the TimeFmt of DefaultFormat are tried.
+	TimeLayout string
+
+	// TimeLoc is the location of times without time zone. It defaults
+	// to time.Local like in DefaultFormat.
+	TimeLoc *time.Location
+}
+
+// Import reads all records and appends one element per record to the
+// slice of structs (or pointers to structs) dst points to. The column
+// specifiers select the fields to populate like in NewExtractor and must
+// match the names in the header line; columns of the header without a
+// specifier are ignored. Without column specifiers every column of the
+// header must be a specifier. Only fields (including nested and promoted
+// ones) can be populated, not methods, map values or registered types.
+//
+// The values are parsed like in NewDelimitedExtractor except for Times
+// which are parsed as described for TimeLayout. Empty fields are NA:
+// Pointer fields are left nil, others are left at their zero value.
+func (imp Importer) Import(dst interface{}, columnSpecs ...string) error {
+	pv := reflect.ValueOf(dst)
+	if pv.Kind() != reflect.Ptr || pv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("export: cannot import into %T", dst)
+	}
+	slice := pv.Elem()
+
+	cr := csv.NewReader(imp.Reader)
+	if imp.Comma != 0 {
+		cr.Comma = imp.Comma
+	}
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			err = fmt.Errorf("export: missing header line")
+		}
+		return err
+	}
+	if len(columnSpecs) == 0 {
+		columnSpecs = header
+	}
+	e, err := NewExtractor(reflect.MakeSlice(slice.Type(), 0, 0).Interface(), columnSpecs...)
+	if err != nil {
+		return err
+	}
+	index := map[string]int{}
+	for i, name := range header {
+		index[name] = i
+	}
+	fields := make([]int, len(e.Columns))
+	for c, field := range e.Columns {
+		importable := field.expr == nil
+		for _, s := range field.access {
+			if s.method.IsValid() || s.key.IsValid() || s.convert != nil {
+				importable = false
+			}
+		}
+		if !importable {
+			return fmt.Errorf("export: cannot import column %s", field.Name)
+		}
+		i, ok := index[field.Name]
+		if !ok {
+			return fmt.Errorf("export: no column %s in header", field.Name)
+		}
+		fields[c] = i
+	}
+
+	for line := 2; ; line++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		elem := reflect.New(slice.Type().Elem()).Elem()
+		v := elem
+		for i := 0; i < e.indir; i++ {
+			v.Set(reflect.New(v.Type().Elem()))
+			v = v.Elem()
+		}
+		for c, field := range e.Columns {
+			val, err := imp.parse(field.Type(), rec[fields[c]])
+			if err == nil {
+				err = assign(v, field.access, val)
+			}
+			if err != nil {
+				return fmt.Errorf("export: line %d, column %s: %v", line, field.Name, err)
+			}
+		}
+		slice.Set(reflect.Append(slice, elem))
+	}
+	return nil
+}
+
+// parse converts s to the canonical value of typ; empty strings are NA.
+func (imp Importer) parse(typ Type, s string) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	if typ != Time {
+		return ColumnDef{Type: typ}.parse(s)
+	}
+	loc := imp.TimeLoc
+	if loc == nil {
+		loc = time.Local
+	}
+	if imp.TimeLayout != "" {
+		return time.ParseInLocation(imp.TimeLayout, s, loc)
+	}
+	t, err := time.ParseInLocation(time.RFC3339Nano, s, loc)
+	if err != nil {
+		t, err = time.ParseInLocation(DefaultFormat.TimeFmt, s, loc)
+	}
+	return t, err
+}
+
+// assign stores the canonical value val in the field of v described by
+// the field access steps, allocating nil pointers on the way. NA values
+// leave the final pointers nil and the field unchanged.
+func assign(v reflect.Value, steps []step, val interface{}) error {
+	for _, s := range steps {
+		if s.promoted != nil {
+			for i, x := range s.promoted {
+				if i > 0 && v.Kind() == reflect.Ptr {
+					if v.IsNil() {
+						if !v.CanSet() {
+							return fmt.Errorf("cannot allocate embedded %s", v.Type())
+						}
+						v.Set(reflect.New(v.Type().Elem()))
+					}
+					v = v.Elem()
+				}
+				v = v.Field(x)
+			}
+		} else {
+			v = v.Field(s.field)
+		}
+		for i := 0; i < s.indir; i++ {
+			if v.IsNil() {
+				if val == nil {
+					return nil
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+	}
+	if val == nil {
+		return nil
+	}
+	return setValue(v, val)
+}
+
+// setValue stores the canonical value x in v.
+func setValue(v reflect.Value, x interface{}) error {
+	overflow := false
+	switch x := x.(type) {
+	case bool:
+		v.SetBool(x)
+	case int64:
+		switch v.Kind() {
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+			if overflow = x < 0 || v.OverflowUint(uint64(x)); !overflow {
+				v.SetUint(uint64(x))
+			}
+		default:
+			if overflow = v.OverflowInt(x); !overflow {
+				v.SetInt(x)
+			}
+		}
+	case uint64:
+		if overflow = v.OverflowUint(x); !overflow {
+			v.SetUint(x)
+		}
+	case float64:
+		v.SetFloat(x)
+	case complex128:
+		v.SetComplex(x)
+	case string:
+		v.SetString(x)
+	case time.Duration:
+		v.SetInt(int64(x))
+	case []byte:
+		v.SetBytes(x)
+	default:
+		v.Set(reflect.ValueOf(x))
+	}
+	if overflow {
+		return fmt.Errorf("value %v overflows %s", x, v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type importee struct {
+	*Base
+	Name   string
+	Count  *int
+	Small  uint8
+	Ratio  float64
+	When   time.Time
+	Lap    time.Duration
+	Nested struct {
+		Ok   bool
+		Note *string
+	}
+}
+
+func TestImporterRoundTrip(t *testing.T) {
+	three, note := 3, "hi"
+	when := time.Date(2024, 5, 6, 7, 8, 9, 0, time.Local)
+	data := []importee{
+		{Base: &Base{ID: 1}, Name: "a", Count: &three, Small: 200, Ratio: 0.5, When: when, Lap: time.Minute},
+		{Base: &Base{ID: 2}, Name: "b, \"c\"", When: when.Add(time.Hour)},
+	}
+	data[0].Nested.Ok = true
+	data[0].Nested.Note = &note
+	specs := []string{"ID", "Name", "Count", "Small", "Ratio", "When", "Lap", "Nested.Ok", "Nested.Note"}
+	extractor, err := NewExtractor(data, specs...)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+
+	var got []*importee
+	if err := (Importer{Reader: buf}).Import(&got); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("Got %d rows", len(got))
+	}
+	if !reflect.DeepEqual(*got[0], data[0]) {
+		t.Errorf("Got %+v, want %+v", *got[0], data[0])
+	}
+	if !reflect.DeepEqual(*got[1], data[1]) {
+		t.Errorf("Got %+v, want %+v", *got[1], data[1])
+	}
+}
+
+func TestImporter(t *testing.T) {
+	input := "Name;Extra;Count;When\nx;1;7;2024-01-02T03:04:05Z\ny;2;;\n"
+	var got []importee
+	err := Importer{Reader: strings.NewReader(input), Comma: ';'}.Import(&got, "Count", "Name", "When")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(got) != 2 || got[0].Name != "x" || *got[0].Count != 7 || got[1].Count != nil ||
+		!got[0].When.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) || !got[1].When.IsZero() {
+		t.Errorf("Got %+v", got)
+	}
+
+	for i, tc := range []struct {
+		input string
+		specs []string
+	}{
+		{"Name,Count\nx,y\n", nil},
+		{"Name,Small\nx,300\n", nil},
+		{"Name\nx\n", []string{"Name", "Count"}},
+		{"Name,Weight\nx,1\n", nil},
+		{"Name\nx\n", []string{"When.Year()"}},
+		{"", nil},
+	} {
+		var dst []importee
+		if err := (Importer{Reader: strings.NewReader(tc.input)}).Import(&dst, tc.specs...); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+	if err := (Importer{Reader: strings.NewReader("Name\n")}).Import([]importee{}); err == nil {
+		t.Errorf("Missing error for non-pointer destination")
+	}
+}
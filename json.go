@@ -0,0 +1,203 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+	"strings"
+	"time"
+)
+
+// JSONDumper dumps the values as a JSON array of objects, one object per
+// row with the column names as keys.
+type JSONDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// Columnar switches to column oriented output: A single object
+	// with the column names as keys and arrays of the column values.
+	Columnar bool
+}
+
+// Dump implements the Dump method of a Dumper.
+// Bools, numbers and NA values are output as JSON literals, everything
+// else as JSON strings formatted according to format. Ints and Durations
+// are output as numbers if format yields a valid JSON number for them,
+// e.g. for DurationFmt "%d".
+func (d JSONDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	f := jsonFormat{format}
+	keys := jsonKeys(e)
+	if d.Columnar {
+		return d.dumpColumns(e, keys, f)
+	}
+	if _, err := io.WriteString(d.Writer, "["); err != nil {
+		return err
+	}
+	err = e.formatRows(func(buf []byte, r int) []byte {
+		if r > 0 {
+			buf = append(buf, ',')
+		}
+		return append(append(buf, '\n'), jsonObject(e, keys, f, r)...)
+	}, func(p []byte) error {
+		_, err := d.Writer.Write(p)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(d.Writer, "\n]\n")
+	return err
+}
+
+// dumpColumns dumps e column oriented.
+func (d JSONDumper) dumpColumns(e *Extractor, keys []string, f Formater) error {
+	if _, err := io.WriteString(d.Writer, "{"); err != nil {
+		return err
+	}
+	sep := "\n"
+	for i, field := range e.Columns {
+		values := make([]string, e.N)
+		for r := range values {
+			values[r] = field.Print(f, r)
+		}
+		_, err := fmt.Fprintf(d.Writer, "%s%s[%s]", sep, keys[i], strings.Join(values, ","))
+		if err != nil {
+			return err
+		}
+		sep = ",\n"
+	}
+	e.progress(0, e.N)
+	_, err := io.WriteString(d.Writer, "\n}\n")
+	return err
+}
+
+// JSONLinesDumper dumps the values as JSON Lines (also known as NDJSON):
+// One JSON object per row and line with the column names as keys.
+type JSONLinesDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+}
+
+// Dump implements the Dump method of a Dumper.
+// The values are represented like in JSONDumper. Each row is written to
+// Writer as soon as it is formatted (or its chunk of rows if e.Workers is
+// greater than one).
+func (d JSONLinesDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	f := jsonFormat{format}
+	keys := jsonKeys(e)
+	return e.formatRows(func(buf []byte, r int) []byte {
+		return append(append(buf, jsonObject(e, keys, f, r)...), '\n')
+	}, func(p []byte) error {
+		_, err := d.Writer.Write(p)
+		return err
+	})
+}
+
+// jsonKeys returns the quoted names of the columns of e followed by a colon.
+func jsonKeys(e *Extractor) []string {
+	keys := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		keys[i] = jsonQuote(field.Name) + ":"
+	}
+	return keys
+}
+
+// jsonObject returns row r of e as a JSON object.
+func jsonObject(e *Extractor, keys []string, f Formater, r int) string {
+	obj := "{"
+	for i, field := range e.Columns {
+		if i > 0 {
+			obj += ","
+		}
+		obj += keys[i] + field.Print(f, r)
+	}
+	return obj + "}"
+}
+
+// jsonQuote returns s as a JSON string.
+func jsonQuote(s string) string {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// jsonNumber returns s if it is a valid JSON number and s as a JSON string
+// otherwise.
+func jsonNumber(s string) string {
+	if s != "" && (s[0] == '-' || (s[0] >= '0' && s[0] <= '9')) && json.Valid([]byte(s)) {
+		return s
+	}
+	return jsonQuote(s)
+}
+
+// jsonFormat is a Formater producing JSON literals based on Format.
+type jsonFormat struct {
+	Format
+}
+
+func (f jsonFormat) Bool(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+func (f jsonFormat) Int(i int64) string {
+	return jsonNumber(f.Format.Int(i))
+}
+func (f jsonFormat) Uint(u uint64) string {
+	return jsonNumber(f.Format.Uint(u))
+}
+func (f jsonFormat) Float(x float64) string {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return "null"
+	}
+	return jsonNumber(f.Format.Float(x))
+}
+func (f jsonFormat) Complex(c complex128) string {
+	if cmplx.IsNaN(c) || cmplx.IsInf(c) {
+		return "null"
+	}
+	return jsonQuote(f.Format.Complex(c))
+}
+func (f jsonFormat) String(s string) string {
+	return jsonQuote(f.truncate(s))
+}
+func (f jsonFormat) Bytes(b []byte) string {
+	return jsonQuote(f.truncate(f.encode(b)))
+}
+func (f jsonFormat) Time(t time.Time) string {
+	if f.TimeUnit > 0 {
+		return f.Format.Time(t)
+	}
+	return jsonQuote(f.Format.Time(t))
+}
+func (f jsonFormat) Duration(d time.Duration) string {
+	return jsonNumber(f.Format.Duration(d))
+}
+func (f jsonFormat) NA() string {
+	return "null"
+}
+
+func init() {
+	RegisterDumper("json", func(w io.Writer) Dumper {
+		return JSONDumper{Writer: w}
+	})
+	RegisterDumper("ndjson", func(w io.Writer) Dumper {
+		return JSONLinesDumper{Writer: w}
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	format.TimeFmt = time.RFC3339
+	format.DurationFmt = "%d"
+	want := `[
+{"B":true,"I":12,"F":3.141,"S":"Hello","T":"2000-01-02T15:20:30Z","D":3000000000,"C":"(3.1+4.2i)","SME":null},
+{"B":true,"I":14,"F":2.718,"S":"World","T":"2000-01-02T03:20:30Z","D":9000000,"C":"(0+9i)","SME":null},
+{"B":false,"I":14,"F":null,"S":"Go","T":"2000-01-02T15:20:30Z","D":0,"C":"(0+0i)","SME":null},
+{"B":false,"I":16,"F":6.022e+23,"S":"A Lot","T":"2009-12-28T09:45:00Z","D":30000000000000,"C":null,"SME":null}
+]
+`
+	buf := &bytes.Buffer{}
+	if err := (JSONDumper{Writer: buf}).Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	got := buf.String()
+	if got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("Invalid JSON")
+	}
+
+	// Human readable durations and special characters in strings.
+	data := []struct {
+		S string
+		D time.Duration
+	}{{"<a\"b\n>", 90 * time.Second}}
+	extractor, _ = NewExtractor(data, "S", "D")
+	buf.Reset()
+	JSONDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	want = "[\n{\"S\":\"<a\\\"b\\n>\",\"D\":\"1m30s\"}\n]\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	extractor.Bind(data[:0])
+	buf.Reset()
+	JSONDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	if got := buf.String(); got != "[\n]\n" || !json.Valid(buf.Bytes()) {
+		t.Errorf("Got %q for empty data", got)
+	}
+}
+
+func TestJSONLinesDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "I", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := `{"S":"Hello","I":12,"F":3.141}
+{"S":"World","I":14,"F":2.718}
+{"S":"Go","I":14,"F":null}
+{"S":"A Lot","I":16,"F":6.022e+23}
+`
+	buf := &bytes.Buffer{}
+	if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestColumnarJSONDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "I", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := `{
+"S":["Hello","World","Go","A Lot"],
+"I":[12,14,14,16],
+"F":[3.141,2.718,null,6.022e+23]
+}
+`
+	buf := &bytes.Buffer{}
+	if err := (JSONDumper{Writer: buf, Columnar: true}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	extractor.Columns = nil
+	buf.Reset()
+	JSONDumper{Writer: buf, Columnar: true}.Dump(extractor, DefaultFormat)
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("Invalid JSON %q for no columns", buf.String())
+	}
+}
@@ -0,0 +1,183 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// NewJSONExtractor returns an Extractor for the JSON objects read from r
+// which contains either an array of objects or a sequence of objects like
+// JSON Lines (NDJSON). There is one column per key in the order of first
+// appearance; missing keys and null values are NA. The column types are
+// inferred from the values:
+//   - Bool for booleans
+//   - Int for integral numbers in the range of int64, Float for other numbers
+//   - Time for strings which are all in RFC 3339 format, String otherwise
+//   - String for nested objects and arrays (as compact JSON text) and
+//     columns with values of different JSON types (non-strings as JSON
+//     text).
+//
+// All values are kept in memory, the returned Extractor cannot be rebound.
+func NewJSONExtractor(r io.Reader) (*Extractor, error) {
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	array := false
+	if c, err := firstNonSpace(br); err == nil && c == '[' {
+		array = true
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	var names []string
+	index := map[string]int{}
+	var columns [][]json.RawMessage
+	n := 0
+	for dec.More() {
+		if t, err := dec.Token(); err != nil {
+			return nil, err
+		} else if t != json.Delim('{') {
+			return nil, fmt.Errorf("export: object %d is not a JSON object", n+1)
+		}
+		for dec.More() {
+			t, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			key := t.(string)
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err != nil {
+				return nil, err
+			}
+			c, ok := index[key]
+			if !ok {
+				c = len(names)
+				index[key] = c
+				names = append(names, key)
+				columns = append(columns, make([]json.RawMessage, n))
+			}
+			columns[c] = append(columns[c][:n], raw)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		n++
+		for c := range columns {
+			if len(columns[c]) < n {
+				columns[c] = append(columns[c], nil) // missing key
+			}
+		}
+	}
+	if array {
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+	}
+
+	e := &Extractor{N: n}
+	for c, name := range names {
+		typ, values := jsonColumn(columns[c])
+		e.Columns = append(e.Columns, Column{
+			Name:  name,
+			typ:   typ,
+			value: func(i int) interface{} { return values[i] },
+		})
+	}
+	return e, nil
+}
+
+// firstNonSpace returns the first non-whitespace byte of br without
+// consuming it.
+func firstNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if c != ' ' && c != '\t' && c != '\n' && c != '\r' {
+			return c, br.UnreadByte()
+		}
+	}
+}
+
+// jsonColumn infers the type of the raw JSON values of a column (nil or
+// missing values are NA) and returns their canonical values.
+func jsonColumn(raws []json.RawMessage) (Type, []interface{}) {
+	kinds := map[byte]bool{}
+	integral, times := true, true
+	for _, raw := range raws {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		switch k := raw[0]; k {
+		case 't', 'f':
+			kinds['b'] = true
+		case '"':
+			kinds['s'] = true
+			var s string
+			json.Unmarshal(raw, &s)
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				times = false
+			}
+		case '{', '[':
+			kinds['o'] = true
+		default:
+			kinds['n'] = true
+			if _, err := strconv.ParseInt(string(raw), 10, 64); err != nil {
+				integral = false
+			}
+		}
+	}
+	typ := String
+	if len(kinds) == 1 {
+		switch {
+		case kinds['b']:
+			typ = Bool
+		case kinds['n'] && integral:
+			typ = Int
+		case kinds['n']:
+			typ = Float
+		case kinds['s'] && times:
+			typ = Time
+		}
+	}
+
+	values := make([]interface{}, len(raws))
+	for i, raw := range raws {
+		if len(raw) == 0 || string(raw) == "null" {
+			continue
+		}
+		switch typ {
+		case Bool:
+			values[i] = raw[0] == 't'
+		case Int:
+			values[i], _ = strconv.ParseInt(string(raw), 10, 64)
+		case Float:
+			values[i], _ = strconv.ParseFloat(string(raw), 64)
+		case Time:
+			var t time.Time
+			json.Unmarshal(raw, &t)
+			values[i] = t
+		default:
+			if raw[0] == '"' {
+				var s string
+				json.Unmarshal(raw, &s)
+				values[i] = s
+			} else {
+				buf := &bytes.Buffer{}
+				json.Compact(buf, raw)
+				values[i] = buf.String()
+			}
+		}
+	}
+	return typ, values
+}
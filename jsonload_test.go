@@ -0,0 +1,58 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJSONExtractor(t *testing.T) {
+	array := `[
+  {"name": "a", "n": 1, "x": 1.5, "ok": true, "at": "2024-01-02T03:04:05Z", "tags": ["p", "q"]},
+  {"name": "b", "n": null, "x": 2, "mixed": "s"},
+  {"n": 3, "x": -1e3, "ok": false, "mixed": 4, "at": "2024-01-03T00:00:00+01:00", "tags": {"k": 1}}
+]`
+	lines := strings.Replace(strings.Trim(array, "[]\n"), "},\n", "}\n", -1)
+	for _, input := range []string{array, lines} {
+		extractor, err := NewJSONExtractor(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		wantTypes := []Type{String, Int, Float, Bool, Time, String, String}
+		if len(extractor.Columns) != len(wantTypes) || extractor.N != 3 {
+			t.Fatalf("Got %d columns and %d rows", len(extractor.Columns), extractor.N)
+		}
+		for i, field := range extractor.Columns {
+			if field.Type() != wantTypes[i] {
+				t.Errorf("Column %s has type %s, want %s", field.Name, field.Type(), wantTypes[i])
+			}
+		}
+		format := DefaultFormat
+		format.TimeLoc = time.UTC
+		buf := &bytes.Buffer{}
+		DelimitedDumper{Writer: buf}.Dump(extractor, format)
+		want := `name,n,x,ok,at,tags,mixed
+a,1,1.5,true,2024-01-02T03:04:05,"[""p"",""q""]",
+b,,2,,,,s
+,3,-1000,false,2024-01-02T23:00:00,"{""k"":1}",4
+`
+		if got := buf.String(); got != want {
+			t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+		}
+	}
+
+	for _, input := range []string{`[1, 2]`, `{"a": 1`, `[{"a": 1}`} {
+		if _, err := NewJSONExtractor(strings.NewReader(input)); err == nil {
+			t.Errorf("Missing error for %s", input)
+		}
+	}
+	empty, err := NewJSONExtractor(strings.NewReader(" []"))
+	if err != nil || empty.N != 0 || len(empty.Columns) != 0 {
+		t.Errorf("Got %v %v", empty, err)
+	}
+}
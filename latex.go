@@ -0,0 +1,101 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// LaTeXDumper dumps the values as a LaTeX tabular environment with numeric
+// columns right aligned and all other columns left aligned.
+type LaTeXDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the header line.
+
+	// Booktabs uses the rules \toprule, \midrule and \bottomrule of
+	// the booktabs package instead of \hline.
+	Booktabs bool
+
+	// Caption and Label are the caption and label of a table
+	// environment wrapped around the tabular. The table environment
+	// is omitted if both are empty. Caption is escaped, Label not.
+	Caption string
+	Label   string
+}
+
+// latexEscaper escapes the LaTeX special characters.
+var latexEscaper = strings.NewReplacer(
+	`\`, `\textbackslash{}`,
+	"&", `\&`, "%", `\%`, "$", `\$`, "#", `\#`, "_", `\_`,
+	"{", `\{`, "}", `\}`,
+	"~", `\textasciitilde{}`, "^", `\textasciicircum{}`,
+)
+
+// Dump implements the Dump method of a Dumper.
+// The formated values are escaped.
+func (d LaTeXDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	top, mid, bottom := `\hline`, `\hline`, `\hline`
+	if d.Booktabs {
+		top, mid, bottom = `\toprule`, `\midrule`, `\bottomrule`
+	}
+	align := ""
+	for _, field := range e.Columns {
+		switch field.Type() {
+		case Int, Uint, Float, Duration:
+			align += "r"
+		default:
+			align += "l"
+		}
+	}
+
+	w := bufio.NewWriter(d.Writer)
+	table := d.Caption != "" || d.Label != ""
+	if table {
+		w.WriteString("\\begin{table}\n\\centering\n")
+		if d.Caption != "" {
+			w.WriteString(`\caption{` + latexEscaper.Replace(d.Caption) + "}\n")
+		}
+		if d.Label != "" {
+			w.WriteString(`\label{` + d.Label + "}\n")
+		}
+	}
+	w.WriteString(`\begin{tabular}{` + align + "}\n" + top + "\n")
+	if !d.OmitHeader {
+		for i, field := range e.Columns {
+			if i > 0 {
+				w.WriteString(" & ")
+			}
+			w.WriteString(latexEscaper.Replace(field.Name))
+		}
+		w.WriteString(" \\\\\n" + mid + "\n")
+	}
+	for r := 0; r < e.N; r++ {
+		for i, field := range e.Columns {
+			if i > 0 {
+				w.WriteString(" & ")
+			}
+			w.WriteString(latexEscaper.Replace(field.Print(format, r)))
+		}
+		w.WriteString(" \\\\\n")
+		e.progress(r, r+1)
+	}
+	w.WriteString(bottom + "\n\\end{tabular}\n")
+	if table {
+		w.WriteString("\\end{table}\n")
+	}
+	return w.Flush()
+}
+
+func init() {
+	RegisterDumper("latex", func(w io.Writer) Dumper {
+		return LaTeXDumper{Writer: w, Booktabs: true}
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestLaTeXDumper(t *testing.T) {
+	data := []struct {
+		Name  string
+		Value float64
+	}{{"50% & more_", 1.5}, {`{a}\b~^#$`, -2}}
+	extractor, err := NewExtractor(data, "Name", "Value")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	d := LaTeXDumper{
+		Writer:   buf,
+		Booktabs: true,
+		Caption:  "Costs in $",
+		Label:    "tab:costs",
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `\begin{table}
+\centering
+\caption{Costs in \$}
+\label{tab:costs}
+\begin{tabular}{lr}
+\toprule
+Name & Value \\
+\midrule
+50\% \& more\_ & 1.5 \\
+\{a\}\textbackslash{}b\textasciitilde{}\textasciicircum{}\#\$ & -2 \\
+\bottomrule
+\end{tabular}
+\end{table}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	LaTeXDumper{Writer: buf, OmitHeader: true}.Dump(extractor, DefaultFormat)
+	want = `\begin{tabular}{lr}
+\hline
+50\% \& more\_ & 1.5 \\
+\{a\}\textbackslash{}b\textasciitilde{}\textasciicircum{}\#\$ & -2 \\
+\hline
+\end{tabular}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
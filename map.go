@@ -0,0 +1,89 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// bindMap is the map version of Bind: The values of data are bound like a
+// slice in the order of their keys.
+func (e *Extractor) bindMap(data interface{}) {
+	v := reflect.ValueOf(data)
+	keys := v.MapKeys()
+	less := func(i, j int) bool { return keyLess(keys[i], keys[j]) }
+	if e.KeyOrder != nil {
+		less = func(i, j int) bool {
+			return e.KeyOrder(keys[i].Interface(), keys[j].Interface())
+		}
+	}
+	sort.Slice(keys, less)
+
+	sorted := reflect.MakeSlice(reflect.SliceOf(v.Type().Key()), len(keys), len(keys))
+	values := reflect.MakeSlice(reflect.SliceOf(v.Type().Elem()), len(keys), len(keys))
+	for i, k := range keys {
+		sorted.Index(i).Set(k)
+		values.Index(i).Set(v.MapIndex(k))
+	}
+	e.keys = sorted
+	e.bindSOM(values.Interface())
+	for c := range e.Columns {
+		if e.Columns[c].mapKey {
+			e.bindKey(&e.Columns[c])
+		}
+	}
+}
+
+// bindKey sets the value function of the key column c to the bound keys.
+func (e *Extractor) bindKey(c *Column) {
+	keys, access, typ, unsigned := e.keys, c.access, c.typ, c.unsigned
+	c.value = func(i int) interface{} {
+		return retrieve(keys.Index(i), access, 0, typ, unsigned)
+	}
+}
+
+// AddKeyColumn inserts a column with the given name in front of the other
+// columns whose values are the map keys of the data of e. It returns an
+// error if e is not an Extractor for map data or if the key type cannot
+// be used as a column.
+func (e *Extractor) AddKeyColumn(name string) error {
+	if e.typ == nil || e.typ.Kind() != reflect.Map {
+		return fmt.Errorf("export: extractor is not for map data")
+	}
+	steps, typ, unsigned, err := finalSteps(e.typ.Key(), nil)
+	if err != nil {
+		return err
+	}
+	key := Column{
+		Name:     name,
+		typ:      typ,
+		access:   steps,
+		unsigned: unsigned,
+		mapKey:   true,
+	}
+	e.bindKey(&key)
+	e.Columns = append([]Column{key}, e.Columns...)
+	return nil
+}
+
+// keyLess orders map keys a and b of the same type: Bools, numbers and
+// strings ascending, other types by their fmt representation.
+func keyLess(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Bool:
+		return !a.Bool() && b.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return a.Uint() < b.Uint()
+	case reflect.Float32, reflect.Float64:
+		return a.Float() < b.Float()
+	case reflect.String:
+		return a.String() < b.String()
+	}
+	return fmt.Sprint(a.Interface()) < fmt.Sprint(b.Interface())
+}
@@ -0,0 +1,64 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMapExtractor(t *testing.T) {
+	type Stock struct {
+		Count int
+		Price float64
+	}
+	data := map[string]Stock{
+		"pear":  {3, 0.5},
+		"apple": {7, 0.25},
+		"fig":   {1, 2},
+	}
+	extractor, err := NewExtractor(data, "Count", "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := extractor.AddKeyColumn("Fruit"); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dump := func() string {
+		buf := &bytes.Buffer{}
+		if err := (DelimitedDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return buf.String()
+	}
+	if got, want := dump(), "Fruit,Count,Price\napple,7,0.25\nfig,1,2\npear,3,0.5\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	// Order by descending count.
+	extractor.KeyOrder = func(a, b interface{}) bool {
+		return data[a.(string)].Count > data[b.(string)].Count
+	}
+	data["kiwi"] = Stock{5, 1}
+	extractor.Bind(data)
+	if got, want := dump(), "Fruit,Count,Price\napple,7,0.25\nkiwi,5,1\npear,3,0.5\nfig,1,2\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	numbers := map[int]*Stock{10: {1, 1}, 2: {2, 2}, 33: nil}
+	extractor, err = NewExtractor(numbers, "Count")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddKeyColumn("N")
+	if got, want := dump(), "N,Count\n2,2\n10,1\n33,\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	extractor, _ = NewExtractor([]Stock{}, "Count")
+	if err := extractor.AddKeyColumn("Key"); err == nil {
+		t.Errorf("Missing error for slice data")
+	}
+}
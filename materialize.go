@@ -0,0 +1,100 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import "time"
+
+// Materialize returns an Extractor with the values of all columns of e
+// evaluated once and stored in typed slices like []float64 or []string.
+// Dumping the result several times, e.g. to different formats, neither
+// accesses the data bound to e nor calls methods again. Failing method
+// calls are recorded and handled by the Dumpers like for e.
+//
+// The returned Extractor keeps the column names, Render, Format, Unit,
+// Label and Description of the columns as well as the settings of e like
+// OnError and Progress. It cannot be rebound and is not affected by
+// rebinding e.
+func (e *Extractor) Materialize() *Extractor {
+	m := e.inherit(&Extractor{N: e.N, Columns: make([]Column, len(e.Columns))})
+	m.UnitsInHeader = e.UnitsInHeader
+	for c, field := range e.Columns {
+		column := Column{
+			Name:        field.Name,
+			Render:      field.Render,
+			Format:      field.Format,
+			Unit:        field.Unit,
+			Label:       field.Label,
+			Description: field.Description,
+			typ:         field.typ,
+		}
+		switch field.Type() {
+		case Bool:
+			column.value = materialized[bool](field, e.N)
+		case Int:
+			column.value = materialized[int64](field, e.N)
+		case Uint:
+			column.value = materialized[uint64](field, e.N)
+		case Float:
+			column.value = materialized[float64](field, e.N)
+		case Complex:
+			column.value = materialized[complex128](field, e.N)
+		case String:
+			column.value = materialized[string](field, e.N)
+		case Time:
+			column.value = materialized[time.Time](field, e.N)
+		case Duration:
+			column.value = materialized[time.Duration](field, e.N)
+		case Bytes:
+			column.value = materialized[[]byte](field, e.N)
+		default:
+			column.value = materialized[interface{}](field, e.N)
+		}
+		if field.fail != nil {
+			errs := map[int]error{}
+			for r := 0; r < e.N; r++ {
+				// Only NA values can stem from a failed call.
+				if column.value(r) != nil {
+					continue
+				}
+				if err := field.fail(r); err != nil {
+					errs[r] = err
+				}
+			}
+			if len(errs) > 0 {
+				column.fail = func(i int) error { return errs[i] }
+			}
+		}
+		m.Columns[c] = column
+	}
+	return m
+}
+
+// materialized stores the n values of field in a slice of their canonical
+// type T and returns the value function reading them. Values not of type T
+// are NA.
+func materialized[T any](field Column, n int) func(i int) interface{} {
+	values := make([]T, n)
+	var na []bool // na is allocated on the first NA value.
+	for r := range values {
+		v, ok := field.value(r).(T)
+		if !ok {
+			if na == nil {
+				na = make([]bool, n)
+			}
+			na[r] = true
+			continue
+		}
+		values[r] = v
+	}
+	if na == nil {
+		return func(i int) interface{} { return values[i] }
+	}
+	return func(i int) interface{} {
+		if na[i] {
+			return nil
+		}
+		return values[i]
+	}
+}
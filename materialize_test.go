@@ -0,0 +1,71 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+var materializeCalls int
+
+type measurement struct {
+	Sensor string
+	Value  *float64
+	At     time.Time
+	Raw    []byte
+}
+
+func (r measurement) Check() (bool, error) {
+	materializeCalls++
+	if r.Value == nil {
+		return false, errors.New("no value")
+	}
+	return *r.Value > 0, nil
+}
+
+func TestMaterialize(t *testing.T) {
+	v1, v2 := 1.5, -2.0
+	data := []measurement{{"a", &v1, time1, []byte("x")}, {"b", nil, time2, nil}, {"c", &v2, time1, nil}}
+	extractor, err := NewExtractor(data, "Sensor", "Value", "At", "Raw", "Check()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[1].Unit = "V"
+	extractor.UnitsInHeader = true
+	extractor.OnError = ErrorSkip
+	var errs []*RowError
+	dump := func(e *Extractor) string {
+		buf := &bytes.Buffer{}
+		errs, err = DumpErrors(DelimitedDumper{Writer: buf}, e, DefaultFormat)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return buf.String()
+	}
+	want := dump(extractor)
+
+	materializeCalls = 0
+	m := extractor.Materialize()
+	calls := materializeCalls
+	extractor.Bind([]measurement{{Sensor: "z"}})
+	for i := 0; i < 2; i++ {
+		if got := dump(m); got != want {
+			t.Errorf("Got %q, want %q", got, want)
+		}
+	}
+	if materializeCalls != calls {
+		t.Errorf("Methods called %d times after materialization", materializeCalls-calls)
+	}
+	if len(errs) != 1 || errs[0].Row != 1 {
+		t.Errorf("Got errors %v", errs)
+	}
+	if m.N != 3 || m.Columns[1].Value(1) != nil || m.Columns[3].Value(1) != nil ||
+		m.Columns[0].Value(2) != "c" {
+		t.Errorf("Bad values in %#v", m)
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MATLABDumper dumps the values as MATLAB column vector assignments,
+// optionally combined into a table.
+//
+// Bools are logical, Ints int64 and Uints uint64 vectors unless the column
+// contains NA values: Then a double vector with NaN for NA is used. Floats
+// and Complex values are double vectors with NaN for NA. Strings are cell
+// arrays of character vectors with empty ones for NA and Bytes cell arrays
+// of uint8 vectors.
+// Times are datetime vectors in UTC with NaT for NA and Durations are
+// duration vectors with NaN for NA.
+// Column names are turned into valid MATLAB identifiers.
+type MATLABDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// Table is the name of a table to construct from the individual
+	// column vectors. An empty value suppresses the table.
+	Table string
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is not used, all values are written with full precision.
+func (d MATLABDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(d.Writer)
+	names := make([]string, len(e.Columns))
+	for c, field := range e.Columns {
+		names[c] = matlabName(field.Name)
+		hasNA := false
+		for r := 0; r < e.N && !hasNA; r++ {
+			hasNA = field.value(r) == nil
+		}
+		open, close := "[", "]"
+		switch field.Type() {
+		case Bool:
+			if !hasNA {
+				open, close = "logical([", "])"
+			}
+		case Int:
+			if !hasNA {
+				open, close = "int64([", "])"
+			}
+		case Uint:
+			if !hasNA {
+				open, close = "uint64([", "])"
+			}
+		case String, Bytes:
+			open, close = "{", "}"
+		case Time:
+			open, close = "datetime({", "}, 'InputFormat', 'yyyy-MM-dd HH:mm:ss.SSSSSSSSS', 'TimeZone', 'UTC')"
+		case Duration:
+			open, close = "seconds([", "])"
+		}
+		w.WriteString(names[c] + " = " + open)
+		for r := 0; r < e.N; r++ {
+			if r > 0 {
+				if r%10 == 0 {
+					w.WriteString("; ...\n    ")
+				} else {
+					w.WriteString("; ")
+				}
+			}
+			w.WriteString(matlabLiteral(field.Type(), field.value(r)))
+		}
+		w.WriteString(close + ";\n")
+	}
+	e.progress(0, e.N)
+	if d.Table != "" {
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = "'" + name + "'"
+		}
+		w.WriteString(d.Table + " = table(" + strings.Join(names, ", ") +
+			", 'VariableNames', {" + strings.Join(quoted, ", ") + "});\n")
+	}
+	return w.Flush()
+}
+
+// matlabLiteral returns the canonical value v of a column of type typ as a
+// MATLAB literal.
+func matlabLiteral(typ Type, v interface{}) string {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return "true"
+		}
+		return "false"
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float64:
+		return matlabFloat(x)
+	case complex128:
+		im := matlabFloat(imag(x))
+		if !strings.HasPrefix(im, "-") {
+			im = "+" + im
+		}
+		return matlabFloat(real(x)) + im + "i"
+	case string:
+		return matlabString(x)
+	case []byte:
+		s := make([]string, len(x))
+		for i, c := range x {
+			s[i] = strconv.Itoa(int(c))
+		}
+		return "uint8([" + strings.Join(s, " ") + "])"
+	case time.Time:
+		return "'" + x.UTC().Format("2006-01-02 15:04:05.000000000") + "'"
+	case time.Duration:
+		return matlabFloat(x.Seconds())
+	}
+	switch typ {
+	case String, Time:
+		return "''"
+	case Bytes:
+		return "uint8([])"
+	}
+	return "NaN"
+}
+
+// matlabFloat returns x as a MATLAB number.
+func matlabFloat(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
+
+// matlabString returns s as a MATLAB character vector expression.
+func matlabString(s string) string {
+	s = "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	s = strings.NewReplacer("\r", "' char(13) '", "\n", "' char(10) '").Replace(s)
+	return "[" + s + "]"
+}
+
+// matlabName returns name turned into a valid MATLAB identifier.
+func matlabName(name string) string {
+	id := []byte(name)
+	for i, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			id[i] = '_'
+		}
+	}
+	if len(id) == 0 || !(id[0] >= 'a' && id[0] <= 'z' || id[0] >= 'A' && id[0] <= 'Z') {
+		return "x" + string(id)
+	}
+	return string(id)
+}
+
+func init() {
+	RegisterDumper("matlab", func(w io.Writer) Dumper {
+		return MATLABDumper{Writer: w, Table: "data"}
+	})
+}
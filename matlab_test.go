@@ -0,0 +1,63 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestMATLABDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (MATLABDumper{Writer: buf, Table: "tbl"}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `B = logical([true; true; false; false]);
+I = int64([12; 14; 14; 16]);
+F = [3.14149; 2.71828; NaN; 6.02214e+23];
+S = {'Hello'; 'World'; 'Go'; 'A Lot'};
+T = datetime({'2000-01-02 15:20:30.000000000'; '2000-01-02 03:20:30.000000000'; '2000-01-02 15:20:30.000000000'; '2009-12-28 09:45:00.000000000'}, 'InputFormat', 'yyyy-MM-dd HH:mm:ss.SSSSSSSSS', 'TimeZone', 'UTC');
+D = seconds([3; 0.009; 0; 30000]);
+C = [3.0999999046325684+4.199999809265137i; 0+9i; 0+0i; -Inf+7i];
+SME = {''; ''; ''; ''};
+tbl = table(B, I, F, S, T, D, C, SME, 'VariableNames', {'B', 'I', 'F', 'S', 'T', 'D', 'C', 'SME'});
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	data := []struct {
+		S *string
+		I *int
+		T *time.Time
+	}{{nil, nil, nil}, {new(string), new(int), &time.Time{}}}
+	*data[1].S = "it's\na"
+	extractor, _ = NewExtractor(data, "S", "I", "T")
+	buf.Reset()
+	MATLABDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	want = `S = {''; ['it''s' char(10) 'a']};
+I = [NaN; 0];
+T = datetime({''; '0001-01-01 00:00:00.000000000'}, 'InputFormat', 'yyyy-MM-dd HH:mm:ss.SSSSSSSSS', 'TimeZone', 'UTC');
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestMATLABName(t *testing.T) {
+	for name, want := range map[string]string{
+		"Price": "Price", "Clarity.String": "Clarity_String", "_x": "x_x", "1a": "x1a", "": "x",
+	} {
+		if got := matlabName(name); got != want {
+			t.Errorf("matlabName(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
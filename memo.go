@@ -0,0 +1,175 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"sync"
+)
+
+// errNilElement is returned by walkers for nil pointer elements.
+var errNilElement = errors.New("nil element")
+
+// prefixMemo memoizes the result of walking a prefix of the access steps
+// for the last row. As the Dumpers process the data row by row, columns
+// sharing the prefix evaluate it once per row.
+type prefixMemo struct {
+	mu  sync.Mutex
+	row int
+	v   reflect.Value
+	err error
+}
+
+// get returns the result of walk for row i, computing it only if the
+// memoized result is for a different row.
+func (m *prefixMemo) get(i int, walk func() (reflect.Value, error)) (reflect.Value, error) {
+	m.mu.Lock()
+	if m.row == i {
+		v, err := m.v, m.err
+		m.mu.Unlock()
+		return v, err
+	}
+	m.mu.Unlock()
+	v, err := walk()
+	m.mu.Lock()
+	m.row, m.v, m.err = i, v, err
+	m.mu.Unlock()
+	return v, err
+}
+
+// prefixMemos are the memos of the step prefixes shared by several
+// columns keyed by prefixKey.
+type prefixMemos map[string]*prefixMemo
+
+// reset forgets the memoized results so that changes to the data made
+// between two dumps are seen.
+func (memos prefixMemos) reset() {
+	for _, m := range memos {
+		m.mu.Lock()
+		m.row = -1
+		m.mu.Unlock()
+	}
+}
+
+// prefixKey identifies the steps from the root'th root value, i.e. the
+// slice field of columns-of-slices data (0 for other data).
+func prefixKey(root int, steps []step) string {
+	key := strconv.Itoa(root)
+	for _, s := range steps {
+		kind := "f"
+		switch {
+		case s.convert != nil:
+			kind = "c"
+		case s.isMethodCall():
+			kind = "m"
+		case s.key.IsValid():
+			kind = "k"
+		}
+		key += "\x00" + kind + s.name
+	}
+	return key
+}
+
+// sharedPrefixes returns memos for the prefixes of the access steps of
+// columns (and the operands of expression columns) which contain a method
+// call or conversion and are shared by at least two columns. Walking plain
+// fields is cheap and is not memoized. The complete steps of columns which
+// may fail are memoized too so that the value and the error of a cell stem
+// from one walk. root returns the index of the root value of a column.
+func sharedPrefixes(columns []Column, root func(c Column) int) prefixMemos {
+	count := map[string]int{}
+	add := func(c Column) {
+		called := false
+		for k, s := range c.access {
+			if called = called || s.convert != nil || s.isMethodCall(); called {
+				count[prefixKey(root(c), c.access[:k+1])]++
+			}
+		}
+		if mayFail(c.access) {
+			count[prefixKey(root(c), c.access)]++
+		}
+	}
+	for _, c := range columns {
+		switch {
+		case c.expr != nil:
+			for _, operand := range c.expr.operands {
+				add(operand)
+			}
+		case !c.synthetic && !c.mapKey && c.compute == nil:
+			add(c)
+		}
+	}
+	memos := prefixMemos{}
+	for key, n := range count {
+		if n > 1 {
+			memos[key] = &prefixMemo{row: -1}
+		}
+	}
+	return memos
+}
+
+// walker returns a function which follows indir pointers from the i'th root
+// value and then steps; the result of the longest memoized prefix of steps
+// is reused and is itself computed from the next shorter memoized prefix.
+func (memos prefixMemos) walker(root int, steps []step, value func(i int) reflect.Value, indir int) func(i int) (reflect.Value, error) {
+	for k := len(steps); k > 0; k-- {
+		memo, ok := memos[prefixKey(root, steps[:k])]
+		if !ok {
+			continue
+		}
+		last, rest := steps[k-1:k], steps[k:]
+		inner := memos.walker(root, steps[:k-1], value, indir)
+		return func(i int) (reflect.Value, error) {
+			v, err := memo.get(i, func() (reflect.Value, error) {
+				v, err := inner(i)
+				if err != nil {
+					return v, err
+				}
+				return access(v, last)
+			})
+			if err != nil {
+				return v, err
+			}
+			return access(v, rest)
+		}
+	}
+	return func(i int) (reflect.Value, error) {
+		v := value(i)
+		for k := 0; k < indir; k++ {
+			if v.IsNil() {
+				return v, errNilElement
+			}
+			v = v.Elem()
+		}
+		return access(v, steps)
+	}
+}
+
+// bindWalker sets the value and fail functions of field to walk the steps
+// with walk.
+func (field *Column) bindWalker(walk func(i int) (reflect.Value, error)) {
+	typ, unsigned := field.typ, field.unsigned
+	if field.value == nil {
+		field.value = func(i int) interface{} {
+			res, err := walk(i)
+			if err != nil {
+				return nil
+			}
+			return canonical(res, typ, unsigned)
+		}
+	}
+	if mayFail(field.access) {
+		field.fail = func(i int) error {
+			if _, err := walk(i); err != nil {
+				if me, ok := err.(methodError); ok {
+					return me
+				}
+			}
+			return nil
+		}
+	}
+}
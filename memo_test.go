@@ -0,0 +1,96 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var stayCalls int64
+
+type span struct {
+	Start time.Time
+	Hours int
+}
+
+type visit struct {
+	Guest string
+	Slot  int
+}
+
+func (v visit) Stay() (span, error) {
+	atomic.AddInt64(&stayCalls, 1)
+	if v.Slot < 0 {
+		return span{}, errors.New("no slot")
+	}
+	return span{Start: time1.Add(time.Duration(v.Slot) * time.Hour), Hours: v.Slot}, nil
+}
+
+func TestSharedPrefixes(t *testing.T) {
+	data := []visit{{"a", 1}, {"b", -1}, {"c", 30}}
+	extractor, err := NewExtractor(data, "Guest", "Stay().Start", "Stay().Hours", "Stay().Start.Day()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	// Stay() and Stay().Start are shared, Stay().Hours and
+	// Stay().Start.Day() may fail.
+	if len(extractor.memos) != 4 {
+		t.Errorf("Got %d memos, want 4", len(extractor.memos))
+	}
+	extractor.OnError = ErrorNA
+	// The times of Stay are in UTC.
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	var errs []*RowError
+	dump := func() string {
+		buf := &bytes.Buffer{}
+		errs, err = DumpErrors(DelimitedDumper{Writer: buf}, extractor, format)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		return buf.String()
+	}
+
+	stayCalls = 0
+	got := dump()
+	// Once per row: The error of a NA value is taken from the memo.
+	if stayCalls != int64(len(data)) {
+		t.Errorf("Stay called %d times", stayCalls)
+	}
+	want := `Guest,Stay.Start,Stay.Hours,Stay.Start.Day
+a,2000-01-02T16:20:30,1,2
+b,,,
+c,2000-01-03T21:20:30,30,3
+`
+	if got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if len(errs) != 3 || errs[0].Row != 1 {
+		t.Errorf("Got errors %v", errs)
+	}
+
+	// Changes of the data are seen by the next dump.
+	data[2].Slot = 2
+	if got := dump(); !strings.HasSuffix(got, "c,2000-01-02T17:20:30,2,2\n") {
+		t.Errorf("Got %q", got)
+	}
+
+	// Interleaved rows of several workers yield the same values.
+	many := make([]visit, 3000)
+	for i := range many {
+		many[i] = visit{Guest: "g", Slot: i % 50}
+	}
+	extractor.Bind(many)
+	want = dump()
+	extractor.Workers = 4
+	if got := dump(); got != want {
+		t.Errorf("Workers changed output")
+	}
+}
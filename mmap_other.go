@@ -0,0 +1,22 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package export
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads the first size bytes of f into memory on systems without
+// support for memory-mapped files.
+func mmap(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	_, err := io.ReadFull(f, data)
+	return data, err
+}
+
+func munmap(data []byte) error { return nil }
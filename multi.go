@@ -0,0 +1,28 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+// MultiDumper dumps the values to several Dumpers, e.g. as CSV to a file
+// and as JSON to an HTTP response. The values are extracted only once;
+// the progress of this extraction is reported to the Progress hook.
+type MultiDumper []Dumper
+
+// Dump implements the Dump method of a Dumper.
+// All Dumpers are run, even if one fails; the first error is returned.
+func (d MultiDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	if len(d) > 1 {
+		e = e.cached()
+	}
+	for _, dumper := range d {
+		if derr := dumper.Dump(e, format); err == nil {
+			err = derr
+		}
+	}
+	return err
+}
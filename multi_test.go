@@ -0,0 +1,42 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMultiDumper(t *testing.T) {
+	calls := 0
+	extractor, err := NewExtractor(table[:3], "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	value := extractor.Columns[0].value
+	extractor.Columns[0].value = func(i int) interface{} {
+		calls++
+		return value(i)
+	}
+
+	text, json := &bytes.Buffer{}, &bytes.Buffer{}
+	d := MultiDumper{
+		DelimitedDumper{Writer: text, Comma: ','},
+		failDumper{},
+		JSONDumper{Writer: json},
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != errFail {
+		t.Errorf("Got error %v, want %v", err, errFail)
+	}
+	if calls != 3 {
+		t.Errorf("Got %d value calls, want 3", calls)
+	}
+	if got, want := text.String(), "I\n12\n14\n14\n"; got != want {
+		t.Errorf("CSV: Got %q, want %q", got, want)
+	}
+	if json.Len() == 0 {
+		t.Errorf("JSON: Missing output")
+	}
+}
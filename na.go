@@ -0,0 +1,67 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// TreatAsNA makes the values of column name which equal one of the given
+// sentinels NA, e.g. -1 for an unknown count, "" for a missing name or
+// time.Time{} for an unset time in data encoding missing values this way.
+// The sentinels are converted to the type of the column like in Coerce,
+// so e.g. -1 works for Int and Float columns; a NaN sentinel matches NaN
+// values. Times are compared with Equal. The rule is kept if e is
+// rebound.
+//
+// TreatAsNA returns an error if a sentinel cannot be converted to the type
+// of the column or if name does not denote exactly one column which
+// accesses the data, i.e. is not an index, key or computed column.
+func (e *Extractor) TreatAsNA(name string, sentinels ...interface{}) error {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		return err
+	}
+	field := &e.Columns[idx[0]]
+	values := make([]interface{}, len(sentinels))
+	for i, s := range sentinels {
+		if values[i], err = sentinelValue(s, field.Type()); err != nil {
+			return fmt.Errorf("export: bad NA sentinel %v for column %s: %v", s, name, err)
+		}
+	}
+	errNA := fmt.Errorf("sentinel")
+	return e.convertColumn(field, field.Type(), func(v interface{}) (interface{}, error) {
+		for _, s := range values {
+			if equalValues(v, s) {
+				return nil, errNA
+			}
+		}
+		return v, nil
+	})
+}
+
+// sentinelValue returns the Go value s as a canonical value of type typ.
+func sentinelValue(s interface{}, typ Type) (interface{}, error) {
+	v := reflect.ValueOf(s)
+	if !v.IsValid() {
+		return nil, fmt.Errorf("nil is NA already")
+	}
+	from := superType(v.Type())
+	if from == NA {
+		return nil, fmt.Errorf("unsupported type %T", s)
+	}
+	conv, err := coercion(from, typ, "")
+	if err != nil {
+		return nil, err
+	}
+	kind := v.Kind()
+	unsigned := kind == reflect.Uint8 || kind == reflect.Uint16 || kind == reflect.Uint32
+	x := canonical(v, from, unsigned)
+	if x == nil {
+		return nil, fmt.Errorf("nil is NA already")
+	}
+	return conv(x)
+}
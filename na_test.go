@@ -0,0 +1,84 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type legacy struct {
+	Count  int32
+	Owner  string
+	Seen   time.Time
+	Weight float64
+	Level  uint8
+}
+
+func TestTreatAsNA(t *testing.T) {
+	data := []legacy{
+		{-1, "", time.Time{}, -999, 255},
+		{3, "bob", time1, math.NaN(), 2},
+		{0, "-", time1.UTC(), 1.5, 0},
+	}
+	extractor, err := NewExtractor(data, "Count", "Owner", "Seen", "Weight", "Level", "Weight+1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for name, sentinels := range map[string][]interface{}{
+		"Count":    {-1},
+		"Owner":    {"", "-"},
+		"Seen":     {time.Time{}},
+		"Weight":   {-999, math.NaN()},
+		"Level":    {uint8(255)},
+		"Weight+1": {"2.5"},
+	} {
+		if err := extractor.TreatAsNA(name, sentinels...); err != nil {
+			t.Fatalf("Unexpected error for %s: %s", name, err)
+		}
+	}
+	want := [][]interface{}{
+		{nil, nil, nil, nil, nil, float64(-998)},
+		{int64(3), "bob", time1, nil, int64(2), math.NaN()},
+		{int64(0), nil, time1, 1.5, int64(0), nil},
+	}
+	check := func() {
+		t.Helper()
+		for r, row := range want {
+			for c, w := range row {
+				got := extractor.Columns[c].Value(r)
+				if (got == nil) != (w == nil) || !equalValues(got, w) {
+					t.Errorf("Row %d column %s: got %#v, want %#v",
+						r, extractor.Columns[c].Name, got, w)
+				}
+			}
+		}
+	}
+	check()
+
+	// The rules are kept when rebinding.
+	extractor.Bind(append([]legacy{}, data...))
+	check()
+
+	for _, c := range []struct {
+		name     string
+		sentinel interface{}
+	}{
+		{"Count", "many"},
+		{"Count", nil},
+		{"Count", []int{1}},
+		{"Seen", 3.5},
+		{"Unknown", 1},
+	} {
+		if err := extractor.TreatAsNA(c.name, c.sentinel); err == nil {
+			t.Errorf("Missing error for %s and %v", c.name, c.sentinel)
+		}
+	}
+	extractor.AddIndexColumn("Row", 0)
+	if err := extractor.TreatAsNA("Row", 0); err == nil {
+		t.Errorf("Missing error for index column")
+	}
+}
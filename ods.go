@@ -0,0 +1,181 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ODSDumper dumps the values as the single table of an OpenDocument
+// spreadsheet as used by LibreOffice.
+//
+// The cells are typed: Bools are boolean cells, Ints and Floats are float
+// cells, Times are date cells (in format.TimeLoc if set) and Durations are
+// time cells. Strings and Complex values are string cells. The displayed
+// text of each cell is produced by the format. NA values, NaN and infinite
+// floats yield empty cells.
+type ODSDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the row of column names.
+
+	// Sheet is the name of the table. It defaults to "Sheet1".
+	Sheet string
+}
+
+const odsMimetype = "application/vnd.oasis.opendocument.spreadsheet"
+
+// Dump implements the Dump method of a Dumper.
+func (d ODSDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	sheet := d.Sheet
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	z := zip.NewWriter(d.Writer)
+	// The mimetype must be the first, uncompressed file.
+	mw, err := z.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, odsMimetype); err != nil {
+		return err
+	}
+	mw, err = z.Create("META-INF/manifest.xml")
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(mw, xmlHeader+odsManifest); err != nil {
+		return err
+	}
+
+	cw, err := z.Create("content.xml")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(cw)
+	w.WriteString(xmlHeader + odsContentHead)
+	w.WriteString(`<table:table table:name="` + xmlEscape(sheet) + `">`)
+	w.WriteString(`<table:table-column table:number-columns-repeated="` +
+		strconv.Itoa(len(e.Columns)) + `"/>`)
+	if !d.OmitHeader {
+		w.WriteString("<table:table-row>")
+		for _, field := range e.Columns {
+			w.WriteString(odsCell(`office:value-type="string"`, field.Name))
+		}
+		w.WriteString("</table:table-row>")
+	}
+	for r := 0; r < e.N; r++ {
+		w.WriteString("<table:table-row>")
+		for _, field := range e.Columns {
+			val := field.value(r)
+			w.WriteString(odsValueCell(val, field.printValue(format, val), format.TimeLoc))
+		}
+		w.WriteString("</table:table-row>")
+		e.progress(r, r+1)
+	}
+	w.WriteString("</table:table></office:spreadsheet></office:body></office:document-content>")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+// odsValueCell returns the typed cell for the canonical value v displayed
+// as text. Times are converted to loc if loc is not nil.
+func odsValueCell(v interface{}, text string, loc *time.Location) string {
+	switch x := v.(type) {
+	case bool:
+		return odsCell(`office:value-type="boolean" office:boolean-value="`+
+			strconv.FormatBool(x)+`"`, text)
+	case int64:
+		return odsCell(`office:value-type="float" office:value="`+
+			strconv.FormatInt(x, 10)+`"`, text)
+	case uint64:
+		return odsCell(`office:value-type="float" office:value="`+
+			strconv.FormatUint(x, 10)+`"`, text)
+	case float64:
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			break
+		}
+		return odsCell(`office:value-type="float" office:value="`+
+			strconv.FormatFloat(x, 'g', -1, 64)+`"`, text)
+	case time.Time:
+		if loc != nil {
+			x = x.In(loc)
+		}
+		return odsCell(`table:style-name="date" office:value-type="date" office:date-value="`+
+			x.Format("2006-01-02T15:04:05.999999999")+`"`, text)
+	case time.Duration:
+		return odsCell(`table:style-name="duration" office:value-type="time" office:time-value="`+
+			odsDuration(x)+`"`, text)
+	case string, complex128, []byte:
+		return odsCell(`office:value-type="string"`, text)
+	}
+	return "<table:table-cell/>"
+}
+
+// odsCell returns a cell with the given attributes and text.
+func odsCell(attrs, text string) string {
+	return "<table:table-cell " + attrs + "><text:p>" + xmlEscape(text) +
+		"</text:p></table:table-cell>"
+}
+
+// odsDuration formats d as an ISO 8601 duration like PT36H05M02.5S.
+func odsDuration(d time.Duration) string {
+	sign := ""
+	u := uint64(d)
+	if d < 0 {
+		sign, u = "-", -u
+	}
+	h, m := u/uint64(time.Hour), u/uint64(time.Minute)%60
+	s := strconv.FormatFloat(float64(u%uint64(time.Minute))/1e9, 'f', -1, 64)
+	if len(s) == 1 || s[1] == '.' {
+		s = "0" + s
+	}
+	return fmt.Sprintf("%sPT%02dH%02dM%sS", sign, h, m, s)
+}
+
+const odsManifest = `<manifest:manifest xmlns:manifest="urn:oasis:names:tc:opendocument:xmlns:manifest:1.0" manifest:version="1.2">` +
+	`<manifest:file-entry manifest:full-path="/" manifest:version="1.2" manifest:media-type="` + odsMimetype + `"/>` +
+	`<manifest:file-entry manifest:full-path="content.xml" manifest:media-type="text/xml"/>` +
+	`</manifest:manifest>`
+
+// odsContentHead starts the content and defines the cell styles date and
+// duration.
+const odsContentHead = `<office:document-content ` +
+	`xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" ` +
+	`xmlns:style="urn:oasis:names:tc:opendocument:xmlns:style:1.0" ` +
+	`xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0" ` +
+	`xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" ` +
+	`xmlns:number="urn:oasis:names:tc:opendocument:xmlns:datastyle:1.0" ` +
+	`office:version="1.2">` +
+	`<office:automatic-styles>` +
+	`<number:date-style style:name="N1"><number:year number:style="long"/><number:text>-</number:text>` +
+	`<number:month number:style="long"/><number:text>-</number:text><number:day number:style="long"/>` +
+	`<number:text> </number:text><number:hours number:style="long"/><number:text>:</number:text>` +
+	`<number:minutes number:style="long"/><number:text>:</number:text><number:seconds number:style="long"/>` +
+	`</number:date-style>` +
+	`<number:time-style style:name="N2" number:truncate-on-overflow="false"><number:hours/><number:text>:</number:text>` +
+	`<number:minutes number:style="long"/><number:text>:</number:text><number:seconds number:style="long"/>` +
+	`</number:time-style>` +
+	`<style:style style:name="date" style:family="table-cell" style:data-style-name="N1"/>` +
+	`<style:style style:name="duration" style:family="table-cell" style:data-style-name="N2"/>` +
+	`</office:automatic-styles>` +
+	`<office:body><office:spreadsheet>`
+
+func init() {
+	RegisterDumper("ods", func(w io.Writer) Dumper {
+		return ODSDumper{Writer: w}
+	})
+}
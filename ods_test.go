@@ -0,0 +1,96 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestODSDumper(t *testing.T) {
+	data := []struct {
+		B bool
+		I *int
+		F float64
+		S string
+		T time.Time
+		D time.Duration
+	}{
+		{true, new(int), 1.5, "a<b", time.Date(2000, 1, 2, 18, 0, 0, 0, time.UTC), 36*time.Hour + 2500*time.Millisecond},
+		{false, nil, math.NaN(), "", time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC), 0},
+	}
+	extractor, err := NewExtractor(data, "B", "I", "F", "S", "T", "D")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	buf := &bytes.Buffer{}
+	if err := (ODSDumper{Writer: buf, Sheet: "Q&A"}).Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	z, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if f := z.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Errorf("First file is %s with method %d", f.Name, f.Method)
+	}
+	files := readZip(t, buf.Bytes())
+	if files["mimetype"] != odsMimetype {
+		t.Errorf("Got mimetype %q", files["mimetype"])
+	}
+	content := files["content.xml"]
+	for _, want := range []string{
+		`<table:table table:name="Q&amp;A"><table:table-column table:number-columns-repeated="6"/>`,
+		`<table:table-row><table:table-cell office:value-type="string"><text:p>B</text:p></table:table-cell>`,
+		`<table:table-cell office:value-type="boolean" office:boolean-value="true"><text:p>true</text:p></table:table-cell>`,
+		`<table:table-cell office:value-type="float" office:value="0"><text:p>0</text:p></table:table-cell>`,
+		`<table:table-cell office:value-type="float" office:value="1.5"><text:p>1.5</text:p></table:table-cell>`,
+		`<table:table-cell office:value-type="string"><text:p>a&lt;b</text:p></table:table-cell>`,
+		`<table:table-cell table:style-name="date" office:value-type="date" office:date-value="2000-01-02T18:00:00"><text:p>2000-01-02T18:00:00</text:p></table:table-cell>`,
+		`<table:table-cell table:style-name="duration" office:value-type="time" office:time-value="PT36H00M02.5S"><text:p>36h0m2.5s</text:p></table:table-cell>`,
+		`<text:p>false</text:p></table:table-cell><table:table-cell/><table:table-cell/><table:table-cell office:value-type="string"><text:p></text:p>`,
+		`office:time-value="PT00H00M00S"`,
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("Missing %s in\n%s", want, content)
+		}
+	}
+}
+
+func TestODSDumperCalls(t *testing.T) {
+	v := 1.5
+	data := []measurement{{Value: &v}, {}, {Value: &v}}
+	extractor, err := NewExtractor(data, "Check()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	materializeCalls = 0
+	if err := (ODSDumper{Writer: io.Discard}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if materializeCalls != len(data) {
+		t.Errorf("Check called %d times for %d rows", materializeCalls, len(data))
+	}
+}
+
+func TestODSDuration(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		0:                                 "PT00H00M00S",
+		90 * time.Second:                  "PT00H01M30S",
+		-(25*time.Hour + time.Nanosecond): "-PT25H00M00.000000001S",
+	} {
+		if got := odsDuration(d); got != want {
+			t.Errorf("Duration %s: got %s, want %s", d, got, want)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// PandasDumper dumps the values as a Python script which constructs a
+// pandas DataFrame from the columns.
+//
+// NA values become None and are handled per dtype: Bools use the nullable
+// "boolean" dtype, Ints the nullable "Int64" dtype, Uints the nullable
+// "UInt64" dtype, Floats use NaN, Times are converted with pd.to_datetime
+// to UTC datetimes and Durations with pd.to_timedelta. Strings, Bytes and
+// Complex values are stored as objects.
+type PandasDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// DataFrame is the name of the Python variable holding the data
+	// frame. It defaults to "df".
+	DataFrame string
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is not used, all values are written with full precision.
+func (d PandasDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	name := d.DataFrame
+	if name == "" {
+		name = "df"
+	}
+	w := bufio.NewWriter(d.Writer)
+	w.WriteString("import pandas as pd\n\n" + name + " = pd.DataFrame({\n")
+	for _, field := range e.Columns {
+		open, close := "[", "]"
+		switch field.Type() {
+		case Bool:
+			open, close = "pd.array([", `], dtype="boolean")`
+		case Int:
+			open, close = "pd.array([", `], dtype="Int64")`
+		case Uint:
+			open, close = "pd.array([", `], dtype="UInt64")`
+		case Float:
+			open, close = "pd.array([", `], dtype="float64")`
+		case Time:
+			open, close = "pd.to_datetime([", "], utc=True)"
+		case Duration:
+			open, close = "pd.to_timedelta([", `], unit="ns")`
+		}
+		w.WriteString("    " + jsonQuote(field.Name) + ": " + open)
+		for r := 0; r < e.N; r++ {
+			if r > 0 {
+				if r%10 == 0 {
+					w.WriteString(",\n        ")
+				} else {
+					w.WriteString(", ")
+				}
+			}
+			w.WriteString(pythonLiteral(field.value(r)))
+		}
+		w.WriteString(close + ",\n")
+	}
+	e.progress(0, e.N)
+	w.WriteString("})\n")
+	return w.Flush()
+}
+
+// pythonLiteral returns the canonical value v as a Python literal.
+// Times are represented as ISO 8601 strings in UTC.
+func pythonLiteral(v interface{}) string {
+	switch x := v.(type) {
+	case bool:
+		if x {
+			return "True"
+		}
+		return "False"
+	case int64:
+		return strconv.FormatInt(x, 10)
+	case uint64:
+		return strconv.FormatUint(x, 10)
+	case float64:
+		return pythonFloat(x)
+	case complex128:
+		return "complex(" + pythonFloat(real(x)) + ", " + pythonFloat(imag(x)) + ")"
+	case string:
+		return jsonQuote(x)
+	case []byte:
+		return pythonBytes(x)
+	case time.Time:
+		return `"` + x.UTC().Format(time.RFC3339Nano) + `"`
+	case time.Duration:
+		return strconv.FormatInt(int64(x), 10)
+	}
+	return "None"
+}
+
+// pythonBytes returns b as a Python bytes literal.
+func pythonBytes(b []byte) string {
+	s := []byte(`b"`)
+	for _, c := range b {
+		switch {
+		case c == '"' || c == '\\':
+			s = append(s, '\\', c)
+		case c >= 0x20 && c < 0x7f:
+			s = append(s, c)
+		default:
+			s = append(s, '\\', 'x', hexDigits[c>>4], hexDigits[c&15])
+		}
+	}
+	return string(append(s, '"'))
+}
+
+const hexDigits = "0123456789abcdef"
+
+// pythonFloat returns x as a Python float expression.
+func pythonFloat(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return `float("nan")`
+	case math.IsInf(x, 1):
+		return `float("inf")`
+	case math.IsInf(x, -1):
+		return `float("-inf")`
+	}
+	s := strconv.FormatFloat(x, 'g', -1, 64)
+	for _, c := range s {
+		if c == '.' || c == 'e' {
+			return s
+		}
+	}
+	return s + ".0"
+}
+
+func init() {
+	RegisterDumper("pandas", func(w io.Writer) Dumper {
+		return PandasDumper{Writer: w}
+	})
+}
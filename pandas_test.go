@@ -0,0 +1,38 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPandasDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (PandasDumper{Writer: buf, DataFrame: "table"}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `import pandas as pd
+
+table = pd.DataFrame({
+    "B": pd.array([True, True, False, False], dtype="boolean"),
+    "I": pd.array([12, 14, 14, 16], dtype="Int64"),
+    "F": pd.array([3.14149, 2.71828, float("nan"), 6.02214e+23], dtype="float64"),
+    "S": ["Hello", "World", "Go", "A Lot"],
+    "T": pd.to_datetime(["2000-01-02T15:20:30Z", "2000-01-02T03:20:30Z", "2000-01-02T15:20:30Z", "2009-12-28T09:45:00Z"], utc=True),
+    "D": pd.to_timedelta([3000000000, 9000000, 0, 30000000000000], unit="ns"),
+    "C": [complex(3.0999999046325684, 4.199999809265137), complex(0.0, 9.0), complex(0.0, 0.0), complex(float("-inf"), 7.0)],
+    "SME": [None, None, None, None],
+})
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
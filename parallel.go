@@ -0,0 +1,77 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+// parallelChunk is the number of rows formatted at once by a worker.
+const parallelChunk = 256
+
+// formatRows appends the output of all rows of e produced by format to a
+// buffer and passes it to write in the order of the rows while reporting
+// the progress. If e.Workers is greater than one, chunks of rows are
+// formatted concurrently by e.Workers goroutines. The first error of write
+// is returned.
+func (e *Extractor) formatRows(format func(buf []byte, r int) []byte, write func(p []byte) error) error {
+	workers := e.Workers
+	if workers <= 1 || e.N <= parallelChunk {
+		var buf []byte
+		for r := 0; r < e.N; r++ {
+			buf = format(buf[:0], r)
+			if err := write(buf); err != nil {
+				return err
+			}
+			e.progress(r, r+1)
+		}
+		return nil
+	}
+
+	// The chunks are sent to the workers and, in the same order, to the
+	// writing loop below which waits for their output.
+	type chunk struct {
+		start, end int
+		out        chan []byte
+	}
+	jobs := make(chan chunk)
+	order := make(chan chunk, 2*workers)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(order)
+		defer close(jobs)
+		for start := 0; start < e.N; start += parallelChunk {
+			c := chunk{start: start, end: start + parallelChunk, out: make(chan []byte, 1)}
+			if c.end > e.N {
+				c.end = e.N
+			}
+			select {
+			case order <- c:
+			case <-done:
+				return
+			}
+			select {
+			case jobs <- c:
+			case <-done:
+				return
+			}
+		}
+	}()
+	for w := 0; w < workers; w++ {
+		go func() {
+			for c := range jobs {
+				var buf []byte
+				for r := c.start; r < c.end; r++ {
+					buf = format(buf, r)
+				}
+				c.out <- buf
+			}
+		}()
+	}
+	for c := range order {
+		if err := write(<-c.out); err != nil {
+			return err
+		}
+		e.progress(c.start, c.end)
+	}
+	return nil
+}
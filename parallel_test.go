@@ -0,0 +1,72 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type failingWriter struct{ n int }
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.n--; w.n < 0 {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func TestParallelDump(t *testing.T) {
+	type row struct {
+		I int
+		S string
+		F *float64
+	}
+	data := make([]row, 2000)
+	for i := range data {
+		data[i] = row{I: i, S: fmt.Sprintf("s,%d", i)}
+		if i%3 == 0 {
+			f := float64(i) / 4
+			data[i].F = &f
+		}
+	}
+	extractor, err := NewExtractor(data, "I", "S", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, dumper := range []func(w io.Writer) Dumper{
+		func(w io.Writer) Dumper { return DelimitedDumper{Writer: w} },
+		func(w io.Writer) Dumper { return JSONDumper{Writer: w} },
+		func(w io.Writer) Dumper { return JSONLinesDumper{Writer: w} },
+	} {
+		extractor.Workers = 0
+		want := &bytes.Buffer{}
+		if err := dumper(want).Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+
+		extractor.Workers = 4
+		var done []int
+		extractor.Progress = func(rowsDone, totalRows int) { done = append(done, rowsDone) }
+		got := &bytes.Buffer{}
+		if err := dumper(got).Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		extractor.Progress = nil
+		if got.String() != want.String() {
+			t.Errorf("%T: parallel output differs", dumper(nil))
+		}
+		if len(done) != 2 || done[1] != len(data) {
+			t.Errorf("%T: got progress %v", dumper(nil), done)
+		}
+
+		if err := dumper(&failingWriter{n: 2}).Dump(extractor, DefaultFormat); err == nil {
+			t.Errorf("%T: missing error", dumper(nil))
+		}
+	}
+}
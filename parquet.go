@@ -0,0 +1,365 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// ParquetDumper dumps the values as an Apache Parquet file.
+//
+// All columns are optional with NA values stored as nulls. Bools are
+// stored as BOOLEAN, Ints as INT64, Uints as INT64 annotated as UINT_64,
+// Floats as DOUBLE, Strings as UTF8 annotated BYTE_ARRAY, Bytes as plain
+// BYTE_ARRAY, Times as INT64 annotated as TIMESTAMP_MILLIS and Durations
+// as INT64 nanoseconds. Complex values are formated as strings.
+// The data pages are PLAIN encoded and uncompressed.
+type ParquetDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// RowGroupSize is the maximal number of rows in one row group.
+	// The default is 1000000 rows.
+	RowGroupSize int
+
+	// PageSize is the maximal number of values in one data page.
+	// The default is 65536 values.
+	PageSize int
+}
+
+// Parquet physical types, converted types, encodings and page types.
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetUTF8            = 0
+	parquetTimestampMillis = 9
+	parquetUint64          = 14
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetDataPage = 0
+)
+
+// parquetType returns the physical and converted type (or -1 for none)
+// used for a column of type t.
+func parquetType(t Type) (physical, converted int) {
+	switch t {
+	case Bool:
+		return parquetBoolean, -1
+	case Int, Duration:
+		return parquetInt64, -1
+	case Uint:
+		return parquetInt64, parquetUint64
+	case Float:
+		return parquetDouble, -1
+	case Time:
+		return parquetInt64, parquetTimestampMillis
+	case Bytes:
+		return parquetByteArray, -1
+	}
+	return parquetByteArray, parquetUTF8
+}
+
+// parquetChunk describes a written column chunk.
+type parquetChunk struct {
+	offset int64 // offset of the first data page
+	size   int64 // size of all pages including headers
+}
+
+// Dump implements the Dump method of a Dumper.
+// The format is used only for complex values.
+func (d ParquetDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	groupSize, pageSize := d.RowGroupSize, d.PageSize
+	if groupSize <= 0 {
+		groupSize = 1000000
+	}
+	if pageSize <= 0 {
+		pageSize = 65536
+	}
+
+	w := &countingWriter{w: d.Writer}
+	if _, err := io.WriteString(w, "PAR1"); err != nil {
+		return err
+	}
+
+	var groups [][]parquetChunk
+	for start := 0; start < e.N; start += groupSize {
+		end := start + groupSize
+		if end > e.N {
+			end = e.N
+		}
+		chunks := make([]parquetChunk, len(e.Columns))
+		for c, field := range e.Columns {
+			chunks[c].offset = w.n
+			for from := start; from < end; from += pageSize {
+				to := from + pageSize
+				if to > end {
+					to = end
+				}
+				if err := writeParquetPage(w, field, format, from, to); err != nil {
+					return err
+				}
+			}
+			chunks[c].size = w.n - chunks[c].offset
+		}
+		groups = append(groups, chunks)
+		e.progress(start, end)
+	}
+
+	meta := parquetMetadata(e, groupSize, groups)
+	footer := make([]byte, 4)
+	binary.LittleEndian.PutUint32(footer, uint32(len(meta)))
+	footer = append(append(meta, footer...), "PAR1"...)
+	_, err = w.Write(footer)
+	return err
+}
+
+// writeParquetPage writes rows from to to of field as one data page.
+func writeParquetPage(w io.Writer, field Column, format Format, from, to int) error {
+	n := to - from
+	levels := make([]byte, (n+7)/8)
+	var values []byte
+	var bits []byte // for bool values
+	nonNull := 0
+	var buf [8]byte
+	for r := from; r < to; r++ {
+		v := field.value(r)
+		if v == nil {
+			continue
+		}
+		levels[(r-from)/8] |= 1 << uint((r-from)%8)
+		switch x := v.(type) {
+		case bool:
+			if nonNull%8 == 0 {
+				bits = append(bits, 0)
+			}
+			if x {
+				bits[nonNull/8] |= 1 << uint(nonNull%8)
+			}
+		case int64:
+			binary.LittleEndian.PutUint64(buf[:], uint64(x))
+			values = append(values, buf[:]...)
+		case uint64:
+			binary.LittleEndian.PutUint64(buf[:], x)
+			values = append(values, buf[:]...)
+		case time.Duration:
+			binary.LittleEndian.PutUint64(buf[:], uint64(x))
+			values = append(values, buf[:]...)
+		case float64:
+			binary.LittleEndian.PutUint64(buf[:], math.Float64bits(x))
+			values = append(values, buf[:]...)
+		case time.Time:
+			binary.LittleEndian.PutUint64(buf[:], uint64(x.UnixMilli()))
+			values = append(values, buf[:]...)
+		case []byte:
+			binary.LittleEndian.PutUint32(buf[:4], uint32(len(x)))
+			values = append(append(values, buf[:4]...), x...)
+		default:
+			s := ""
+			if c, ok := x.(complex128); ok {
+				s = format.Complex(c)
+			} else {
+				s, _ = x.(string)
+			}
+			binary.LittleEndian.PutUint32(buf[:4], uint32(len(s)))
+			values = append(append(values, buf[:4]...), s...)
+		}
+		nonNull++
+	}
+	values = append(values, bits...)
+
+	// Definition levels as a single bit-packed run of the RLE/bit-packing
+	// hybrid encoding, prefixed by its length.
+	run := binary.AppendUvarint(nil, uint64(len(levels))<<1|1)
+	run = append(run, levels...)
+	page := binary.LittleEndian.AppendUint32(nil, uint32(len(run)))
+	page = append(append(page, run...), values...)
+
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32Field(1, parquetDataPage)
+	t.i32Field(2, int32(len(page)))
+	t.i32Field(3, int32(len(page)))
+	t.fieldBegin(5, thriftStruct)
+	t.structBegin()
+	t.i32Field(1, int32(n))
+	t.i32Field(2, parquetPlain)
+	t.i32Field(3, parquetRLE)
+	t.i32Field(4, parquetRLE)
+	t.structEnd()
+	t.structEnd()
+
+	if _, err := w.Write(t.buf); err != nil {
+		return err
+	}
+	_, err := w.Write(page)
+	return err
+}
+
+// parquetMetadata returns the thrift encoded FileMetaData.
+func parquetMetadata(e *Extractor, groupSize int, groups [][]parquetChunk) []byte {
+	t := &thriftWriter{}
+	t.structBegin()
+	t.i32Field(1, 1) // version
+
+	// Schema: a root element followed by one element per column.
+	t.fieldBegin(2, thriftList)
+	t.listBegin(thriftStruct, len(e.Columns)+1)
+	t.structBegin()
+	t.binaryField(4, "schema")
+	t.i32Field(5, int32(len(e.Columns)))
+	t.structEnd()
+	for _, field := range e.Columns {
+		physical, converted := parquetType(field.Type())
+		t.structBegin()
+		t.i32Field(1, int32(physical))
+		t.i32Field(3, 1) // OPTIONAL
+		t.binaryField(4, field.Name)
+		if converted >= 0 {
+			t.i32Field(6, int32(converted))
+		}
+		t.structEnd()
+	}
+
+	t.i64Field(3, int64(e.N))
+
+	t.fieldBegin(4, thriftList)
+	t.listBegin(thriftStruct, len(groups))
+	for g, chunks := range groups {
+		rows := groupSize
+		if g == len(groups)-1 {
+			rows = e.N - g*groupSize
+		}
+		total := int64(0)
+		t.structBegin()
+		t.fieldBegin(1, thriftList)
+		t.listBegin(thriftStruct, len(chunks))
+		for c, chunk := range chunks {
+			physical, _ := parquetType(e.Columns[c].Type())
+			t.structBegin()
+			t.i64Field(2, chunk.offset)
+			t.fieldBegin(3, thriftStruct)
+			t.structBegin()
+			t.i32Field(1, int32(physical))
+			t.fieldBegin(2, thriftList)
+			t.listBegin(thriftI32, 2)
+			t.varint(parquetPlain)
+			t.varint(parquetRLE)
+			t.fieldBegin(3, thriftList)
+			t.listBegin(thriftBinary, 1)
+			t.binary(e.Columns[c].Name)
+			t.i32Field(4, 0) // UNCOMPRESSED
+			t.i64Field(5, int64(rows))
+			t.i64Field(6, chunk.size)
+			t.i64Field(7, chunk.size)
+			t.i64Field(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+			total += chunk.size
+		}
+		t.i64Field(2, total)
+		t.i64Field(3, int64(rows))
+		t.structEnd()
+	}
+
+	t.binaryField(6, "github.com/vdobler/export")
+	t.structEnd()
+	return t.buf
+}
+
+// Thrift compact protocol types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes thrift structs in the compact protocol.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // stack of the last field ids of the open structs
+}
+
+func (t *thriftWriter) structBegin() { t.last = append(t.last, 0) }
+
+func (t *thriftWriter) structEnd() {
+	t.buf = append(t.buf, 0)
+	t.last = t.last[:len(t.last)-1]
+}
+
+func (t *thriftWriter) fieldBegin(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+func (t *thriftWriter) listBegin(elem byte, size int) {
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elem)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elem)
+	t.buf = binary.AppendUvarint(t.buf, uint64(size))
+}
+
+// varint writes a zigzag encoded varint as used for i16, i32 and i64.
+func (t *thriftWriter) varint(i int64) {
+	t.buf = binary.AppendVarint(t.buf, i)
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.buf = binary.AppendUvarint(t.buf, uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+func (t *thriftWriter) i32Field(id int16, i int32) {
+	t.fieldBegin(id, thriftI32)
+	t.varint(int64(i))
+}
+
+func (t *thriftWriter) i64Field(id int16, i int64) {
+	t.fieldBegin(id, thriftI64)
+	t.varint(i)
+}
+
+func (t *thriftWriter) binaryField(id int16, s string) {
+	t.fieldBegin(id, thriftBinary)
+	t.binary(s)
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func init() {
+	RegisterDumper("parquet", func(w io.Writer) Dumper {
+		return ParquetDumper{Writer: w}
+	})
+}
@@ -0,0 +1,198 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// thriftReader decodes the thrift compact protocol into maps from field
+// ids to int64, string, bool, []interface{} and nested maps.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (t *thriftReader) byte() byte {
+	b := t.data[t.pos]
+	t.pos++
+	return b
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	u, n := binary.Uvarint(t.data[t.pos:])
+	t.pos += n
+	return u
+}
+
+func (t *thriftReader) varint() int64 {
+	i, n := binary.Varint(t.data[t.pos:])
+	t.pos += n
+	return i
+}
+
+func (t *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return t.varint()
+	case thriftBinary:
+		n := int(t.uvarint())
+		s := string(t.data[t.pos : t.pos+n])
+		t.pos += n
+		return s
+	case thriftList:
+		h := t.byte()
+		size := int(h >> 4)
+		if size == 15 {
+			size = int(t.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = t.value(h & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return t.readStruct()
+	}
+	panic(fmt.Sprintf("unsupported thrift type %d", typ))
+}
+
+func (t *thriftReader) readStruct() map[int16]interface{} {
+	s := map[int16]interface{}{}
+	id := int16(0)
+	for {
+		h := t.byte()
+		if h == 0 {
+			return s
+		}
+		if delta := int16(h >> 4); delta != 0 {
+			id += delta
+		} else {
+			id = int16(t.varint())
+		}
+		s[id] = t.value(h & 0x0f)
+	}
+}
+
+// readParquet decodes the values of all columns of a file written by
+// ParquetDumper. NA values are nil.
+func readParquet(t *testing.T, data []byte) ([]string, [][]interface{}) {
+	if string(data[:4]) != "PAR1" || string(data[len(data)-4:]) != "PAR1" {
+		t.Fatalf("Missing magic")
+	}
+	size := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	tr := &thriftReader{data: data[len(data)-8-size : len(data)-8]}
+	meta := tr.readStruct()
+	if tr.pos != size {
+		t.Fatalf("Metadata has %d bytes, read %d", size, tr.pos)
+	}
+
+	schema := meta[2].([]interface{})
+	var names []string
+	for _, elem := range schema[1:] {
+		names = append(names, elem.(map[int16]interface{})[4].(string))
+	}
+	columns := make([][]interface{}, len(names))
+	for _, rg := range meta[4].([]interface{}) {
+		for c, chunk := range rg.(map[int16]interface{})[1].([]interface{}) {
+			cmd := chunk.(map[int16]interface{})[3].(map[int16]interface{})
+			physical := cmd[1].(int64)
+			pos := int(cmd[9].(int64))
+			end := pos + int(cmd[7].(int64))
+			for pos < end {
+				tr := &thriftReader{data: data, pos: pos}
+				header := tr.readStruct()
+				n := int(header[5].(map[int16]interface{})[1].(int64))
+				page := data[tr.pos : tr.pos+int(header[3].(int64))]
+				pos = tr.pos + len(page)
+
+				levelLen := int(binary.LittleEndian.Uint32(page))
+				levels := page[4 : 4+levelLen]
+				run, k := binary.Uvarint(levels)
+				if run&1 != 1 {
+					t.Fatalf("Expected bit-packed run")
+				}
+				levels = levels[k:]
+				values := page[4+levelLen:]
+				nonNull := 0
+				for i := 0; i < n; i++ {
+					if levels[i/8]&(1<<uint(i%8)) == 0 {
+						columns[c] = append(columns[c], nil)
+						continue
+					}
+					var v interface{}
+					switch physical {
+					case parquetBoolean:
+						v = values[nonNull/8]&(1<<uint(nonNull%8)) != 0
+					case parquetInt64:
+						v = int64(binary.LittleEndian.Uint64(values))
+						values = values[8:]
+					case parquetDouble:
+						v = math.Float64frombits(binary.LittleEndian.Uint64(values))
+						values = values[8:]
+					case parquetByteArray:
+						l := int(binary.LittleEndian.Uint32(values))
+						v = string(values[4 : 4+l])
+						values = values[4+l:]
+					}
+					columns[c] = append(columns[c], v)
+					nonNull++
+				}
+			}
+		}
+	}
+	if meta[3].(int64) != int64(len(columns[0])) {
+		t.Errorf("num_rows=%d, got %d rows", meta[3], len(columns[0]))
+	}
+	return names, columns
+}
+
+func TestParquetDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, d := range []ParquetDumper{
+		{},
+		{RowGroupSize: 3, PageSize: 2},
+	} {
+		buf := &bytes.Buffer{}
+		d.Writer = buf
+		if err := d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		names, columns := readParquet(t, buf.Bytes())
+		if want := []string{"B", "I", "F", "S", "T", "D", "C", "SME"}; !reflect.DeepEqual(names, want) {
+			t.Errorf("Got names %v, want %v", names, want)
+		}
+
+		want := [][]interface{}{
+			{true, true, false, false},
+			{int64(12), int64(14), int64(14), int64(16)},
+			{3.14149, 2.71828, math.NaN(), 6.02214e23},
+			{"Hello", "World", "Go", "A Lot"},
+			{time1.UnixMilli(), time2.UnixMilli(), time1.UnixMilli(), time3.UnixMilli()},
+			{int64(3 * time.Second), int64(9 * time.Millisecond), int64(0), int64(500 * time.Minute)},
+			{"(3.1+4.2i)", "(0+9i)", "(0+0i)", "+∞"},
+			{nil, nil, nil, nil},
+		}
+		for c := range want {
+			if got := fmt.Sprint(columns[c]); got != fmt.Sprint(want[c]) {
+				t.Errorf("Column %s: got %s, want %v", names[c], got, want[c])
+			}
+		}
+	}
+}
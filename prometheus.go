@@ -0,0 +1,172 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrometheusDumper dumps the numeric columns as metrics in the Prometheus
+// text exposition format. Each row yields one sample per metric column,
+// labeled with the values of the label columns.
+//
+// Ints, Uints and Floats are used directly, Durations in seconds and Bools
+// as 0 and 1. Other columns which are not label columns are ignored, as are
+// NA values. Metric and label names are derived from the column names by
+// replacing invalid characters with underscores.
+type PrometheusDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// Prefix is prepended to each metric name, e.g. "myapp_".
+	Prefix string
+
+	// Type is the metric type, e.g. "gauge" or "counter". It defaults
+	// to "untyped".
+	Type string
+
+	// Labels are the names of the columns used as labels.
+	Labels []string
+
+	// Timestamp is the name of an optional Time column providing the
+	// timestamps of the samples.
+	Timestamp string
+}
+
+// Dump implements the Dump method of a Dumper.
+// Label values are formatted according to format.
+func (d PrometheusDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	typ := d.Type
+	if typ == "" {
+		typ = "untyped"
+	}
+	index := map[string]int{}
+	for i, field := range e.Columns {
+		index[field.Name] = i
+	}
+	var labels []int
+	for _, name := range d.Labels {
+		i, ok := index[name]
+		if !ok {
+			return fmt.Errorf("export: no label column %q", name)
+		}
+		labels = append(labels, i)
+	}
+	ts := -1
+	if d.Timestamp != "" {
+		i, ok := index[d.Timestamp]
+		if !ok || e.Columns[i].Type() != Time {
+			return fmt.Errorf("export: no timestamp column %q", d.Timestamp)
+		}
+		ts = i
+	}
+
+	// The label sets and timestamps are shared by all metrics.
+	sets := make([]string, e.N)
+	stamps := make([]string, e.N)
+	for r := range sets {
+		var pairs []string
+		for _, i := range labels {
+			pairs = append(pairs, prometheusName(e.Columns[i].Name, false)+"="+
+				prometheusLabel(e.Columns[i].Print(format, r)))
+		}
+		if len(pairs) > 0 {
+			sets[r] = "{" + strings.Join(pairs, ",") + "}"
+		}
+		if ts >= 0 {
+			if t, ok := e.Columns[ts].value(r).(time.Time); ok {
+				stamps[r] = " " + strconv.FormatInt(t.UnixMilli(), 10)
+			}
+		}
+	}
+
+	w := bufio.NewWriter(d.Writer)
+outer:
+	for i, field := range e.Columns {
+		if i == ts {
+			continue
+		}
+		for _, l := range labels {
+			if i == l {
+				continue outer
+			}
+		}
+		switch field.Type() {
+		case Bool, Int, Uint, Float, Duration:
+		default:
+			continue
+		}
+		name := prometheusName(d.Prefix+field.Name, true)
+		w.WriteString("# TYPE " + name + " " + typ + "\n")
+		for r := 0; r < e.N; r++ {
+			var value string
+			switch v := field.value(r).(type) {
+			case nil:
+				continue
+			case bool:
+				value = "0"
+				if v {
+					value = "1"
+				}
+			case int64:
+				value = strconv.FormatInt(v, 10)
+			case uint64:
+				value = strconv.FormatUint(v, 10)
+			case float64:
+				value = prometheusFloat(v)
+			case time.Duration:
+				value = prometheusFloat(v.Seconds())
+			}
+			w.WriteString(name + sets[r] + " " + value + stamps[r] + "\n")
+		}
+	}
+	e.progress(0, e.N)
+	return w.Flush()
+}
+
+// prometheusName replaces all characters not allowed in metric names (or
+// label names if metric is false) by underscores.
+func prometheusName(name string, metric bool) string {
+	id := []byte(name)
+	for i, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '_', c == ':' && metric:
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			id[i] = '_'
+		}
+	}
+	if len(id) == 0 {
+		return "_"
+	}
+	return string(id)
+}
+
+// prometheusLabel returns s as a quoted label value.
+func prometheusLabel(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// prometheusFloat formats x as a sample value.
+func prometheusFloat(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return "NaN"
+	case math.IsInf(x, 1):
+		return "+Inf"
+	case math.IsInf(x, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(x, 'g', -1, 64)
+}
@@ -0,0 +1,77 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPrometheusDumper(t *testing.T) {
+	data := []struct {
+		Host    string
+		Path    string
+		Up      bool
+		Latency time.Duration
+		Load    *float64
+		At      time.Time
+		Note    string
+	}{
+		{"a", `/x"y`, true, 1500 * time.Millisecond, nil, time.Unix(1700000000, 0), "ignored"},
+		{"b", "/", false, 0, new(float64), time.Unix(1700000001, 0), "ignored"},
+	}
+	*data[1].Load = math.Inf(1)
+	extractor, err := NewExtractor(data, "Host", "Path", "Up", "Latency", "Load", "At", "Note")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	buf := &bytes.Buffer{}
+	d := PrometheusDumper{
+		Writer:    buf,
+		Prefix:    "app.",
+		Type:      "gauge",
+		Labels:    []string{"Host", "Path"},
+		Timestamp: "At",
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `# TYPE app_Up gauge
+app_Up{Host="a",Path="/x\"y"} 1 1700000000000
+app_Up{Host="b",Path="/"} 0 1700000001000
+# TYPE app_Latency gauge
+app_Latency{Host="a",Path="/x\"y"} 1.5 1700000000000
+app_Latency{Host="b",Path="/"} 0 1700000001000
+# TYPE app_Load gauge
+app_Load{Host="b",Path="/"} +Inf 1700000001000
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	PrometheusDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	want = `# TYPE Up untyped
+Up 1
+Up 0
+# TYPE Latency untyped
+Latency 1.5
+Latency 0
+# TYPE Load untyped
+Load +Inf
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	for _, d := range []PrometheusDumper{{Labels: []string{"Nope"}}, {Timestamp: "Host"}} {
+		if err := d.Dump(extractor, DefaultFormat); err == nil {
+			t.Errorf("Missing error for %+v", d)
+		}
+	}
+}
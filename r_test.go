@@ -12,6 +12,7 @@ import (
 	"flag"
 	"fmt"
 	"os/exec"
+	"path/filepath"
 	"testing"
 )
 
@@ -579,3 +580,20 @@ Sys.sleep(3)
 			err, string(out))
 	}
 }
+
+func TestRRunnerPlot(t *testing.T) {
+	if !*doR {
+		t.Skip("Skipped test using R. Enable with the -R flag.")
+	}
+
+	extractor, err := NewExtractor(diamonds, "Carat", "Cut", "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	path := filepath.Join(t.TempDir(), "diamonds.png")
+	r := RRunner{Binary: *rBinary, DataFrame: "my.diamonds", Width: 4, Height: 3}
+	err = r.Plot(extractor, `ggplot(my.diamonds, aes(Carat, Price, color=Cut)) + geom_point()`, path)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v\nTry setting -Rbin.", err)
+	}
+}
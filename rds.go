@@ -0,0 +1,305 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"math"
+	"time"
+	"unicode/utf8"
+)
+
+// RDSDumper dumps the values as a R data.frame in the RDS format written by
+// saveRDS, so that R can read large datasets with readRDS much faster than
+// by parsing the script generated by RVecDumper.
+//
+// Bools are stored as logical vectors, Ints and Uints as integer vectors
+// if all values fit into R's 32 bit integers and as double vectors
+// otherwise, Floats as double and Complex as complex vectors and Strings
+// as character vectors. Times are stored as POSIXct in the time zone of
+// the format's TimeLoc (UTC if nil), Durations as difftime in seconds and
+// Bytes as a list of raw vectors. NA values are R's NA (NULL in the list
+// of raw vectors). Column labels are stored as the "label" attribute of the
+// vectors. The format is not used otherwise.
+type RDSDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	// Compress gzips the output like saveRDS does by default.
+	Compress bool
+}
+
+// R serialization constants.
+const (
+	rdsNil       = 254 // NILVALUE_SXP
+	rdsSymbol    = 1
+	rdsPairList  = 2
+	rdsChar      = 9
+	rdsLogical   = 10
+	rdsInteger   = 13
+	rdsReal      = 14
+	rdsComplex   = 15
+	rdsString    = 16
+	rdsList      = 19
+	rdsRaw       = 24
+	rdsIsObject  = 1 << 8
+	rdsHasAttr   = 1 << 9
+	rdsHasTag    = 1 << 10
+	rdsUTF8      = 8 << 12  // UTF8_MASK in the gp bits of a CHARSXP
+	rdsASCII     = 64 << 12 // ASCII_MASK
+	rdsNAInteger = math.MinInt32
+	rdsNAReal    = 0x7FF00000000007A2 // The NaN with payload 1954.
+)
+
+// rdsAttr is an attribute of a R object.
+type rdsAttr struct {
+	name  string
+	value []string
+}
+
+// Dump implements the Dump method of a Dumper.
+func (d RDSDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	var gz *gzip.Writer
+	out := d.Writer
+	if d.Compress {
+		gz = gzip.NewWriter(out)
+		out = gz
+	}
+	w := rdsWriter{bufio.NewWriter(out)}
+	w.WriteString("X\n")
+	w.int(2)        // Serialization format version.
+	w.int(0x040000) // Written by R 4.0.0.
+	w.int(0x020300) // Readable by R 2.3.0 and newer.
+
+	w.int(rdsList | rdsIsObject | rdsHasAttr)
+	w.int(len(e.Columns))
+	for _, field := range e.Columns {
+		d.column(w, e, field, format)
+	}
+	names := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		names[i] = field.Name
+	}
+	w.tag("names")
+	w.strings(names)
+	w.tag("class")
+	w.strings([]string{"data.frame"})
+	w.tag("row.names") // The compact form c(NA, -N) of 1:N.
+	w.int(rdsInteger)
+	w.int(2)
+	w.int(rdsNAInteger)
+	w.int(-e.N)
+	w.int(rdsNil)
+
+	e.progress(0, e.N)
+	err = w.Flush()
+	if gz != nil {
+		if cerr := gz.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// column writes the values of field as a R vector.
+func (d RDSDumper) column(w rdsWriter, e *Extractor, field Column, format Format) {
+	var attrs []rdsAttr
+	switch field.Type() {
+	case Time:
+		attrs = []rdsAttr{{"class", []string{"POSIXct", "POSIXt"}},
+			{"tzone", []string{rTimeZone(format.TimeLoc)}}}
+	case Duration:
+		attrs = []rdsAttr{{"class", []string{"difftime"}}, {"units", []string{"secs"}}}
+	}
+	object := attrs != nil
+	if field.Label != "" {
+		attrs = append(attrs, rdsAttr{"label", []string{field.Label}})
+	}
+	header := func(typ int) {
+		if object {
+			typ |= rdsIsObject
+		}
+		if attrs != nil {
+			typ |= rdsHasAttr
+		}
+		w.int(typ)
+		w.int(e.N)
+	}
+
+	switch typ := field.Type(); {
+	case typ == Bool || typ == NA:
+		header(rdsLogical)
+		for r := 0; r < e.N; r++ {
+			switch b, ok := field.value(r).(bool); {
+			case !ok:
+				w.int(rdsNAInteger)
+			case b:
+				w.int(1)
+			default:
+				w.int(0)
+			}
+		}
+	case (typ == Int || typ == Uint) && rdsIntegers(e, field):
+		header(rdsInteger)
+		for r := 0; r < e.N; r++ {
+			switch x := field.value(r).(type) {
+			case int64:
+				w.int(int(x))
+			case uint64:
+				w.int(int(x))
+			default:
+				w.int(rdsNAInteger)
+			}
+		}
+	case typ == Int || typ == Uint || typ == Float || typ == Time || typ == Duration:
+		header(rdsReal)
+		for r := 0; r < e.N; r++ {
+			switch x := field.value(r).(type) {
+			case int64:
+				w.double(float64(x))
+			case uint64:
+				w.double(float64(x))
+			case float64:
+				w.double(x)
+			case time.Time:
+				w.double(float64(x.Unix()) + float64(x.Nanosecond())/1e9)
+			case time.Duration:
+				w.double(x.Seconds())
+			default:
+				w.bits(rdsNAReal)
+			}
+		}
+	case typ == Complex:
+		header(rdsComplex)
+		for r := 0; r < e.N; r++ {
+			if c, ok := field.value(r).(complex128); ok {
+				w.double(real(c))
+				w.double(imag(c))
+			} else {
+				w.bits(rdsNAReal)
+				w.bits(rdsNAReal)
+			}
+		}
+	case typ == Bytes:
+		header(rdsList)
+		for r := 0; r < e.N; r++ {
+			b, ok := field.value(r).([]byte)
+			if !ok {
+				w.int(rdsNil)
+				continue
+			}
+			w.int(rdsRaw)
+			w.int(len(b))
+			w.Write(b)
+		}
+	default:
+		header(rdsString)
+		for r := 0; r < e.N; r++ {
+			if s, ok := field.value(r).(string); ok {
+				w.char(s)
+			} else {
+				w.int(rdsChar)
+				w.int(-1) // NA_STRING
+			}
+		}
+	}
+
+	if attrs != nil {
+		for _, attr := range attrs {
+			w.tag(attr.name)
+			w.strings(attr.value)
+		}
+		w.int(rdsNil)
+	}
+}
+
+// rdsIntegers reports whether all values of the Int or Uint column field
+// are representable as R integers.
+func rdsIntegers(e *Extractor, field Column) bool {
+	for r := 0; r < e.N; r++ {
+		switch x := field.value(r).(type) {
+		case int64:
+			if x <= math.MinInt32 || x > math.MaxInt32 {
+				return false
+			}
+		case uint64:
+			if x > math.MaxInt32 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// rdsWriter writes R objects in the XDR serialization format. Errors are
+// reported by Flush.
+type rdsWriter struct {
+	*bufio.Writer
+}
+
+func (w rdsWriter) int(i int) {
+	w.bits32(uint32(int32(i)))
+}
+
+func (w rdsWriter) bits32(u uint32) {
+	w.Write([]byte{byte(u >> 24), byte(u >> 16), byte(u >> 8), byte(u)})
+}
+
+func (w rdsWriter) double(x float64) {
+	w.bits(math.Float64bits(x))
+}
+
+func (w rdsWriter) bits(u uint64) {
+	w.bits32(uint32(u >> 32))
+	w.bits32(uint32(u))
+}
+
+// char writes s as a CHARSXP.
+func (w rdsWriter) char(s string) {
+	encoding := rdsUTF8
+	if isASCII(s) {
+		encoding = rdsASCII
+	}
+	w.int(rdsChar | encoding)
+	w.int(len(s))
+	w.WriteString(s)
+}
+
+// strings writes a character vector.
+func (w rdsWriter) strings(values []string) {
+	w.int(rdsString)
+	w.int(len(values))
+	for _, s := range values {
+		w.char(s)
+	}
+}
+
+// tag starts the next node of a tagged pairlist like an attribute list.
+func (w rdsWriter) tag(name string) {
+	w.int(rdsPairList | rdsHasTag)
+	w.int(rdsSymbol)
+	w.char(name)
+}
+
+// isASCII reports whether s consists of ASCII characters only.
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func init() {
+	RegisterDumper("rds", func(w io.Writer) Dumper {
+		return RDSDumper{Writer: w}
+	})
+}
@@ -0,0 +1,200 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rObject is a decoded R object.
+type rObject struct {
+	typ   int
+	attrs map[string]*rObject
+	ints  []int32
+	reals []float64
+	strs  []string // NA_STRING is decoded as "<NA>".
+	elems []*rObject
+	raw   []byte
+}
+
+// rReader decodes the R objects written by RDSDumper.
+type rReader struct {
+	t    *testing.T
+	data []byte
+}
+
+func (r *rReader) int() int32 {
+	if len(r.data) < 4 {
+		r.t.Fatalf("Unexpected end of data")
+	}
+	i := int32(binary.BigEndian.Uint32(r.data))
+	r.data = r.data[4:]
+	return i
+}
+
+func (r *rReader) real() float64 {
+	hi, lo := uint32(r.int()), uint32(r.int())
+	return math.Float64frombits(uint64(hi)<<32 | uint64(lo))
+}
+
+func (r *rReader) char() string {
+	flags := r.int()
+	if flags&0xff != rdsChar {
+		r.t.Fatalf("Got type %d, want CHARSXP", flags&0xff)
+	}
+	n := int(r.int())
+	if n < 0 {
+		return "<NA>"
+	}
+	s := string(r.data[:n])
+	r.data = r.data[n:]
+	return s
+}
+
+func (r *rReader) object() *rObject {
+	flags := r.int()
+	obj := &rObject{typ: int(flags & 0xff)}
+	switch obj.typ {
+	case rdsNil:
+		return obj
+	case rdsLogical, rdsInteger:
+		for n := r.int(); n > 0; n-- {
+			obj.ints = append(obj.ints, r.int())
+		}
+	case rdsReal:
+		for n := r.int(); n > 0; n-- {
+			obj.reals = append(obj.reals, r.real())
+		}
+	case rdsComplex:
+		for n := 2 * r.int(); n > 0; n-- {
+			obj.reals = append(obj.reals, r.real())
+		}
+	case rdsString:
+		for n := r.int(); n > 0; n-- {
+			obj.strs = append(obj.strs, r.char())
+		}
+	case rdsList:
+		for n := r.int(); n > 0; n-- {
+			obj.elems = append(obj.elems, r.object())
+		}
+	case rdsRaw:
+		n := int(r.int())
+		obj.raw, r.data = r.data[:n], r.data[n:]
+	default:
+		r.t.Fatalf("Unexpected type %d", obj.typ)
+	}
+	if flags&rdsHasAttr != 0 {
+		obj.attrs = map[string]*rObject{}
+		for r.int() == rdsPairList|rdsHasTag {
+			if sym := r.int(); sym != rdsSymbol {
+				r.t.Fatalf("Got tag type %d", sym)
+			}
+			name := r.char()
+			obj.attrs[name] = r.object()
+		}
+	}
+	return obj
+}
+
+func TestRDSDumper(t *testing.T) {
+	type rdsRow struct {
+		B *bool
+		I int
+		U uint64
+		F float64
+		S *string
+		T time.Time
+		D time.Duration
+		C complex128
+		X []byte
+	}
+	yes, grün := true, "grün"
+	data := []rdsRow{
+		{&yes, 1, 2, 0.5, &grün, time.Unix(1500000000, 500000000), 1500 * time.Millisecond, 1 + 2i, []byte{1, 2}},
+		{nil, -7, math.MaxUint32, math.NaN(), nil, time.Unix(0, 0), -time.Minute, 0, nil},
+	}
+	extractor, err := NewExtractor(data, "B", "I", "U", "F", "S", "T", "D", "C", "X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[1].Label = "Count"
+	extractor.Columns[5].Label = "Seen at"
+	buf := &bytes.Buffer{}
+	utc := RFormat
+	utc.TimeLoc = time.UTC
+	if err := (RDSDumper{Writer: buf, Compress: true}).Dump(extractor, utc); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	gz, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	raw, _ := io.ReadAll(gz)
+	if !strings.HasPrefix(string(raw), "X\n\x00\x00\x00\x02") {
+		t.Fatalf("Bad header % x", raw[:6])
+	}
+	r := &rReader{t: t, data: raw[14:]}
+	df := r.object()
+	if len(r.data) != 0 {
+		t.Errorf("Trailing %d bytes", len(r.data))
+	}
+
+	if df.typ != rdsList || len(df.elems) != 9 {
+		t.Fatalf("Got %#v", df)
+	}
+	if got := df.attrs["names"].strs; !reflect.DeepEqual(got, []string{"B", "I", "U", "F", "S", "T", "D", "C", "X"}) {
+		t.Errorf("Got names %q", got)
+	}
+	if got := df.attrs["class"].strs; !reflect.DeepEqual(got, []string{"data.frame"}) {
+		t.Errorf("Got class %q", got)
+	}
+	if got := df.attrs["row.names"].ints; !reflect.DeepEqual(got, []int32{math.MinInt32, -2}) {
+		t.Errorf("Got row.names %v", got)
+	}
+
+	col := df.elems
+	if col[0].typ != rdsLogical || !reflect.DeepEqual(col[0].ints, []int32{1, math.MinInt32}) {
+		t.Errorf("Got B %#v", col[0])
+	}
+	if col[1].typ != rdsInteger || !reflect.DeepEqual(col[1].ints, []int32{1, -7}) ||
+		!reflect.DeepEqual(col[1].attrs["label"].strs, []string{"Count"}) {
+		t.Errorf("Got I %#v", col[1])
+	}
+	if col[2].typ != rdsReal || !reflect.DeepEqual(col[2].reals, []float64{2, math.MaxUint32}) {
+		t.Errorf("Got U %#v", col[2])
+	}
+	if col[3].typ != rdsReal || col[3].reals[0] != 0.5 || !math.IsNaN(col[3].reals[1]) ||
+		math.Float64bits(col[3].reals[1]) == rdsNAReal {
+		t.Errorf("Got F %#v", col[3])
+	}
+	if col[4].typ != rdsString || !reflect.DeepEqual(col[4].strs, []string{"grün", "<NA>"}) {
+		t.Errorf("Got S %#v", col[4])
+	}
+	if !reflect.DeepEqual(col[5].reals, []float64{1500000000.5, 0}) ||
+		!reflect.DeepEqual(col[5].attrs["class"].strs, []string{"POSIXct", "POSIXt"}) ||
+		!reflect.DeepEqual(col[5].attrs["tzone"].strs, []string{"UTC"}) ||
+		!reflect.DeepEqual(col[5].attrs["label"].strs, []string{"Seen at"}) {
+		t.Errorf("Got T %#v", col[5])
+	}
+	if !reflect.DeepEqual(col[6].reals, []float64{1.5, -60}) ||
+		!reflect.DeepEqual(col[6].attrs["units"].strs, []string{"secs"}) {
+		t.Errorf("Got D %#v", col[6])
+	}
+	if col[7].typ != rdsComplex || !reflect.DeepEqual(col[7].reals, []float64{1, 2, 0, 0}) {
+		t.Errorf("Got C %#v", col[7])
+	}
+	if col[8].typ != rdsList || len(col[8].elems) != 2 ||
+		!bytes.Equal(col[8].elems[0].raw, []byte{1, 2}) || col[8].elems[1].typ != rdsNil {
+		t.Errorf("Got X %#v", col[8])
+	}
+}
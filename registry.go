@@ -0,0 +1,112 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+)
+
+// A DumperFactory constructs a Dumper which outputs to w.
+type DumperFactory func(w io.Writer) Dumper
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]DumperFactory{}
+)
+
+// RegisterDumper makes the Dumpers constructed by factory available under
+// the given format name. Registering a name again replaces the factory,
+// registering a nil factory removes the name.
+// The Dumpers of this package are registered under lower case names like
+// "csv", "markdown" or "r"; RegisteredDumpers lists all names.
+func RegisterDumper(name string, factory DumperFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if factory == nil {
+		delete(registry, name)
+		return
+	}
+	registry[name] = factory
+}
+
+// LookupDumper returns the factory registered under the format name
+// or nil if no factory is registered under this name.
+func LookupDumper(name string) DumperFactory {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return registry[name]
+}
+
+// RegisteredDumpers returns the sorted names of all registered Dumpers.
+func RegisteredDumpers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DumpAs dumps e in the given format to w with the Dumper registered
+// under name.
+func DumpAs(e *Extractor, name string, w io.Writer, format Format) error {
+	factory := LookupDumper(name)
+	if factory == nil {
+		return fmt.Errorf("export: no dumper registered for format %q", name)
+	}
+	return factory(w).Dump(e, format)
+}
+
+// DumpReader returns a reader producing the dump of e in the given format
+// by the Dumper constructed by factory. The dump is produced on demand while
+// reading; an error of the Dumper is returned by Read. Closing the reader
+// before reaching EOF aborts the dump.
+// The Extractor must not be rebound until the dump is completely read.
+func DumpReader(e *Extractor, factory DumperFactory, format Format) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(factory(pw).Dump(e, format))
+	}()
+	return pr
+}
+
+// flushDumper is a Dumper which flushes after dumping.
+type flushDumper struct {
+	Dumper
+	flush func() error
+}
+
+func (d flushDumper) Dump(e *Extractor, format Format) error {
+	if err := d.Dumper.Dump(e, format); err != nil {
+		return err
+	}
+	return d.flush()
+}
+
+func init() {
+	RegisterDumper("binary", func(w io.Writer) Dumper {
+		return BinaryDumper{Writer: w}
+	})
+	RegisterDumper("csv", func(w io.Writer) Dumper {
+		return CSVDumper{Writer: csv.NewWriter(w)}
+	})
+	RegisterDumper("markdown", func(w io.Writer) Dumper {
+		return MarkdownDumper{Writer: w}
+	})
+	RegisterDumper("r", func(w io.Writer) Dumper {
+		return RVecDumper{Writer: w}
+	})
+	RegisterDumper("tab", func(w io.Writer) Dumper {
+		tw := tabwriter.NewWriter(w, 1, 8, 1, ' ', 0)
+		return flushDumper{TabDumper{Writer: tw}, tw.Flush}
+	})
+}
@@ -0,0 +1,116 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDumpAs(t *testing.T) {
+	extractor, err := NewExtractor(table[:2], "S", "I", "F")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for _, tc := range []struct{ name, want string }{
+		{"csv", "S,I,F\nHello,12,3.141\nWorld,14,2.718\n"},
+		{"tab", "S     I  F\nHello 12 3.141\nWorld 14 2.718\n"},
+		{"markdown", "| S | I | F |\n| --- | ---: | ---: |\n| Hello | 12 | 3.141 |\n| World | 14 | 2.718 |\n"},
+	} {
+		buf := &bytes.Buffer{}
+		if err := DumpAs(extractor, tc.name, buf, DefaultFormat); err != nil {
+			t.Errorf("%s: Unexpected error: %s", tc.name, err)
+		}
+		if got := buf.String(); got != tc.want {
+			t.Errorf("%s: Got:\n%s\nWant:\n%s", tc.name, got, tc.want)
+		}
+	}
+
+	if err := DumpAs(extractor, "no-such-format", io.Discard, DefaultFormat); err == nil {
+		t.Errorf("Missing error for unregistered format")
+	}
+}
+
+func TestRegisterDumper(t *testing.T) {
+	RegisterDumper("test-upper", func(w io.Writer) Dumper {
+		return upperDumper{w}
+	})
+	defer RegisterDumper("test-upper", nil)
+
+	found := false
+	for _, name := range RegisteredDumpers() {
+		found = found || name == "test-upper"
+	}
+	if !found {
+		t.Errorf("test-upper not in %v", RegisteredDumpers())
+	}
+
+	extractor, _ := NewExtractor(table[:2], "S")
+	buf := &bytes.Buffer{}
+	if err := DumpAs(extractor, "test-upper", buf, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := buf.String(); got != "HELLO\nWORLD\n" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestDumpReader(t *testing.T) {
+	extractor, _ := NewExtractor(table[:2], "S", "I")
+	r := DumpReader(extractor, LookupDumper("csv"), DefaultFormat)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := string(data); got != "S,I\nHello,12\nWorld,14\n" {
+		t.Errorf("Got %q", got)
+	}
+
+	// Errors of the Dumper are reported by Read.
+	failing := func(w io.Writer) Dumper { return failDumper{} }
+	if _, err := io.ReadAll(DumpReader(extractor, failing, DefaultFormat)); err != errFail {
+		t.Errorf("Got error %v, want %v", err, errFail)
+	}
+
+	// Closing early aborts the dump.
+	r = DumpReader(extractor, LookupDumper("csv"), DefaultFormat)
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := r.Close(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+var errFail = errors.New("fail")
+
+type failDumper struct{}
+
+func (failDumper) Dump(e *Extractor, format Format) error { return errFail }
+
+type upperDumper struct{ w io.Writer }
+
+func (d upperDumper) Dump(e *Extractor, format Format) error {
+	for r := 0; r < e.N; r++ {
+		io.WriteString(d.w, strings.ToUpper(e.Columns[0].Print(format, r))+"\n")
+	}
+	return nil
+}
+
+func TestMarkdownEscaping(t *testing.T) {
+	data := []struct{ A string }{{"a|b"}, {"two\nlines"}}
+	extractor, _ := NewExtractor(data, "A")
+	buf := &bytes.Buffer{}
+	MarkdownDumper{Writer: buf}.Dump(extractor, DefaultFormat)
+	want := "| A |\n| --- |\n| a\\|b |\n| two lines |\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// RRunner runs R scripts on the data of an Extractor in a R subprocess,
+// e.g. to render a ggplot2 plot of the data to a PNG or PDF file:
+//
+//	r := RRunner{}
+//	err := r.Plot(e, "ggplot(data, aes(Carat, Price)) + geom_point()", "price.png")
+//
+// The data is fed to R as the script generated by RVecDumper with RFormat.
+type RRunner struct {
+	// Binary is the path of the R executable, the default is Rscript
+	// looked up in PATH. The script is passed on standard input.
+	Binary string
+
+	// DataFrame is the name of the data frame containing the data,
+	// the default is data.
+	DataFrame string
+
+	// Width and Height are the size of plots in inches, the default
+	// is 7 by 7 inches.
+	Width, Height float64
+
+	// DPI is the resolution of plots to raster formats like PNG, the
+	// default is 150.
+	DPI int
+}
+
+// Run executes the R script after the data of e has been loaded into the
+// data frame and returns the output of R. If R fails the error contains
+// the output of R.
+func (r RRunner) Run(e *Extractor, script string) ([]byte, error) {
+	binary := r.Binary
+	if binary == "" {
+		binary = "Rscript"
+	}
+	name := r.DataFrame
+	if name == "" {
+		name = "data"
+	}
+	cmd := exec.Command(binary, "--vanilla", "-")
+	pr, pw := io.Pipe()
+	cmd.Stdin = pr
+	dumped := make(chan error, 1)
+	go func() {
+		err := RVecDumper{Writer: pw, DataFrame: name}.Dump(e, RFormat)
+		if err == nil {
+			_, err = io.WriteString(pw, script)
+		}
+		pw.CloseWithError(err)
+		dumped <- err
+	}()
+	out, err := cmd.CombinedOutput()
+	pr.Close() // Stop dumping if R exits early.
+	if derr := <-dumped; derr != nil && derr != io.ErrClosedPipe {
+		return out, derr
+	}
+	if err != nil {
+		return out, fmt.Errorf("export: running %s: %v\n%s", binary, err, out)
+	}
+	return out, nil
+}
+
+// Plot renders the ggplot2 plot returned by the R expression plot to the
+// file path whose extension (e.g. .png, .pdf or .svg) determines the
+// format. The data of e is available in the data frame and the ggplot2
+// library is loaded.
+func (r RRunner) Plot(e *Extractor, plot string, path string) error {
+	width, height := r.Width, r.Height
+	if width <= 0 {
+		width = 7
+	}
+	if height <= 0 {
+		height = 7
+	}
+	dpi := r.DPI
+	if dpi <= 0 {
+		dpi = 150
+	}
+	script := fmt.Sprintf(`suppressPackageStartupMessages(library(ggplot2))
+p <- (%s)
+ggsave(%q, plot = p, width = %g, height = %g, units = "in", dpi = %d)
+`, plot, path, width, height, dpi)
+	if _, err := r.Run(e, script); err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("export: R did not produce %s", path)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// fakeR writes a shell script to dir which stores its standard input in
+// script.R in dir and then runs body.
+func fakeR(t *testing.T, dir, body string) string {
+	path := filepath.Join(dir, "R")
+	sh := "#!/bin/sh\ncat > " + filepath.Join(dir, "script.R") + "\n" + body + "\n"
+	if err := os.WriteFile(path, []byte(sh), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestRRunner(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Needs a shell.")
+	}
+	extractor, err := NewExtractor(table, "I", "S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dir := t.TempDir()
+	png := filepath.Join(dir, "plot.png")
+	r := RRunner{Binary: fakeR(t, dir, "touch "+png), Width: 5}
+	if err := r.Plot(extractor, "ggplot(data, aes(I)) + geom_bar()", png); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	script, _ := os.ReadFile(filepath.Join(dir, "script.R"))
+	for _, want := range []string{
+		"I <- c(12, 14, 14, 16)\n",
+		"data <- data.frame(I, S)\n",
+		"p <- (ggplot(data, aes(I)) + geom_bar())\n",
+		`ggsave("` + png + `", plot = p, width = 5, height = 7, units = "in", dpi = 150)`,
+	} {
+		if !strings.Contains(string(script), want) {
+			t.Errorf("Missing %q in\n%s", want, script)
+		}
+	}
+
+	// No plot produced.
+	if err := r.Plot(extractor, "ggplot()", filepath.Join(dir, "none.pdf")); err == nil {
+		t.Errorf("Missing error")
+	}
+
+	r.Binary = fakeR(t, dir, "echo 'Error: object not found' >&2; exit 1")
+	out, err := r.Run(extractor, "print(summary(data))")
+	if err == nil || !strings.Contains(err.Error(), "Error: object not found") ||
+		string(out) != "Error: object not found\n" {
+		t.Errorf("Got %q and error %v", out, err)
+	}
+
+	// R exiting without reading all of a large dump.
+	many := make([]S, 50000)
+	extractor.Bind(many)
+	r.Binary = filepath.Join(dir, "exit3")
+	if err := os.WriteFile(r.Binary, []byte("#!/bin/sh\nexit 3\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Run(extractor, ""); err == nil || !strings.Contains(err.Error(), "exit status 3") {
+		t.Errorf("Got error %v", err)
+	}
+}
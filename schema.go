@@ -0,0 +1,164 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Schema describes the columns of an Extractor as written by the Dumpers.
+// All columns may contain NA values.
+type Schema struct {
+	Columns []ColumnDef
+}
+
+// Schema returns the schema of e. Columns with a Render function or a
+// Format are String columns like in the output of the Dumpers.
+func (e *Extractor) Schema() Schema {
+	s := Schema{Columns: make([]ColumnDef, len(e.Columns))}
+	for i, field := range e.Columns {
+		typ := field.Type()
+		if field.render() != nil {
+			typ = String
+		}
+		s.Columns[i] = ColumnDef{Name: field.Name, Type: typ,
+			Label: field.Label, Description: field.Description}
+	}
+	return s
+}
+
+// jsonSchemaTypes are the JSON Schema types of the values written by
+// JSONDumper with DefaultFormat.
+var jsonSchemaTypes = map[Type]string{
+	Bool:     "boolean",
+	Int:      "integer",
+	Uint:     "integer",
+	Float:    "number",
+	Complex:  "string",
+	String:   "string",
+	Time:     "string",
+	Duration: "string",
+	Bytes:    "string",
+}
+
+// JSONSchema returns a JSON Schema (draft 2020-12) of the array of objects
+// written by JSONDumper with DefaultFormat: Each object has all columns as
+// properties whose value may be null.
+func (s Schema) JSONSchema() []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"$schema":"https://json-schema.org/draft/2020-12/schema",` +
+		`"type":"array","items":{"type":"object","properties":{`)
+	names := make([]string, len(s.Columns))
+	for i, col := range s.Columns {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		names[i] = jsonQuote(col.Name)
+		buf.WriteString(names[i] + `:{"type":["` + jsonSchemaTypes[col.Type] + `","null"]`)
+		if col.Label != "" {
+			buf.WriteString(`,"title":` + jsonQuote(col.Label))
+		}
+		if col.Description != "" {
+			buf.WriteString(`,"description":` + jsonQuote(col.Description))
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString(`},"required":[` + strings.Join(names, ",") + `]}}`)
+	return indentJSON(buf.Bytes())
+}
+
+// avroTypes are the Avro types of the column types. Uints are decimals as
+// they may exceed the range of long, Times are microseconds since the Unix
+// epoch and Durations nanoseconds.
+var avroTypes = map[Type]string{
+	Bool:     `"boolean"`,
+	Int:      `"long"`,
+	Uint:     `{"type":"bytes","logicalType":"decimal","precision":20,"scale":0}`,
+	Float:    `"double"`,
+	Complex:  `"string"`,
+	String:   `"string"`,
+	Time:     `{"type":"long","logicalType":"timestamp-micros"}`,
+	Duration: `"long"`,
+	Bytes:    `"bytes"`,
+}
+
+// AvroSchema returns an Avro schema of a record with the given name and
+// one nullable field per column documented by its label and description.
+// Column names which are not valid Avro names are changed by replacing
+// invalid characters with '_'.
+func (s Schema) AvroSchema(name string) []byte {
+	buf := &bytes.Buffer{}
+	buf.WriteString(`{"type":"record","name":` + jsonQuote(avroName(name)) + `,"fields":[`)
+	for i, col := range s.Columns {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(`{"name":` + jsonQuote(avroName(col.Name)) +
+			`,"type":["null",` + avroTypes[col.Type] + `],"default":null`)
+		if doc := col.doc(); doc != "" {
+			buf.WriteString(`,"doc":` + jsonQuote(doc))
+		}
+		buf.WriteString("}")
+	}
+	buf.WriteString("]}")
+	return indentJSON(buf.Bytes())
+}
+
+// avroName returns name with all characters but ASCII letters, digits and
+// '_' replaced by '_' and a leading digit prefixed by '_'.
+func avroName(name string) string {
+	b := []byte(name)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || b[0] >= '0' && b[0] <= '9' {
+		b = append([]byte{'_'}, b...)
+	}
+	return string(b)
+}
+
+// CreateTable returns an SQL CREATE TABLE statement for a table with the
+// columns of s. The SQL column types are taken from types which defaults
+// to the types used by SQLiteDumper. The identifiers are quoted like in
+// ANSI SQL. Labels and descriptions of the columns are added as comments
+// which e.g. SQLite keeps in its schema.
+func (s Schema) CreateTable(table string, types map[Type]string) string {
+	if types == nil {
+		types = sqliteTypes
+	}
+	buf := &strings.Builder{}
+	buf.WriteString("CREATE TABLE " + quoteIdent(table) + " (")
+	for i, col := range s.Columns {
+		buf.WriteString("\n  " + quoteIdent(col.Name) + " " + types[col.Type])
+		if i < len(s.Columns)-1 {
+			buf.WriteString(",")
+		}
+		if doc := col.doc(); doc != "" {
+			buf.WriteString(" -- " + strings.Join(strings.Fields(doc), " "))
+		}
+	}
+	buf.WriteString("\n)")
+	return buf.String()
+}
+
+// doc returns the label and the description of d separated by ": ".
+func (d ColumnDef) doc() string {
+	if d.Label != "" && d.Description != "" {
+		return d.Label + ": " + d.Description
+	}
+	return d.Label + d.Description
+}
+
+// indentJSON returns the valid JSON b indented by two spaces.
+func indentJSON(b []byte) []byte {
+	buf := &bytes.Buffer{}
+	json.Indent(buf, b, "", "  ")
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
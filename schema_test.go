@@ -0,0 +1,179 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	extractor, err := NewExtractor([]gem{}, "Cut", "Price", "Carat", "Bought", "Price/Carat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[1].Render = func(v interface{}) string { return "#" }
+	schema := extractor.Schema()
+	want := []ColumnDef{
+		{Name: "Cut", Type: String},
+		{Name: "Price", Type: String},
+		{Name: "Carat", Type: Float},
+		{Name: "Bought", Type: Time},
+		{Name: "Price/Carat", Type: Float},
+	}
+	for i, col := range schema.Columns {
+		if col != want[i] {
+			t.Errorf("Column %d: Got %+v, want %+v", i, col, want[i])
+		}
+	}
+
+	wantJSON := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "array",
+  "items": {
+    "type": "object",
+    "properties": {
+      "Cut": {
+        "type": [
+          "string",
+          "null"
+        ]
+      },
+      "Price": {
+        "type": [
+          "string",
+          "null"
+        ]
+      },
+      "Carat": {
+        "type": [
+          "number",
+          "null"
+        ]
+      },
+      "Bought": {
+        "type": [
+          "string",
+          "null"
+        ]
+      },
+      "Price/Carat": {
+        "type": [
+          "number",
+          "null"
+        ]
+      }
+    },
+    "required": [
+      "Cut",
+      "Price",
+      "Carat",
+      "Bought",
+      "Price/Carat"
+    ]
+  }
+}
+`
+	if got := string(schema.JSONSchema()); got != wantJSON {
+		t.Errorf("Got JSON Schema:\n%s\nWant:\n%s", got, wantJSON)
+	}
+
+	var avro struct {
+		Type, Name string
+		Fields     []struct {
+			Name    string
+			Type    []interface{}
+			Default interface{}
+		}
+	}
+	if err := json.Unmarshal(schema.AvroSchema("1gem"), &avro); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if avro.Type != "record" || avro.Name != "_1gem" || len(avro.Fields) != 5 {
+		t.Fatalf("Got %+v", avro)
+	}
+	if f := avro.Fields[4]; f.Name != "Price_Carat" || f.Type[0] != "null" || f.Type[1] != "double" {
+		t.Errorf("Got field %+v", f)
+	}
+	if f := avro.Fields[3]; f.Type[1].(map[string]interface{})["logicalType"] != "timestamp-micros" {
+		t.Errorf("Got field %+v", f)
+	}
+
+	wantSQL := `CREATE TABLE "gems" (
+  "Cut" TEXT,
+  "Price" TEXT,
+  "Carat" REAL,
+  "Bought" TIMESTAMP,
+  "Price/Carat" REAL
+)`
+	if got := schema.CreateTable("gems", nil); got != wantSQL {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, wantSQL)
+	}
+}
+
+func TestColumnMetadata(t *testing.T) {
+	data := []gem{{Cut: "Ideal", Price: 300, Carat: 0.5}}
+	extractor, err := NewExtractor(data, "Cut", "Price", "Carat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[0].Label = "Cut quality"
+	extractor.Columns[1].Label = "Price"
+	extractor.Columns[1].Description = "List price\nin USD"
+	extractor = extractor.Materialize()
+
+	schema := extractor.Schema()
+	wantSQL := `CREATE TABLE "gems" (
+  "Cut" TEXT, -- Cut quality
+  "Price" INTEGER, -- Price: List price in USD
+  "Carat" REAL
+)`
+	if got := schema.CreateTable("gems", nil); got != wantSQL {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, wantSQL)
+	}
+
+	var avro struct {
+		Fields []struct{ Name, Doc string }
+	}
+	if err := json.Unmarshal(schema.AvroSchema("gem"), &avro); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for i, want := range []string{"Cut quality", "Price: List price\nin USD", ""} {
+		if got := avro.Fields[i].Doc; got != want {
+			t.Errorf("Field %d: got doc %q, want %q", i, got, want)
+		}
+	}
+
+	var jsonSchema struct {
+		Items struct {
+			Properties map[string]struct{ Title, Description string }
+		}
+	}
+	if err := json.Unmarshal(schema.JSONSchema(), &jsonSchema); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if p := jsonSchema.Items.Properties["Price"]; p.Title != "Price" || p.Description != "List price\nin USD" {
+		t.Errorf("Got Price %+v", p)
+	}
+
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf, LabelRow: true}.Dump(extractor, DefaultFormat)
+	if got, want := buf.String(), "Cut,Price,Carat\nCut quality,Price,\nIdeal,300,0.5\n"; got != want {
+		t.Errorf("Got CSV %q, want %q", got, want)
+	}
+
+	buf.Reset()
+	RVecDumper{Writer: buf}.Dump(extractor, RFormat)
+	want := `Cut <- c("Ideal")
+attr(Cut, "label") <- "Cut quality"
+Price <- c(300)
+attr(Price, "label") <- "Price"
+Carat <- c(0.5)
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got R\n%s\nwant\n%s", got, want)
+	}
+}
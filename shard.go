@@ -0,0 +1,114 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"io"
+)
+
+// ShardedDumper dumps the values into several files, each a complete dump
+// (e.g. with its own header line) of consecutive rows.
+type ShardedDumper struct {
+	// Pattern is the package fmt template of the file names. It is
+	// formatted with the number of the shard (starting at 0), e.g.
+	// "out-%04d.csv".
+	Pattern string
+
+	// Dumper constructs the Dumper for each file. If nil the Dumper is
+	// selected by the file extension like in WriteFileAuto.
+	Dumper DumperFactory
+
+	// MaxRows is the maximal number of rows in one file, 0 means
+	// unlimited.
+	MaxRows int
+
+	// MaxBytes is the maximal size of one file, 0 means unlimited.
+	// A single row exceeding MaxBytes is put in a file of its own.
+	// Determining the row ranges obeying MaxBytes requires trial dumps
+	// whose number and size grow with the rows per file only.
+	MaxBytes int64
+}
+
+// Dump implements the Dump method of a Dumper.
+// At least one file is written, even if e has no rows.
+func (d ShardedDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	shard := 0
+	for start := 0; start < e.N || shard == 0; shard++ {
+		path := fmt.Sprintf(d.Pattern, shard)
+		factory := d.Dumper
+		if factory == nil {
+			var err error
+			if factory, err = dumperForPath(path); err != nil {
+				return err
+			}
+		}
+		end := e.N
+		if d.MaxRows > 0 && start+d.MaxRows < end {
+			end = start + d.MaxRows
+		}
+		if d.MaxBytes > 0 && end > start+1 {
+			var err error
+			if end, err = d.fitBytes(e, factory, format, start, end); err != nil {
+				return err
+			}
+		}
+		if err := writeFile(path, factory, e.window(start, end), format); err != nil {
+			return err
+		}
+		e.progress(start, end)
+		start = end
+	}
+	return nil
+}
+
+// fitBytes returns the largest end in (start, max] such that the rows start
+// to end-1 dump to at most d.MaxBytes, or start+1 if there is none.
+func (d ShardedDumper) fitBytes(e *Extractor, factory DumperFactory, format Format, start, max int) (int, error) {
+	size := func(end int) (int64, error) {
+		w := &countingWriter{w: io.Discard}
+		err := factory(w).Dump(e.window(start, end), format)
+		return w.n, err
+	}
+	// Double the rows until they do not fit (galloping search) so that
+	// the trial dumps do not depend on the rows after the file, then
+	// bisect with the invariant: lo fits (or is start+1), hi does not.
+	lo, hi := start+1, max
+	for rows := 1; ; rows *= 2 {
+		end := start + rows
+		if end > max {
+			end = max
+		}
+		n, err := size(end)
+		if err != nil {
+			return 0, err
+		}
+		if n > d.MaxBytes {
+			hi = end
+			break
+		}
+		lo = end
+		if end == max {
+			return max, nil
+		}
+	}
+	for hi-lo > 1 {
+		mid := lo + (hi-lo)/2
+		n, err := size(mid)
+		if err != nil {
+			return 0, err
+		}
+		if n <= d.MaxBytes {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo, nil
+}
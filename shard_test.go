@@ -0,0 +1,113 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShardedDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for i, tc := range []struct {
+		d    ShardedDumper
+		want []string
+	}{
+		{
+			ShardedDumper{MaxRows: 3},
+			[]string{"S,I\nHello,12\nWorld,14\nGo,14\n", "S,I\nA Lot,16\n"},
+		},
+		{
+			ShardedDumper{},
+			[]string{"S,I\nHello,12\nWorld,14\nGo,14\nA Lot,16\n"},
+		},
+		{
+			// Header has 4 bytes, rows 9, 9, 6 and 9 bytes.
+			ShardedDumper{MaxBytes: 22},
+			[]string{"S,I\nHello,12\nWorld,14\n", "S,I\nGo,14\nA Lot,16\n"},
+		},
+		{
+			ShardedDumper{MaxBytes: 5, MaxRows: 3},
+			[]string{"S,I\nHello,12\n", "S,I\nWorld,14\n", "S,I\nGo,14\n", "S,I\nA Lot,16\n"},
+		},
+	} {
+		dir := t.TempDir()
+		tc.d.Pattern = filepath.Join(dir, "out-%02d.csv")
+		if err := tc.d.Dump(extractor, DefaultFormat); err != nil {
+			t.Fatalf("%d: Unexpected error: %s", i, err)
+		}
+		files, _ := filepath.Glob(filepath.Join(dir, "*"))
+		if len(files) != len(tc.want) {
+			t.Errorf("%d: Got %d files %v, want %d", i, len(files), files, len(tc.want))
+			continue
+		}
+		for s, want := range tc.want {
+			got, err := os.ReadFile(fmt.Sprintf(tc.d.Pattern, s))
+			if err != nil || string(got) != want {
+				t.Errorf("%d: Shard %d got %q (%v), want %q", i, s, got, err, want)
+			}
+		}
+	}
+
+	// No rows still yields a file with a header.
+	extractor.Bind(table[:0])
+	dir := t.TempDir()
+	d := ShardedDumper{Pattern: filepath.Join(dir, "out-%d.md"), MaxRows: 2}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "out-0.md")); string(got) != "| S | I |\n| --- | ---: |\n" {
+		t.Errorf("Got %q", got)
+	}
+
+	d.Pattern = filepath.Join(dir, "out-%d.xyz")
+	if err := d.Dump(extractor, DefaultFormat); err == nil {
+		t.Errorf("Missing error for unknown extension")
+	}
+}
+
+// rowCounter is a DelimitedDumper counting the dumped rows.
+type rowCounter struct {
+	w    io.Writer
+	rows *int
+}
+
+func (d rowCounter) Dump(e *Extractor, format Format) error {
+	*d.rows += e.N
+	return DelimitedDumper{Writer: d.w}.Dump(e, format)
+}
+
+func TestShardedDumperTrialRows(t *testing.T) {
+	data := make([]struct{ I int }, 2000)
+	extractor, err := NewExtractor(data, "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rows := 0
+	d := ShardedDumper{
+		Pattern:  filepath.Join(t.TempDir(), "out-%03d.csv"),
+		Dumper:   func(w io.Writer) Dumper { return rowCounter{w, &rows} },
+		MaxBytes: 2 + 10*2, // header and 10 rows
+	}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	files, _ := filepath.Glob(filepath.Join(filepath.Dir(d.Pattern), "*"))
+	if len(files) != 200 {
+		t.Errorf("Got %d files", len(files))
+	}
+	// At most trial dumps of 1, 2, 4, 8, 16, 12, 10 and 11 rows plus the
+	// file, independent of the rows after the file.
+	if rows > 200*(64+10) {
+		t.Errorf("Dumped %d rows for %d rows of data", rows, len(data))
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// SortOrder determines the order of the values of a column in SortBy.
+type SortOrder int
+
+const (
+	// Ascending sorts the values from small to large, false before
+	// true and earlier times before later ones.
+	Ascending SortOrder = 0
+
+	// Descending reverses Ascending.
+	Descending SortOrder = 1
+
+	// NAFirst puts NA values (and NaNs) in front of the other values
+	// instead of after them; it is combined with Ascending or
+	// Descending like Descending|NAFirst.
+	NAFirst SortOrder = 2
+)
+
+// SortBy returns a view of e with the rows sorted by the given columns.
+// The keys are column names, each optionally followed by a SortOrder
+// which defaults to Ascending, e.g. SortBy("Price", Descending, "Cut").
+// The sort is stable and puts NA values last unless NAFirst is given.
+// The values of the key columns are extracted once. Like the view
+// returned by Slice the sorted view reads its values from e.
+func (e *Extractor) SortBy(keys ...interface{}) (*Extractor, error) {
+	var names []string
+	var orders []SortOrder
+	for _, k := range keys {
+		switch k := k.(type) {
+		case string:
+			names = append(names, k)
+			orders = append(orders, Ascending)
+		case SortOrder:
+			if len(orders) == 0 {
+				return nil, fmt.Errorf("export: sort order %d without column", k)
+			}
+			orders[len(orders)-1] = k
+		default:
+			return nil, fmt.Errorf("export: bad sort key %v of type %T", k, k)
+		}
+	}
+	indices, err := e.columnIndices(names)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]interface{}, len(indices))
+	for k, c := range indices {
+		values[k] = make([]interface{}, e.N)
+		for r := range values[k] {
+			values[k][r] = e.Columns[c].value(r)
+		}
+	}
+	perm := make([]int, e.N)
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.SliceStable(perm, func(i, j int) bool {
+		for k, order := range orders {
+			a, b := values[k][perm[i]], values[k][perm[j]]
+			na, nb := isNA(a), isNA(b)
+			switch {
+			case na && nb:
+				continue
+			case na || nb:
+				return nb != (order&NAFirst != 0)
+			}
+			c := compareValues(a, b)
+			if c == 0 {
+				continue
+			}
+			if order&Descending != 0 {
+				return c > 0
+			}
+			return c < 0
+		}
+		return false
+	})
+
+	return e.subset(perm), nil
+}
+
+// isNA reports whether the canonical value v is NA or a NaN.
+func isNA(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case float64:
+		return x != x
+	}
+	return false
+}
+
+// compareValues returns -1, 0 or +1 if the canonical value a (which is
+// not NA) is less than, equal to or greater than the value b of the same
+// type. Complex numbers are compared by real part first.
+func compareValues(a, b interface{}) int {
+	cmp := func(less, greater bool) int {
+		switch {
+		case less:
+			return -1
+		case greater:
+			return 1
+		}
+		return 0
+	}
+	switch x := a.(type) {
+	case bool:
+		y := b.(bool)
+		return cmp(!x && y, x && !y)
+	case int64:
+		y := b.(int64)
+		return cmp(x < y, x > y)
+	case uint64:
+		y := b.(uint64)
+		return cmp(x < y, x > y)
+	case float64:
+		y := b.(float64)
+		return cmp(x < y, x > y)
+	case complex128:
+		y := b.(complex128)
+		if c := cmp(real(x) < real(y), real(x) > real(y)); c != 0 {
+			return c
+		}
+		return cmp(imag(x) < imag(y), imag(x) > imag(y))
+	case string:
+		y := b.(string)
+		return cmp(x < y, x > y)
+	case time.Time:
+		y := b.(time.Time)
+		return cmp(x.Before(y), x.After(y))
+	case time.Duration:
+		y := b.(time.Duration)
+		return cmp(x < y, x > y)
+	case []byte:
+		return bytes.Compare(x, b.([]byte))
+	}
+	return 0
+}
@@ -0,0 +1,76 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestSortBy(t *testing.T) {
+	type Item struct {
+		Name  string
+		Group string
+		Price *float64
+	}
+	p := func(f float64) *float64 { return &f }
+	data := []Item{
+		{"a", "x", p(3)},
+		{"b", "y", nil},
+		{"c", "x", p(1)},
+		{"d", "y", p(3)},
+		{"e", "x", p(math.NaN())},
+		{"f", "y", p(1)},
+	}
+	extractor, err := NewExtractor(data, "Name", "Group", "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	names := func(e *Extractor) string {
+		s := ""
+		for i := 0; i < e.N; i++ {
+			s += e.Columns[0].value(i).(string)
+		}
+		return s
+	}
+	for i, tc := range []struct {
+		keys []interface{}
+		want string
+	}{
+		{[]interface{}{"Price"}, "cfadbe"},
+		{[]interface{}{"Price", Descending}, "adcfbe"},
+		{[]interface{}{"Price", Descending | NAFirst}, "beadcf"},
+		{[]interface{}{"Group", Descending, "Price"}, "fdbcae"},
+		{[]interface{}{"Group", "Name", Descending}, "ecafdb"},
+		{[]interface{}{}, "abcdef"},
+	} {
+		sorted, err := extractor.SortBy(tc.keys...)
+		if err != nil {
+			t.Errorf("%d: Unexpected error: %s", i, err)
+			continue
+		}
+		if got := names(sorted); got != tc.want {
+			t.Errorf("%d: Got %s, want %s", i, got, tc.want)
+		}
+	}
+
+	sorted, _ := extractor.SortBy("Price")
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf, OmitHeader: true}.Dump(sorted.window(0, 2), DefaultFormat)
+	if got, want := buf.String(), "c,x,1\nf,y,1\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	for i, keys := range [][]interface{}{
+		{Descending, "Price"},
+		{"Price", 1.5},
+		{"Weight"},
+	} {
+		if _, err := extractor.SortBy(keys...); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+}
@@ -0,0 +1,372 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Execer is the interface of *sql.DB and *sql.Tx used by DBDumper.
+type Execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// DBDumper inserts the values into an existing database table.
+type DBDumper struct {
+	DB    Execer // DB is the database or transaction to insert into.
+	Table string // Table is the name of the table, it is used verbatim.
+
+	// DollarPlaceholders selects the placeholders $1, $2, ... used
+	// e.g. by PostgreSQL instead of ?.
+	DollarPlaceholders bool
+
+	// BatchSize is the number of rows inserted by one statement. The
+	// default keeps the number of parameters per statement below 1000.
+	BatchSize int
+
+	// Quote quotes column names in the insert statement. If nil the
+	// names are enclosed in double quotes as in ANSI SQL.
+	Quote func(name string) string
+}
+
+// Dump implements the Dump method of a Dumper.
+// The values are passed to the database driver as bool, int64, float64,
+// string, []byte and time.Time, durations as int64 nanoseconds and NA
+// values as NULL; the driver converts them to the native literals of the database,
+// e.g. bools independent of TrueRep and FalseRep. Uints are passed as
+// int64 if they fit and as decimal strings otherwise as drivers need not
+// support uint64. Only complex values are formated (with format) to
+// strings.
+func (d DBDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	if len(e.Columns) == 0 {
+		return nil
+	}
+	quote := d.Quote
+	if quote == nil {
+		quote = quoteIdent
+	}
+	names := make([]string, len(e.Columns))
+	for i, field := range e.Columns {
+		names[i] = quote(field.Name)
+	}
+	batch := d.BatchSize
+	if batch <= 0 {
+		batch = 999 / len(e.Columns)
+		if batch == 0 {
+			batch = 1
+		}
+	}
+	prefix := "INSERT INTO " + d.Table + " (" + strings.Join(names, ", ") + ") VALUES "
+
+	args := make([]interface{}, 0, batch*len(e.Columns))
+	for start := 0; start < e.N; start += batch {
+		end := start + batch
+		if end > e.N {
+			end = e.N
+		}
+		args = args[:0]
+		tuples := make([]string, 0, end-start)
+		for r := start; r < end; r++ {
+			ph := make([]string, len(e.Columns))
+			for i, field := range e.Columns {
+				args = append(args, sqlValue(field, format, r))
+				if d.DollarPlaceholders {
+					ph[i] = fmt.Sprintf("$%d", len(args))
+				} else {
+					ph[i] = "?"
+				}
+			}
+			tuples = append(tuples, "("+strings.Join(ph, ", ")+")")
+		}
+		if _, err := d.DB.Exec(prefix+strings.Join(tuples, ", "), args...); err != nil {
+			return fmt.Errorf("export: inserting rows %d to %d: %v", start, end-1, err)
+		}
+		e.progress(start, end)
+	}
+	return nil
+}
+
+// SQLiteDumper creates a table in a SQLite database file and inserts the
+// values. The program must import a SQLite driver for package database/sql,
+// e.g. github.com/mattn/go-sqlite3 or modernc.org/sqlite.
+type SQLiteDumper struct {
+	Path  string // Path of the database file which is created if missing.
+	Table string // Table is the name of the table to create.
+
+	// Driver is the name of the registered database driver.
+	// It defaults to "sqlite3".
+	Driver string
+
+	// Append inserts the values into the table if it exists already.
+	// The set of column names of the table must match the columns of
+	// the extractor.
+	Append bool
+}
+
+// sqliteTypes are the SQLite column types used for the column types.
+var sqliteTypes = map[Type]string{
+	Bool:     "BOOLEAN",
+	Int:      "INTEGER",
+	Uint:     "INTEGER",
+	Float:    "REAL",
+	Complex:  "TEXT",
+	String:   "TEXT",
+	Time:     "TIMESTAMP",
+	Duration: "INTEGER",
+	Bytes:    "BLOB",
+}
+
+// Dump implements the Dump method of a Dumper.
+// The values are inserted by a DBDumper in a single transaction.
+func (d SQLiteDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	driver := d.Driver
+	if driver == "" {
+		driver = "sqlite3"
+	}
+	db, err := sql.Open(driver, d.Path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	exists := false
+	if d.Append {
+		if exists, err = checkTableColumns(tx, d.Table, e); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if !exists {
+		if _, err := tx.Exec(e.Schema().CreateTable(d.Table, sqliteTypes)); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	dumper := DBDumper{DB: tx, Table: quoteIdent(d.Table)}
+	if err := dumper.Dump(e, format); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// checkTableColumns reports whether table exists in tx and returns an error
+// if its set of column names differs from the columns of e.
+func checkTableColumns(tx *sql.Tx, table string, e *Extractor) (bool, error) {
+	rows, err := tx.Query("SELECT * FROM " + quoteIdent(table) + " LIMIT 0")
+	if err != nil {
+		// Most likely the table does not exist.
+		return false, nil
+	}
+	names, err := rows.Columns()
+	rows.Close()
+	if err != nil {
+		return true, err
+	}
+	if _, err := reorderColumns(e, names); err != nil {
+		return true, fmt.Errorf("export: cannot append to table %s: %v", table, err)
+	}
+	return true, nil
+}
+
+// quoteIdent quotes name as an ANSI SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// sqlValue returns the r'th value of field as a value suitable for a
+// database driver.
+func sqlValue(field Column, format Format, r int) interface{} {
+	v := field.value(r)
+	switch x := v.(type) {
+	case complex128:
+		return format.Complex(x)
+	case time.Duration:
+		return int64(x)
+	case uint64:
+		if x > math.MaxInt64 {
+			return strconv.FormatUint(x, 10)
+		}
+		return int64(x)
+	}
+	return v
+}
+
+// NewExtractorFromRows returns an Extractor for the result set rows of a
+// query with one column per result column. All rows are read into memory
+// and rows is closed. The column types are determined from the scan and
+// database types reported by the driver, e.g. "INTEGER", "REAL", "TEXT",
+// "BLOB" and "TIMESTAMP", or from the first non-NULL value if the driver
+// reports neither; NULLs and values which cannot be converted to the type
+// of their column are NA. The returned Extractor cannot be rebound.
+func NewExtractorFromRows(rows *sql.Rows) (*Extractor, error) {
+	defer rows.Close()
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	types := make([]Type, len(names))
+	if cts, err := rows.ColumnTypes(); err == nil {
+		for i, ct := range cts {
+			types[i] = sqlColumnType(ct)
+		}
+	}
+
+	values := make([][]interface{}, len(names))
+	dest := make([]interface{}, len(names))
+	for rows.Next() {
+		scanned := make([]interface{}, len(names))
+		for i := range dest {
+			dest[i] = &scanned[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		for i, v := range scanned {
+			values[i] = append(values[i], v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	e := &Extractor{Columns: make([]Column, len(names))}
+	for i, name := range names {
+		typ := types[i]
+		for r := 0; typ == NA && r < len(values[i]); r++ {
+			if values[i][r] != nil {
+				typ = superType(reflect.TypeOf(values[i][r]))
+			}
+		}
+		if typ == NA {
+			typ = String
+		}
+		column := make([]interface{}, len(values[i]))
+		for r, v := range values[i] {
+			column[r] = sqlCanonical(v, typ)
+		}
+		e.N = len(column)
+		e.Columns[i] = Column{
+			Name:  name,
+			typ:   typ,
+			value: func(r int) interface{} { return column[r] },
+		}
+	}
+	return e, nil
+}
+
+// sqlColumnType returns the Type of the result column ct or NA if the
+// driver does not provide enough information.
+func sqlColumnType(ct *sql.ColumnType) Type {
+	if st := ct.ScanType(); st != nil {
+		if conv, ok := lookupType(st); ok {
+			return conv.typ
+		}
+		for st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if t := superType(st); t != NA && t != Bytes {
+			return t
+		}
+	}
+	name := strings.ToUpper(ct.DatabaseTypeName())
+	contains := func(parts ...string) bool {
+		for _, p := range parts {
+			if strings.Contains(name, p) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case name == "":
+		return NA
+	case contains("BOOL"):
+		return Bool
+	case contains("INT"):
+		return Int
+	case contains("REAL", "FLOA", "DOUB", "NUMERIC", "DECIMAL"):
+		return Float
+	case contains("DATE", "TIMESTAMP"):
+		return Time
+	case contains("BLOB", "BINARY", "BYTEA"):
+		return Bytes
+	}
+	return String
+}
+
+// sqlCanonical converts the scanned value v to the canonical value of typ
+// with the conversions of package database/sql. Values which cannot be
+// converted are NA.
+func sqlCanonical(v interface{}, typ Type) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch typ {
+	case Bool:
+		var n sql.NullBool
+		if n.Scan(v) == nil && n.Valid {
+			return n.Bool
+		}
+	case Int:
+		var n sql.NullInt64
+		if n.Scan(v) == nil && n.Valid {
+			return n.Int64
+		}
+	case Uint:
+		var n sql.NullString
+		if n.Scan(v) == nil && n.Valid {
+			if u, err := strconv.ParseUint(n.String, 10, 64); err == nil {
+				return u
+			}
+		}
+	case Float:
+		var n sql.NullFloat64
+		if n.Scan(v) == nil && n.Valid {
+			return n.Float64
+		}
+	case String:
+		var n sql.NullString
+		if n.Scan(v) == nil && n.Valid {
+			return n.String
+		}
+	case Time:
+		var n sql.NullTime
+		if n.Scan(v) == nil && n.Valid {
+			return n.Time
+		}
+	case Bytes:
+		switch b := v.(type) {
+		case []byte:
+			return b
+		case string:
+			return []byte(b)
+		}
+	case Duration:
+		var n sql.NullInt64
+		if n.Scan(v) == nil && n.Valid {
+			return time.Duration(n.Int64)
+		}
+	}
+	return nil
+}
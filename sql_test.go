@@ -0,0 +1,335 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recDriver is a database driver which records all executed statements.
+// Queries of the form SELECT * FROM "name" ... return no rows but the
+// columns of the table name in tables, other queries return the result
+// in results.
+type recDriver struct {
+	mu      sync.Mutex
+	log     []string
+	fail    bool
+	tables  map[string][]string
+	results map[string]*recResult
+}
+
+var testDriver = &recDriver{}
+
+func init() {
+	sql.Register("exporttest", testDriver)
+}
+
+func (d *recDriver) reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = nil
+	d.tables = nil
+	d.results = nil
+}
+
+func (d *recDriver) Open(name string) (driver.Conn, error) { return recConn{d}, nil }
+
+type recConn struct{ d *recDriver }
+
+func (c recConn) Prepare(query string) (driver.Stmt, error) { return recStmt{c.d, query}, nil }
+func (c recConn) Close() error                              { return nil }
+func (c recConn) Begin() (driver.Tx, error)                 { return recTx{c.d}, nil }
+
+type recTx struct{ d *recDriver }
+
+func (tx recTx) Commit() error   { return tx.d.record("COMMIT") }
+func (tx recTx) Rollback() error { return tx.d.record("ROLLBACK") }
+
+func (d *recDriver) record(entry string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.log = append(d.log, entry)
+	return nil
+}
+
+type recStmt struct {
+	d     *recDriver
+	query string
+}
+
+func (s recStmt) Close() error  { return nil }
+func (s recStmt) NumInput() int { return -1 }
+func (s recStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	if s.d.fail {
+		return nil, errors.New("failure")
+	}
+	entry := s.query
+	for _, a := range args {
+		if t, ok := a.(time.Time); ok {
+			a = t.UTC().Format(time.RFC3339)
+		}
+		entry += fmt.Sprintf(" | %T %v", a, a)
+	}
+	s.d.log = append(s.d.log, entry)
+	return driver.RowsAffected(1), nil
+}
+func (s recStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.d.mu.Lock()
+	defer s.d.mu.Unlock()
+	for name, columns := range s.d.tables {
+		if strings.HasPrefix(s.query, "SELECT * FROM "+quoteIdent(name)) {
+			return recRows(columns), nil
+		}
+	}
+	if result, ok := s.d.results[s.query]; ok {
+		r := *result
+		return &r, nil
+	}
+	return nil, errors.New("no query")
+}
+
+type recRows []string
+
+func (r recRows) Columns() []string              { return r }
+func (r recRows) Close() error                   { return nil }
+func (r recRows) Next(dest []driver.Value) error { return io.EOF }
+
+// recResult is a result set with optional database type names.
+type recResult struct {
+	columns, types []string
+	rows           [][]driver.Value
+}
+
+func (r *recResult) Columns() []string { return r.columns }
+func (r *recResult) Close() error      { return nil }
+func (r *recResult) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+func (r *recResult) ColumnTypeDatabaseTypeName(i int) string {
+	if r.types == nil {
+		return ""
+	}
+	return r.types[i]
+}
+
+func TestDBDumper(t *testing.T) {
+	db, err := sql.Open("exporttest", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer db.Close()
+	testDriver.reset()
+
+	extractor, err := NewExtractor(table[:3], "B", "I", "F", "S", "T", "D", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	d := DBDumper{DB: db, Table: "data", BatchSize: 2, DollarPlaceholders: true}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{
+		`INSERT INTO data ("B", "I", "F", "S", "T", "D", "SME") VALUES ($1, $2, $3, $4, $5, $6, $7), ($8, $9, $10, $11, $12, $13, $14)` +
+			` | bool true | int64 12 | float64 3.14149 | string Hello | string 2000-01-02T15:20:30Z | int64 3000000000 | <nil> <nil>` +
+			` | bool true | int64 14 | float64 2.71828 | string World | string 2000-01-02T03:20:30Z | int64 9000000 | <nil> <nil>`,
+		`INSERT INTO data ("B", "I", "F", "S", "T", "D", "SME") VALUES ($1, $2, $3, $4, $5, $6, $7)` +
+			` | bool false | int64 14 | float64 NaN | string Go | string 2000-01-02T15:20:30Z | int64 0 | <nil> <nil>`,
+	}
+	if len(testDriver.log) != len(want) {
+		t.Fatalf("Got %d statements, want %d: %v", len(testDriver.log), len(want), testDriver.log)
+	}
+	for i := range want {
+		if testDriver.log[i] != want[i] {
+			t.Errorf("Statement %d:\nGot  %s\nWant %s", i, testDriver.log[i], want[i])
+		}
+	}
+
+	testDriver.reset()
+	d = DBDumper{DB: db, Table: "t", Quote: func(s string) string { return "`" + s + "`" }}
+	extractor, _ = NewExtractor(table, "C")
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want = []string{"INSERT INTO t (`C`) VALUES (?), (?), (?), (?)" +
+		" | string (3.1+4.2i) | string (0+9i) | string (0+0i) | string +∞"}
+	if len(testDriver.log) != 1 || testDriver.log[0] != want[0] {
+		t.Errorf("Got %v\nWant %v", testDriver.log, want)
+	}
+
+	testDriver.fail = true
+	defer func() { testDriver.fail = false }()
+	if err := d.Dump(extractor, DefaultFormat); err == nil {
+		t.Errorf("Missing error")
+	}
+}
+
+func TestSQLiteDumper(t *testing.T) {
+	testDriver.reset()
+	extractor, err := NewExtractor(table[:1], "B", "I", "F", "S", "T", "D", "C")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	d := SQLiteDumper{Path: "ignored.db", Table: "my table", Driver: "exporttest"}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	want := []string{
+		`CREATE TABLE "my table" (
+  "B" BOOLEAN,
+  "I" INTEGER,
+  "F" REAL,
+  "S" TEXT,
+  "T" TIMESTAMP,
+  "D" INTEGER,
+  "C" TEXT
+)`,
+		`INSERT INTO "my table" ("B", "I", "F", "S", "T", "D", "C") VALUES (?, ?, ?, ?, ?, ?, ?)` +
+			` | bool true | int64 12 | float64 3.14149 | string Hello | string 2000-01-02T15:20:30Z | int64 3000000000 | string (3.1+4.2i)`,
+		"COMMIT",
+	}
+	if len(testDriver.log) != len(want) {
+		t.Fatalf("Got %d statements, want %d: %v", len(testDriver.log), len(want), testDriver.log)
+	}
+	for i := range want {
+		if testDriver.log[i] != want[i] {
+			t.Errorf("Statement %d:\nGot  %s\nWant %s", i, testDriver.log[i], want[i])
+		}
+	}
+
+	d.Driver = "no-such-driver"
+	if err := d.Dump(extractor, DefaultFormat); err == nil {
+		t.Errorf("Missing error for unknown driver")
+	}
+}
+
+func TestSQLiteDumperAppend(t *testing.T) {
+	testDriver.reset()
+	extractor, err := NewExtractor(table[:1], "S", "I")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	d := SQLiteDumper{Path: "ignored.db", Table: "data", Driver: "exporttest", Append: true}
+
+	// Missing table is created.
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(testDriver.log) != 3 || !strings.HasPrefix(testDriver.log[0], "CREATE TABLE") {
+		t.Errorf("Got %v", testDriver.log)
+	}
+
+	// Existing table is appended to.
+	testDriver.reset()
+	testDriver.tables = map[string][]string{"data": {"I", "S"}}
+	if err := d.Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := []string{
+		`INSERT INTO "data" ("S", "I") VALUES (?, ?) | string Hello | int64 12`,
+		"COMMIT",
+	}
+	if fmt.Sprint(testDriver.log) != fmt.Sprint(want) {
+		t.Errorf("Got %v\nWant %v", testDriver.log, want)
+	}
+
+	// Mismatching columns.
+	testDriver.reset()
+	testDriver.tables = map[string][]string{"data": {"S", "X"}}
+	if err := d.Dump(extractor, DefaultFormat); err == nil ||
+		!strings.Contains(err.Error(), "missing columns X; extra columns I") {
+		t.Errorf("Got error %v", err)
+	}
+	if fmt.Sprint(testDriver.log) != "[ROLLBACK]" {
+		t.Errorf("Got %v", testDriver.log)
+	}
+}
+
+func TestNewExtractorFromRows(t *testing.T) {
+	testDriver.reset()
+	defer testDriver.reset()
+	when := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	testDriver.results = map[string]*recResult{
+		"typed": {
+			columns: []string{"id", "name", "price", "sold", "raw", "flag"},
+			types:   []string{"INTEGER", "VARCHAR(20)", "DECIMAL(8,2)", "TIMESTAMP", "BLOB", "BOOLEAN"},
+			rows: [][]driver.Value{
+				{int64(1), []byte("pear"), "1.25", when, []byte{1, 2}, int64(1)},
+				{int64(2), nil, nil, nil, nil, "false"},
+				{"x", "fig", 3.5, "no time", "ab", "maybe"},
+			},
+		},
+		"untyped": {
+			columns: []string{"a", "b", "c"},
+			rows: [][]driver.Value{
+				{nil, 2.5, nil},
+				{int64(7), 1.0, nil},
+			},
+		},
+	}
+	db, err := sql.Open("exporttest", "")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	defer db.Close()
+
+	for _, tc := range []struct {
+		query string
+		types []Type
+		want  [][]interface{}
+	}{
+		{"typed", []Type{Int, String, Float, Time, Bytes, Bool}, [][]interface{}{
+			{int64(1), "pear", 1.25, when, "\x01\x02", true},
+			{int64(2), nil, nil, nil, nil, false},
+			{nil, "fig", 3.5, nil, "ab", nil},
+		}},
+		{"untyped", []Type{Int, Float, String}, [][]interface{}{
+			{nil, 2.5, nil},
+			{int64(7), 1.0, nil},
+		}},
+	} {
+		rows, err := db.Query(tc.query)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		extractor, err := NewExtractorFromRows(rows)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if extractor.N != len(tc.want) {
+			t.Fatalf("%s: Got %d rows", tc.query, extractor.N)
+		}
+		for i, field := range extractor.Columns {
+			if field.Type() != tc.types[i] {
+				t.Errorf("%s: Column %s has type %s, want %s", tc.query, field.Name, field.Type(), tc.types[i])
+			}
+			for r, row := range tc.want {
+				got := field.value(r)
+				if b, ok := got.([]byte); ok {
+					got = string(b)
+				}
+				if got != row[i] {
+					t.Errorf("%s: %s[%d] = %#v, want %#v", tc.query, field.Name, r, got, row[i])
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"math"
+)
+
+// Summary returns an Extractor with one row of statistics per column of e
+// to sanity-check data like R's summary function. Its columns are
+//   - Column: the name of the column
+//   - Type: the type of the column
+//   - Count: the number of non-NA values
+//   - NA: the number of NA values (NaNs are counted as NA)
+//   - Min, Max: the smallest and largest value formatted with
+//     DefaultFormat (NA for Complex columns)
+//   - Mean, SD: the arithmetic mean and the sample standard deviation
+//     of Int, Uint and Float columns
+//   - Distinct: the number of distinct values of String columns.
+//
+// Statistics which do not apply or lack enough values are NA. The values
+// are computed once, the returned Extractor cannot be rebound.
+func (e *Extractor) Summary() *Extractor {
+	n := len(e.Columns)
+	stats := make([][]interface{}, 9)
+	for i := range stats {
+		stats[i] = make([]interface{}, n)
+	}
+	for c, field := range e.Columns {
+		typ := field.Type()
+		var count, na int64
+		var min, max interface{}
+		var mean, m2 float64 // running mean and squared deviations
+		distinct := map[string]bool{}
+		for r := 0; r < e.N; r++ {
+			v := field.value(r)
+			if isNA(v) {
+				na++
+				continue
+			}
+			count++
+			if typ != Complex {
+				if min == nil || compareValues(v, min) < 0 {
+					min = v
+				}
+				if max == nil || compareValues(v, max) > 0 {
+					max = v
+				}
+			}
+			switch typ {
+			case Int, Uint, Float:
+				// Welford's algorithm is numerically stable.
+				x := toFloat(v)
+				delta := x - mean
+				mean += delta / float64(count)
+				m2 += delta * (x - mean)
+			case String:
+				distinct[v.(string)] = true
+			}
+		}
+
+		stats[0][c], stats[1][c] = field.Name, typ.String()
+		stats[2][c], stats[3][c] = count, na
+		if min != nil {
+			stats[4][c] = formatValue(DefaultFormat, typ, min)
+			stats[5][c] = formatValue(DefaultFormat, typ, max)
+		}
+		if typ == Int || typ == Uint || typ == Float {
+			if count > 0 {
+				stats[6][c] = mean
+			}
+			if count > 1 {
+				stats[7][c] = math.Sqrt(m2 / float64(count-1))
+			}
+		}
+		if typ == String {
+			stats[8][c] = int64(len(distinct))
+		}
+	}
+
+	names := []string{"Column", "Type", "Count", "NA", "Min", "Max", "Mean", "SD", "Distinct"}
+	types := []Type{String, String, Int, Int, String, String, Float, Float, Int}
+	summary := &Extractor{N: n}
+	for i, name := range names {
+		values := stats[i]
+		summary.Columns = append(summary.Columns, Column{
+			Name:  name,
+			typ:   types[i],
+			value: func(r int) interface{} { return values[r] },
+		})
+	}
+	return summary
+}
+
+// Tabulate returns a frequency table of the column name of e: An Extractor
+// with the distinct values of the column (with NA last if present) in
+// ascending order, the number of their occurrences in column "Count" and
+// their share of all rows in percent in column "Percent".
+func (e *Extractor) Tabulate(name string) (*Extractor, error) {
+	groups, err := e.GroupBy(name).Aggregate(Count())
+	if err != nil {
+		return nil, err
+	}
+	groups.Columns[1].Name = "Count"
+	return addPercent(groups, e.N), nil
+}
+
+// Histogram returns a frequency table of the Int, Uint or Float column name
+// of e whose values are binned into the given number of bins of equal
+// width between the smallest and the largest finite value; a single bin is
+// used if all these values are equal. The bins are labeled like "[0,10)"
+// (the last bin is closed). Infinite values are counted in the bins
+// "[-Inf]" and "[+Inf]" before and after the others and a bin counting NA
+// values is appended if there are any. The other columns are like in
+// Tabulate.
+func (e *Extractor) Histogram(name string, bins int) (*Extractor, error) {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	field := e.Columns[idx[0]]
+	if typ := field.Type(); typ != Int && typ != Uint && typ != Float {
+		return nil, fmt.Errorf("export: cannot bin %s column %s", typ, name)
+	}
+	if bins < 1 {
+		return nil, fmt.Errorf("export: bad number of bins %d", bins)
+	}
+
+	values := make([]float64, 0, e.N)
+	min, max := math.Inf(1), math.Inf(-1)
+	var minusInf, plusInf int64
+	for r := 0; r < e.N; r++ {
+		v := field.value(r)
+		if isNA(v) {
+			continue
+		}
+		switch x := toFloat(v); {
+		case math.IsInf(x, -1):
+			minusInf++
+		case math.IsInf(x, 1):
+			plusInf++
+		default:
+			values = append(values, x)
+			min, max = math.Min(min, x), math.Max(max, x)
+		}
+	}
+	switch {
+	case len(values) == 0:
+		bins = 0
+	case min == max:
+		bins = 1
+	}
+	width := (max - min) / float64(bins)
+	counts := make([]int64, bins)
+	for _, x := range values {
+		b := bins - 1
+		if width > 0 {
+			b = int((x - min) / width)
+		}
+		if b >= bins {
+			b = bins - 1
+		}
+		counts[b]++
+	}
+
+	labels := make([]interface{}, 0, bins+3)
+	freqs := make([]interface{}, 0, bins+3)
+	if minusInf > 0 {
+		labels = append(labels, "[-Inf]")
+		freqs = append(freqs, minusInf)
+	}
+	for b, n := range counts {
+		lo, hi, closing := min+float64(b)*width, min+float64(b+1)*width, ")"
+		if b == bins-1 {
+			hi, closing = max, "]"
+		}
+		labels = append(labels, fmt.Sprintf("[%g,%g%s", lo, hi, closing))
+		freqs = append(freqs, n)
+	}
+	if plusInf > 0 {
+		labels = append(labels, "[+Inf]")
+		freqs = append(freqs, plusInf)
+	}
+	if na := e.N - len(values) - int(minusInf+plusInf); na > 0 {
+		labels = append(labels, nil)
+		freqs = append(freqs, int64(na))
+	}
+	hist := &Extractor{N: len(labels), Columns: []Column{
+		{Name: name, typ: String, value: func(i int) interface{} { return labels[i] }},
+		{Name: "Count", typ: Int, value: func(i int) interface{} { return freqs[i] }},
+	}}
+	return addPercent(hist, e.N), nil
+}
+
+// addPercent appends a column "Percent" with the values of the column
+// "Count" of e relative to total.
+func addPercent(e *Extractor, total int) *Extractor {
+	count := e.Columns[1].value
+	e.Columns = append(e.Columns, Column{
+		Name: "Percent",
+		typ:  Float,
+		value: func(i int) interface{} {
+			return 100 * float64(count(i).(int64)) / float64(total)
+		},
+	})
+	return e
+}
+
+// NACount returns the number of NA values (including NaNs) in column c of
+// the data bound to e.
+func (c Column) NACount(e *Extractor) int {
+	n := 0
+	for r := 0; r < e.N; r++ {
+		if isNA(c.value(r)) {
+			n++
+		}
+	}
+	return n
+}
+
+// ColumnCompleteness describes the NA values in a column.
+type ColumnCompleteness struct {
+	Name     string  // Name of the column.
+	NA       int     // NA is the number of NA values including NaNs.
+	Complete float64 // Complete is the fraction of non-NA values, 1 if there are no rows.
+}
+
+// Completeness returns the completeness of each column of e, e.g. to check
+// the quality of the data before dumping.
+func (e *Extractor) Completeness() []ColumnCompleteness {
+	report := make([]ColumnCompleteness, len(e.Columns))
+	for i, field := range e.Columns {
+		na := field.NACount(e)
+		complete := 1.0
+		if e.N > 0 {
+			complete = float64(e.N-na) / float64(e.N)
+		}
+		report[i] = ColumnCompleteness{Name: field.Name, NA: na, Complete: complete}
+	}
+	return report
+}
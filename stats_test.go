@@ -0,0 +1,131 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSummary(t *testing.T) {
+	two := 2
+	data := []gem{
+		{Cut: "Ideal", Price: 2, Carat: 1, X: &two, Held: time.Hour},
+		{Cut: "Good", Price: 4, Carat: math.NaN()},
+		{Cut: "Ideal", Price: 9, Carat: 2},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Price", "Carat", "X", "Held")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	buf := &bytes.Buffer{}
+	DelimitedDumper{Writer: buf}.Dump(extractor.Summary(), DefaultFormat)
+	want := `Column,Type,Count,NA,Min,Max,Mean,SD,Distinct
+Cut,String,3,0,Good,Ideal,,,2
+Price,Int,3,0,2,9,5,3.606,
+Carat,Float,2,1,1,2,1.5,0.7071,
+X,Int,1,2,2,2,2,,
+Held,Duration,3,0,0s,1h0m0s,,,
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestTabulateAndHistogram(t *testing.T) {
+	one := 1
+	data := []gem{
+		{Cut: "Ideal", Price: 0, X: &one},
+		{Cut: "Good", Price: 5},
+		{Cut: "Ideal", Price: 10, X: &one},
+		{Cut: "Fair", Price: 20},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Price", "X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	dump := func(e *Extractor) string {
+		buf := &bytes.Buffer{}
+		DelimitedDumper{Writer: buf}.Dump(e, DefaultFormat)
+		return buf.String()
+	}
+
+	table, err := extractor.Tabulate("Cut")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(table), "Cut,Count,Percent\nFair,1,25\nGood,1,25\nIdeal,2,50\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	hist, err := extractor.Histogram("Price", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(hist), "Price,Count,Percent\n\"[0,10)\",2,50\n\"[10,20]\",2,50\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	hist, err = extractor.Histogram("X", 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(hist), "X,Count,Percent\n\"[1,1]\",2,50\n,2,50\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	infinite, err := NewExtractor([]gem{{Carat: 1}, {Carat: math.Inf(1)}, {Carat: math.Inf(-1)},
+		{Carat: 3}, {Carat: math.NaN()}}, "Carat")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hist, err = infinite.Histogram("Carat", 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := dump(hist), "Carat,Count,Percent\n[-Inf],1,20\n\"[1,2)\",1,20\n\"[2,3]\",1,20\n[+Inf],1,20\n,1,20\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if hist, err = infinite.Slice(1, 3).Histogram("Carat", 3); err != nil || hist.N != 2 {
+		t.Errorf("Got %v and %d bins for infinite values only", err, hist.N)
+	}
+
+	if _, err := extractor.Histogram("Cut", 2); err == nil {
+		t.Errorf("Missing error for String column")
+	}
+	if _, err := extractor.Tabulate("Weight"); err == nil {
+		t.Errorf("Missing error for unknown column")
+	}
+}
+
+func TestCompleteness(t *testing.T) {
+	one := 1
+	data := []gem{
+		{Cut: "Ideal", Carat: math.NaN(), X: &one},
+		{Cut: "Good", Carat: 1},
+		{Cut: "Fair", Carat: 2},
+		{Cut: "Poor", Carat: math.NaN()},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Carat", "X")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got := extractor.Columns[2].NACount(extractor); got != 3 {
+		t.Errorf("Got %d NAs, want 3", got)
+	}
+	want := []ColumnCompleteness{{"Cut", 0, 1}, {"Carat", 2, 0.5}, {"X", 3, 0.25}}
+	for i, got := range extractor.Completeness() {
+		if got != want[i] {
+			t.Errorf("Got %+v, want %+v", got, want[i])
+		}
+	}
+
+	extractor.Bind([]gem{})
+	if got := extractor.Completeness()[0]; got.NA != 0 || got.Complete != 1 {
+		t.Errorf("Got %+v for no rows", got)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Stream dumps the values received from a channel or produced by an
+// iterator, a generator function or a cursor in batches, so that the output
+// of a pipeline or data larger than memory can be exported without
+// collecting it in a slice first: Only one batch is resident at a time.
+type Stream struct {
+	// Extractor determines the columns to dump. It is constructed for
+	// slices of the element type of the stream and bound to each batch
+	// in turn. Its columns may be manipulated as usual; index columns
+	// count the rows from the start of the stream. The Progress function
+	// of Extractor is not used.
+	Extractor *Extractor
+
+	// BatchSize is the number of rows dumped at once, the default is
+	// 1000.
+	BatchSize int
+
+	// Errors collects the failed method calls during the last Dump like
+	// DumpErrors; the rows are counted from the start of the stream.
+	Errors []*RowError
+
+	source reflect.Value
+}
+
+// NewStream returns a Stream for the given column specifications of source
+// which is one of
+//   - a channel of elements of type T; the stream ends once the channel
+//     is closed,
+//   - an iterator function of type func(yield func(T) bool) like
+//     iter.Seq[T],
+//   - a generator function of type func(i int) (row T, ok bool) which is
+//     called with i = 0, 1, 2, ... until ok is false or
+//   - a cursor with the methods Next() bool, Value() T and Err() error:
+//     Value returns the current row after Next reported one; the stream
+//     ends once Next returns false and Dump reports the error returned by
+//     Err. Cursors typically decode the rows read from an io.Reader.
+//
+// The column specifications are those of NewExtractor for data of type []T.
+func NewStream(source interface{}, columnSpecs ...string) (*Stream, error) {
+	rv := reflect.ValueOf(source)
+	if !rv.IsValid() {
+		return nil, fmt.Errorf("export: cannot stream nil")
+	}
+	elem := streamElem(rv.Type())
+	if elem == nil {
+		return nil, fmt.Errorf("export: cannot stream %s", rv.Type())
+	}
+	e, err := NewExtractor(reflect.MakeSlice(reflect.SliceOf(elem), 0, 0).Interface(),
+		columnSpecs...)
+	if err != nil {
+		return nil, err
+	}
+	return &Stream{Extractor: e, source: rv}, nil
+}
+
+// streamElem returns the element type of a receivable channel type, of an
+// iterator or generator function type or of a cursor type or nil if typ is
+// none of these.
+func streamElem(typ reflect.Type) reflect.Type {
+	switch typ.Kind() {
+	case reflect.Chan:
+		if typ.ChanDir()&reflect.RecvDir != 0 {
+			return typ.Elem()
+		}
+		return nil
+	case reflect.Func:
+		if typ.NumIn() != 1 {
+			return nil
+		}
+		if isGenerator(typ) {
+			return typ.Out(0)
+		}
+		yield := typ.In(0)
+		if typ.NumOut() == 0 && yield.Kind() == reflect.Func && yield.NumIn() == 1 &&
+			yield.NumOut() == 1 && yield.Out(0).Kind() == reflect.Bool {
+			return yield.In(0)
+		}
+		return nil
+	}
+	return cursorElem(typ)
+}
+
+// isGenerator reports whether typ is func(i int) (T, bool).
+func isGenerator(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Func && typ.NumIn() == 1 && typ.In(0).Kind() == reflect.Int &&
+		typ.NumOut() == 2 && typ.Out(1).Kind() == reflect.Bool
+}
+
+// cursorElem returns the type T of the Value method of typ if typ has the
+// methods Next() bool, Value() T and Err() error or nil otherwise.
+func cursorElem(typ reflect.Type) reflect.Type {
+	next, ok := typ.MethodByName("Next")
+	if !ok || next.Type.NumIn() != 1 || next.Type.NumOut() != 1 ||
+		next.Type.Out(0).Kind() != reflect.Bool {
+		return nil
+	}
+	fail, ok := typ.MethodByName("Err")
+	if !ok || fail.Type.NumIn() != 1 || fail.Type.NumOut() != 1 ||
+		fail.Type.Out(0) != errorInterface {
+		return nil
+	}
+	value, ok := typ.MethodByName("Value")
+	if !ok || value.Type.NumIn() != 1 || value.Type.NumOut() != 1 {
+		return nil
+	}
+	return value.Type.Out(0)
+}
+
+// Dump consumes the stream and dumps its values batch by batch in the
+// given format: The first batch is dumped by first, all following
+// batches by rest which typically is first with its header suppressed,
+// e.g. a DelimitedDumper with OmitHeader set. If rest is nil, first dumps
+// all batches. An empty stream is dumped as one empty batch.
+//
+// The rows of a batch are dumped once the batch is full (or the stream
+// ends), so the Dumpers should write row by row like DelimitedDumper or
+// JSONLinesDumper. If dumping fails Dump returns without consuming the
+// rest of the stream. The error of a cursor is returned after the rows
+// read before it have been dumped.
+func (s *Stream) Dump(first, rest Dumper, format Format) error {
+	if rest == nil {
+		rest = first
+	}
+	size := s.BatchSize
+	if size <= 0 {
+		size = 1000
+	}
+	s.Errors = nil
+	batch := reflect.MakeSlice(s.Extractor.typ, 0, size)
+	offset, dumped := 0, false
+	flush := func() error {
+		dumper := rest
+		if !dumped {
+			dumper = first
+		}
+		err := s.dumpBatch(dumper, format, batch.Interface(), offset)
+		offset += batch.Len()
+		batch, dumped = batch.Slice(0, 0), true
+		return err
+	}
+
+	var err, cursorErr error
+	add := func(v reflect.Value) {
+		if batch = reflect.Append(batch, v); batch.Len() == size {
+			err = flush()
+		}
+	}
+	switch {
+	case s.source.Kind() == reflect.Chan:
+		for err == nil {
+			v, ok := s.source.Recv()
+			if !ok {
+				break
+			}
+			add(v)
+		}
+	case isGenerator(s.source.Type()):
+		for i := 0; err == nil; i++ {
+			out := s.source.Call([]reflect.Value{reflect.ValueOf(i)})
+			if !out[1].Bool() {
+				break
+			}
+			add(out[0])
+		}
+	case s.source.Kind() != reflect.Func:
+		next := s.source.MethodByName("Next")
+		value := s.source.MethodByName("Value")
+		for err == nil && next.Call(nil)[0].Bool() {
+			add(value.Call(nil)[0])
+		}
+		if cerr := s.source.MethodByName("Err").Call(nil)[0]; !cerr.IsNil() {
+			cursorErr = cerr.Interface().(error)
+		}
+	default:
+		yield := reflect.MakeFunc(s.source.Type().In(0), func(args []reflect.Value) []reflect.Value {
+			add(args[0])
+			return []reflect.Value{reflect.ValueOf(err == nil)}
+		})
+		s.source.Call([]reflect.Value{yield})
+	}
+	if err == nil && (batch.Len() > 0 || !dumped) {
+		err = flush()
+	}
+	if err == nil {
+		err = cursorErr
+	}
+	return err
+}
+
+// dumpBatch binds s.Extractor to batch whose first row is the row offset
+// of the stream and dumps it with dumper.
+func (s *Stream) dumpBatch(dumper Dumper, format Format, batch interface{}, offset int) error {
+	e := s.Extractor
+	e.Bind(batch)
+	b := *e
+	b.Progress = nil
+	b.Columns = make([]Column, len(e.Columns))
+	for i, field := range e.Columns {
+		if field.synthetic {
+			value := field.value
+			field.value = func(r int) interface{} { return value(offset + r) }
+		}
+		b.Columns[i] = field
+	}
+	errs, err := DumpErrors(dumper, &b, format)
+	for _, re := range errs {
+		re.Row += offset
+		s.Errors = append(s.Errors, re)
+	}
+	return err
+}
@@ -0,0 +1,190 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+type reading struct {
+	Sensor string
+	Value  int
+}
+
+func (r reading) Check() (int, error) {
+	if r.Value < 0 {
+		return 0, errors.New("negative")
+	}
+	return r.Value, nil
+}
+
+func TestStreamChannel(t *testing.T) {
+	ch := make(chan reading)
+	go func() {
+		for i, s := range []string{"a", "b", "c", "d", "e"} {
+			ch <- reading{s, i * 10}
+		}
+		close(ch)
+	}()
+	stream, err := NewStream((<-chan reading)(ch), "Sensor", "Value")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stream.BatchSize = 2
+	stream.Extractor.AddIndexColumn("Row", 1)
+	buf := &bytes.Buffer{}
+	err = stream.Dump(DelimitedDumper{Writer: buf},
+		DelimitedDumper{Writer: buf, OmitHeader: true}, DefaultFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "Row,Sensor,Value\n1,a,0\n2,b,10\n3,c,20\n4,d,30\n5,e,40\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	empty := make(chan reading)
+	close(empty)
+	stream, _ = NewStream(empty, "Sensor")
+	buf.Reset()
+	stream.Dump(DelimitedDumper{Writer: buf}, nil, DefaultFormat)
+	if got := buf.String(); got != "Sensor\n" {
+		t.Errorf("Got %q", got)
+	}
+}
+
+func TestStreamIterator(t *testing.T) {
+	seq := func(yield func(*reading) bool) {
+		for i := 0; i < 7; i++ {
+			if !yield(&reading{"x", 3 - i}) {
+				return
+			}
+		}
+	}
+	stream, err := NewStream(seq, "Value", "Check()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stream.BatchSize = 3
+	stream.Extractor.OnError = ErrorSkip
+	buf := &bytes.Buffer{}
+	if err := stream.Dump(JSONLinesDumper{Writer: buf}, nil, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := bytes.Count(buf.Bytes(), []byte("\n")), 4; got != want {
+		t.Errorf("Got %d rows, want %d:\n%s", got, want, buf)
+	}
+	if len(stream.Errors) != 3 || stream.Errors[0].Row != 4 || stream.Errors[2].Row != 6 {
+		t.Errorf("Got errors %v", stream.Errors)
+	}
+
+	stream.Extractor.OnError = ErrorAbort
+	err = stream.Dump(JSONLinesDumper{Writer: buf}, nil, DefaultFormat)
+	if re, ok := err.(*RowError); !ok || re.Row != 4 {
+		t.Errorf("Got error %v", err)
+	}
+
+	for _, source := range []interface{}{nil, []reading{}, make(chan<- reading),
+		func(yield func(reading)) {}} {
+		if _, err := NewStream(source, "Value"); err == nil {
+			t.Errorf("Missing error for %T", source)
+		}
+	}
+}
+
+func TestStreamGenerator(t *testing.T) {
+	calls := 0
+	gen := func(i int) (reading, bool) {
+		calls++
+		return reading{"g", i}, i < 5
+	}
+	stream, err := NewStream(gen, "Sensor", "Value")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stream.BatchSize = 2
+	buf := &bytes.Buffer{}
+	err = stream.Dump(DelimitedDumper{Writer: buf},
+		DelimitedDumper{Writer: buf, OmitHeader: true}, DefaultFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "Sensor,Value\ng,0\ng,1\ng,2\ng,3\ng,4\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if calls != 6 {
+		t.Errorf("Generator called %d times", calls)
+	}
+}
+
+// lineCursor is a cursor decoding readings from the lines of a reader.
+type lineCursor struct {
+	scanner *bufio.Scanner
+	current reading
+	err     error
+}
+
+func (c *lineCursor) Next() bool {
+	if c.err != nil || !c.scanner.Scan() {
+		return false
+	}
+	sensor, value, _ := strings.Cut(c.scanner.Text(), " ")
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		c.err = err
+		return false
+	}
+	c.current = reading{sensor, v}
+	return true
+}
+
+func (c *lineCursor) Value() reading { return c.current }
+
+func (c *lineCursor) Err() error {
+	if c.err != nil {
+		return c.err
+	}
+	return c.scanner.Err()
+}
+
+func TestStreamCursor(t *testing.T) {
+	cursor := &lineCursor{scanner: bufio.NewScanner(strings.NewReader("a 1\nb 2\nc 3\n"))}
+	stream, err := NewStream(cursor, "Value", "Sensor")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	stream.BatchSize = 2
+	buf := &bytes.Buffer{}
+	err = stream.Dump(DelimitedDumper{Writer: buf, Comma: ';'},
+		DelimitedDumper{Writer: buf, Comma: ';', OmitHeader: true}, DefaultFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "Value;Sensor\n1;a\n2;b\n3;c\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	cursor = &lineCursor{scanner: bufio.NewScanner(strings.NewReader("a 1\nb x\nc 3\n"))}
+	stream, _ = NewStream(cursor, "Value")
+	buf.Reset()
+	err = stream.Dump(JSONLinesDumper{Writer: buf}, nil, DefaultFormat)
+	if _, ok := err.(*strconv.NumError); !ok || buf.String() != "{\"Value\":1}\n" {
+		t.Errorf("Got error %v and %q", err, buf)
+	}
+
+	for _, source := range []interface{}{func(i int) reading { return reading{} },
+		func(i int) (reading, error) { return reading{}, nil }, lineCursor{}} {
+		if _, err := NewStream(source, "Value"); err == nil {
+			t.Errorf("Missing error for %T", source)
+		}
+	}
+}
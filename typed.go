@@ -0,0 +1,81 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// TypedExtractor is an Extractor for data of type []T which can be
+// rebound to other []T without type assertions.
+type TypedExtractor[T any] struct {
+	*Extractor
+}
+
+// NewTypedExtractor returns an Extractor for the given column specifications
+// of data like NewExtractor.
+func NewTypedExtractor[T any](data []T, columnSpecs ...string) (*TypedExtractor[T], error) {
+	e, err := NewExtractor(data, columnSpecs...)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedExtractor[T]{e}, nil
+}
+
+// Bind (re)binds e to data.
+func (e *TypedExtractor[T]) Bind(data []T) { e.Extractor.Bind(data) }
+
+// ColumnOf returns the values of the column name of e as a []V where V (or
+// its element type if V is a pointer type) must be a Go type of the Type
+// of the column, e.g. ColumnOf[float64](e, "Price") or
+// ColumnOf[*int](e, "Count"). NA values are nil for pointer types and the
+// zero value otherwise.
+func ColumnOf[V any](e *Extractor, name string) ([]V, error) {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		return nil, err
+	}
+	field := e.Columns[idx[0]]
+	target := reflect.TypeOf((*V)(nil)).Elem()
+	elem := target
+	if target.Kind() == reflect.Ptr {
+		elem = target.Elem()
+	}
+	canon := canonicalTypes[field.Type()]
+	if canon == nil || superType(elem) != field.Type() || !canon.ConvertibleTo(elem) {
+		return nil, fmt.Errorf("export: cannot convert %s column %s to %s", field.Type(), name, target)
+	}
+
+	values := make([]V, e.N)
+	for r := range values {
+		v := field.value(r)
+		if v == nil {
+			continue
+		}
+		rv := reflect.ValueOf(v).Convert(elem)
+		if target.Kind() == reflect.Ptr {
+			p := reflect.New(elem)
+			p.Elem().Set(rv)
+			rv = p
+		}
+		values[r] = rv.Interface().(V)
+	}
+	return values, nil
+}
+
+// canonicalTypes are the Go types of the canonical values of the types.
+var canonicalTypes = map[Type]reflect.Type{
+	Bool:     reflect.TypeOf(false),
+	Int:      reflect.TypeOf(int64(0)),
+	Uint:     reflect.TypeOf(uint64(0)),
+	Float:    reflect.TypeOf(0.0),
+	Complex:  reflect.TypeOf(complex128(0)),
+	String:   reflect.TypeOf(""),
+	Time:     reflect.TypeOf(time.Time{}),
+	Duration: reflect.TypeOf(time.Duration(0)),
+	Bytes:    reflect.TypeOf([]byte(nil)),
+}
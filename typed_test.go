@@ -0,0 +1,52 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTypedExtractor(t *testing.T) {
+	two := 2
+	data := []gem{
+		{Cut: "Ideal", Price: 326, Carat: 0.25, X: &two, Held: time.Hour},
+		{Cut: "Good", Price: 500, Carat: 0.5},
+	}
+	extractor, err := NewTypedExtractor(data, "Cut", "Price", "Carat", "X", "Held")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	carats, err := ColumnOf[float64](extractor.Extractor, "Carat")
+	if err != nil || !reflect.DeepEqual(carats, []float64{0.25, 0.5}) {
+		t.Errorf("Got %v, %v", carats, err)
+	}
+	xs, err := ColumnOf[*int](extractor.Extractor, "X")
+	if err != nil || len(xs) != 2 || *xs[0] != 2 || xs[1] != nil {
+		t.Errorf("Got %v, %v", xs, err)
+	}
+	held, err := ColumnOf[time.Duration](extractor.Extractor, "Held")
+	if err != nil || held[0] != time.Hour {
+		t.Errorf("Got %v, %v", held, err)
+	}
+
+	extractor.Bind(data[1:])
+	prices, err := ColumnOf[int32](extractor.Extractor, "Price")
+	if err != nil || !reflect.DeepEqual(prices, []int32{500}) {
+		t.Errorf("Got %v, %v", prices, err)
+	}
+
+	if _, err := ColumnOf[string](extractor.Extractor, "Price"); err == nil {
+		t.Errorf("Missing error for Int as string")
+	}
+	if _, err := ColumnOf[int](extractor.Extractor, "Carat"); err == nil {
+		t.Errorf("Missing error for Float as int")
+	}
+	if _, err := ColumnOf[int](extractor.Extractor, "Weight"); err == nil {
+		t.Errorf("Missing error for unknown column")
+	}
+}
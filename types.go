@@ -0,0 +1,184 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+)
+
+// typeConverter converts values of a registered Go type.
+type typeConverter struct {
+	typ     Type
+	convert func(v interface{}) interface{}
+}
+
+// typeConverters contains the registered types. The nullable types of
+// package database/sql are predefined and yield NA if not Valid.
+var typeConverters = map[reflect.Type]typeConverter{
+	reflect.TypeOf(sql.NullBool{}): {Bool, func(v interface{}) interface{} {
+		if n := v.(sql.NullBool); n.Valid {
+			return n.Bool
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullByte{}): {Int, func(v interface{}) interface{} {
+		if n := v.(sql.NullByte); n.Valid {
+			return int64(n.Byte)
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullInt16{}): {Int, func(v interface{}) interface{} {
+		if n := v.(sql.NullInt16); n.Valid {
+			return int64(n.Int16)
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullInt32{}): {Int, func(v interface{}) interface{} {
+		if n := v.(sql.NullInt32); n.Valid {
+			return int64(n.Int32)
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullInt64{}): {Int, func(v interface{}) interface{} {
+		if n := v.(sql.NullInt64); n.Valid {
+			return n.Int64
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullFloat64{}): {Float, func(v interface{}) interface{} {
+		if n := v.(sql.NullFloat64); n.Valid {
+			return n.Float64
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullString{}): {String, func(v interface{}) interface{} {
+		if n := v.(sql.NullString); n.Valid {
+			return n.String
+		}
+		return nil
+	}},
+	reflect.TypeOf(sql.NullTime{}): {Time, func(v interface{}) interface{} {
+		if n := v.(sql.NullTime); n.Valid {
+			return n.Time
+		}
+		return nil
+	}},
+}
+
+// RegisterType makes columns of the Go type typ (which must not be a
+// pointer type) available as columns of type t. The function convert is
+// called with the field or method result of type typ and must return the
+// canonical representation of the value for t: a bool, int64, float64,
+// complex128, string, time.Time, time.Duration, []byte or uint64, or nil
+// for NA.
+// E.g.
+//
+//	export.RegisterType(reflect.TypeOf(net.IP{}), export.String,
+//		func(v interface{}) interface{} { return v.(net.IP).String() })
+//
+// A registered type takes precedence over the builtin handling of typ,
+// e.g. over using its String method. Registering a nil convert removes
+// typ. RegisterType affects Extractors constructed afterwards only.
+func RegisterType(typ reflect.Type, t Type, convert func(v interface{}) interface{}) {
+	if t == NA || t > Uint {
+		panic(fmt.Sprintf("export: cannot register %s as type %s", typ, t))
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if convert == nil {
+		delete(typeConverters, typ)
+		return
+	}
+	typeConverters[typ] = typeConverter{typ: t, convert: convert}
+}
+
+// lookupType returns the converter registered for typ.
+func lookupType(typ reflect.Type) (typeConverter, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := typeConverters[typ]
+	return c, ok
+}
+
+// boolType is the reflect.Type of bool.
+var boolType = reflect.TypeOf(true)
+
+// nullableStep returns a converting step and the type of the boxed value if
+// typ is a generic option type like sql.Null[T]. Such types report the
+// presence of a value in a bool field Valid, a method Valid() bool or a
+// method IsZero() bool (reporting absence) and provide the value in a field
+// V or Value or a method Value(). An absent value or a nil pointer as value
+// results in NA.
+func nullableStep(typ reflect.Type) (step, reflect.Type, bool) {
+	if typ.Kind() == reflect.Interface {
+		return step{}, nil, false
+	}
+	var valid func(v reflect.Value) bool
+	if f, ok := fieldByName(typ, "Valid"); ok && f.IsExported() && f.Type == boolType {
+		valid = func(v reflect.Value) bool { return v.FieldByIndex(f.Index).Bool() }
+	} else if m, ok := typ.MethodByName("Valid"); ok && returnsOne(m.Type, boolType) {
+		valid = func(v reflect.Value) bool { return m.Func.Call([]reflect.Value{v})[0].Bool() }
+	} else if m, ok := typ.MethodByName("IsZero"); ok && returnsOne(m.Type, boolType) {
+		valid = func(v reflect.Value) bool { return !m.Func.Call([]reflect.Value{v})[0].Bool() }
+	} else {
+		return step{}, nil, false
+	}
+
+	var value func(v reflect.Value) reflect.Value
+	var elem reflect.Type
+	f, ok := fieldByName(typ, "V")
+	if !ok {
+		f, ok = fieldByName(typ, "Value")
+	}
+	if ok && f.IsExported() {
+		value = func(v reflect.Value) reflect.Value { return v.FieldByIndex(f.Index) }
+		elem = f.Type
+	} else if m, ok := typ.MethodByName("Value"); ok && returnsOne(m.Type, nil) {
+		value = func(v reflect.Value) reflect.Value { return m.Func.Call([]reflect.Value{v})[0] }
+		elem = m.Type.Out(0)
+	} else {
+		return step{}, nil, false
+	}
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	s := step{
+		name: "Value",
+		convert: func(v interface{}) (interface{}, error) {
+			x := reflect.ValueOf(v)
+			if !valid(x) {
+				return nil, nil
+			}
+			x = value(x)
+			for x.Kind() == reflect.Ptr {
+				if x.IsNil() {
+					return nil, nil
+				}
+				x = x.Elem()
+			}
+			return x.Interface(), nil
+		},
+	}
+	return s, elem, true
+}
+
+// fieldByName is reflect.Type.FieldByName which reports false on non-struct
+// types instead of panicking.
+func fieldByName(typ reflect.Type, name string) (reflect.StructField, bool) {
+	if typ.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+	return typ.FieldByName(name)
+}
+
+// returnsOne reports whether the method type mt takes no arguments besides
+// the receiver and returns exactly one value of type out (or any type if
+// out is nil).
+func returnsOne(mt reflect.Type, out reflect.Type) bool {
+	return mt.NumIn() == 1 && mt.NumOut() == 1 && (out == nil || mt.Out(0) == out)
+}
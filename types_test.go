@@ -0,0 +1,146 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type ipv4 [4]byte
+
+type cents int64
+
+func TestRegisterType(t *testing.T) {
+	RegisterType(reflect.TypeOf(ipv4{}), String, func(v interface{}) interface{} {
+		ip := v.(ipv4)
+		if ip == (ipv4{}) {
+			return nil
+		}
+		return fmt.Sprintf("%d.%d.%d.%d", ip[0], ip[1], ip[2], ip[3])
+	})
+	RegisterType(reflect.TypeOf(cents(0)), Float, func(v interface{}) interface{} {
+		return float64(v.(cents)) / 100
+	})
+	defer RegisterType(reflect.TypeOf(ipv4{}), String, nil)
+	defer RegisterType(reflect.TypeOf(cents(0)), Float, nil)
+
+	type Host struct {
+		IP    ipv4
+		Price *cents
+	}
+	price := cents(1250)
+	data := []Host{{ipv4{10, 0, 0, 1}, &price}, {ipv4{}, nil}}
+	extractor, err := NewExtractor(data, "IP", "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if typ := extractor.Columns[1].Type(); typ != Float {
+		t.Errorf("Got type %s for Price", typ)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, PreciseFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `{"IP":"10.0.0.1","Price":12.5}
+{"IP":null,"Price":null}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+
+	RegisterType(reflect.TypeOf(ipv4{}), String, nil)
+	if _, err := NewExtractor(data, "IP"); err == nil {
+		t.Errorf("Missing error for unregistered type")
+	}
+}
+
+func TestSQLNullTypes(t *testing.T) {
+	type Row struct {
+		ID    sql.NullInt32
+		Name  sql.NullString
+		Score *sql.NullFloat64
+		Seen  sql.NullTime
+		OK    sql.NullBool
+	}
+	seen := time.Date(2024, 3, 5, 12, 0, 0, 0, time.UTC)
+	score := sql.NullFloat64{Float64: 2.5, Valid: true}
+	data := []Row{
+		{sql.NullInt32{Int32: 7, Valid: true}, sql.NullString{String: "Go", Valid: true},
+			&score, sql.NullTime{Time: seen, Valid: true}, sql.NullBool{Bool: true, Valid: true}},
+		{sql.NullInt32{Int32: 7}, sql.NullString{String: "Go"},
+			nil, sql.NullTime{Time: seen}, sql.NullBool{Bool: true}},
+	}
+	extractor, err := NewExtractor(data, "ID", "Name", "Score", "Seen", "OK")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for c, want := range []Type{Int, String, Float, Time, Bool} {
+		if typ := extractor.Columns[c].Type(); typ != want {
+			t.Errorf("Column %d: Got type %s, want %s", c, typ, want)
+		}
+	}
+
+	format := PreciseFormat
+	format.TimeLoc = time.UTC
+	buf := &bytes.Buffer{}
+	if err := (JSONLinesDumper{Writer: buf}).Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `{"ID":7,"Name":"Go","Score":2.5,"Seen":"2024-03-05T12:00:00Z","OK":true}
+{"ID":null,"Name":null,"Score":null,"Seen":null,"OK":null}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got %s, want %s", got, want)
+	}
+}
+
+// opt is an option type with the IsZero and Value() convention.
+type opt[T any] struct {
+	set bool
+	v   T
+}
+
+func (o opt[T]) IsZero() bool { return !o.set }
+func (o opt[T]) Value() T     { return o.v }
+
+func TestNullableBoxes(t *testing.T) {
+	type Row struct {
+		N sql.Null[int]
+		D sql.Null[*time.Duration]
+		S opt[string]
+	}
+	d := 3 * time.Second
+	data := []Row{
+		{sql.Null[int]{V: 4, Valid: true}, sql.Null[*time.Duration]{V: &d, Valid: true}, opt[string]{true, "Go"}},
+		{sql.Null[int]{V: 4}, sql.Null[*time.Duration]{Valid: true}, opt[string]{false, "Go"}},
+	}
+	extractor, err := NewExtractor(data, "N", "D", "S")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for c, want := range []Type{Int, Duration, String} {
+		if typ := extractor.Columns[c].Type(); typ != want {
+			t.Errorf("Column %d: Got type %s, want %s", c, typ, want)
+		}
+	}
+
+	buf := &bytes.Buffer{}
+	errs, err := DumpErrors(DelimitedDumper{Writer: buf}, extractor, DefaultFormat)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if got, want := buf.String(), "N,D,S\n4,3s,Go\n,,\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if len(errs) != 0 {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import "fmt"
+
+// unit is a unit of measurement known to ConvertUnit.
+type unit struct {
+	dimension string  // dimension is the quantity measured.
+	factor    float64 // factor is the size of the unit in the base unit.
+}
+
+// units are the units known to ConvertUnit. The base units are bytes,
+// seconds and meters.
+var units = map[string]unit{
+	"B":   {"size", 1},
+	"kB":  {"size", 1e3},
+	"MB":  {"size", 1e6},
+	"GB":  {"size", 1e9},
+	"TB":  {"size", 1e12},
+	"KiB": {"size", 1 << 10},
+	"MiB": {"size", 1 << 20},
+	"GiB": {"size", 1 << 30},
+	"TiB": {"size", 1 << 40},
+
+	"ns":  {"time", 1e-9},
+	"us":  {"time", 1e-6},
+	"ms":  {"time", 1e-3},
+	"s":   {"time", 1},
+	"min": {"time", 60},
+	"h":   {"time", 3600},
+	"d":   {"time", 86400},
+
+	"mm": {"length", 1e-3},
+	"cm": {"length", 1e-2},
+	"m":  {"length", 1},
+	"km": {"length", 1e3},
+}
+
+// ConvertUnit converts the values of the column name from its Unit to the
+// unit to and sets its Unit. The converted values are Floats, e.g. the
+// values of an Int column with Unit "B" are converted to fractional
+// mebibytes with to "MiB". Duration columns without a Unit are converted
+// from nanoseconds. The conversion is kept if e is rebound.
+//
+// Known units are B, kB, MB, GB, TB, KiB, MiB, GiB and TiB for sizes,
+// ns, us, ms, s, min, h and d for times and mm, cm, m and km for lengths.
+// ConvertUnit returns an error if the units are unknown or measure
+// different quantities or if the column cannot be coerced (see Coerce).
+func (e *Extractor) ConvertUnit(name, to string) error {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		return err
+	}
+	field := &e.Columns[idx[0]]
+	unitName := field.Unit
+	switch field.Type() {
+	case Int, Uint, Float:
+	case Duration:
+		if unitName == "" {
+			unitName = "ns"
+		}
+	default:
+		return fmt.Errorf("export: cannot convert units of %s column %s", field.Type(), name)
+	}
+	from, ok := units[unitName]
+	if !ok {
+		return fmt.Errorf("export: unknown unit %q of column %s", unitName, name)
+	}
+	u, ok := units[to]
+	if !ok {
+		return fmt.Errorf("export: unknown unit %q", to)
+	}
+	if from.dimension != u.dimension {
+		return fmt.Errorf("export: cannot convert %s to %s", unitName, to)
+	}
+	factor := from.factor / u.factor
+	conv := func(v interface{}) (interface{}, error) { return toFloat(v) * factor, nil }
+	if err := e.convertColumn(field, Float, conv); err != nil {
+		return err
+	}
+	field.Unit = to
+	return nil
+}
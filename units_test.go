@@ -0,0 +1,57 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+type transfer struct {
+	Size    int64
+	Elapsed time.Duration
+	Length  float64
+	Name    string
+}
+
+func TestConvertUnit(t *testing.T) {
+	data := []transfer{{3 << 20, 1500 * time.Millisecond, 2500, "a"}}
+	extractor, err := NewExtractor(data, "Size", "Elapsed", "Length", "Name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.Columns[0].Unit = "B"
+	extractor.Columns[2].Unit = "m"
+	for _, c := range [][2]string{{"Size", "MiB"}, {"Elapsed", "ms"}, {"Length", "km"}} {
+		if err := extractor.ConvertUnit(c[0], c[1]); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	extractor.UnitsInHeader = true
+	buf := &bytes.Buffer{}
+	if err := (DelimitedDumper{Writer: buf}).Dump(extractor, DefaultFormat); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := "Size [MiB],Elapsed [ms],Length [km],Name\n3,1500,2.5,a\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+
+	// Conversions are kept on rebinding.
+	extractor.Bind([]transfer{{1 << 19, time.Second, 100, "b"}})
+	for i, want := range []interface{}{0.5, 1000.0, 0.1, "b"} {
+		if got := extractor.Columns[i].Value(0); got != want {
+			t.Errorf("Column %d: got %v, want %v", i, got, want)
+		}
+	}
+
+	for i, c := range [][2]string{{"Size", "s"}, {"Length", "parsec"},
+		{"Name", "m"}, {"Unknown", "m"}} {
+		if err := extractor.ConvertUnit(c[0], c[1]); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Rule is a constraint on the values of a column checked by Validate.
+type Rule struct {
+	Column string // Column is the name of the constrained column.
+	Name   string // Name describes the rule in violations, e.g. "not NA".
+
+	// ok reports whether the value v satisfies the rule; prev is the
+	// previous non-NA value of the column or nil. NA values are passed
+	// only if na is set.
+	ok    func(v, prev interface{}) bool
+	na    bool
+	types []Type // types are the admissible column types, nil for all
+}
+
+// NotNA returns a Rule requiring the values of column to be non-NA (NaNs
+// are NA).
+func NotNA(column string) Rule {
+	return Rule{Column: column, Name: "not NA", na: true,
+		ok: func(v, _ interface{}) bool { return !isNA(v) }}
+}
+
+// InRange returns a Rule requiring the values of the Int, Uint or Float
+// column to be between min and max (inclusive). NA values are ignored.
+func InRange(column string, min, max float64) Rule {
+	return Rule{Column: column, Name: fmt.Sprintf("in [%g,%g]", min, max),
+		types: []Type{Int, Uint, Float},
+		ok: func(v, _ interface{}) bool {
+			x := toFloat(v)
+			return x >= min && x <= max
+		}}
+}
+
+// Matches returns a Rule requiring the values of the String column to
+// match re. NA values are ignored.
+func Matches(column string, re *regexp.Regexp) Rule {
+	return Rule{Column: column, Name: "matches " + re.String(),
+		types: []Type{String},
+		ok:    func(v, _ interface{}) bool { return re.MatchString(v.(string)) }}
+}
+
+// Increasing returns a Rule requiring the values of column, e.g. the
+// timestamps of a time series, to be monotonically increasing: Each value
+// must not be less than (or if strict, must be greater than) the previous
+// non-NA value. NA values are ignored. Complex columns cannot be used.
+func Increasing(column string, strict bool) Rule {
+	name := "non-decreasing"
+	if strict {
+		name = "increasing"
+	}
+	return Rule{Column: column, Name: name,
+		types: []Type{Bool, Int, Uint, Float, String, Time, Duration, Bytes},
+		ok: func(v, prev interface{}) bool {
+			if prev == nil {
+				return true
+			}
+			c := compareValues(v, prev)
+			return c > 0 || c == 0 && !strict
+		}}
+}
+
+// Check returns a Rule with the given name requiring ok to report true for
+// the non-NA values of column. The values are passed as by Column.Value.
+func Check(column, name string, ok func(v interface{}) bool) Rule {
+	return Rule{Column: column, Name: name,
+		ok: func(v, _ interface{}) bool { return ok(v) }}
+}
+
+// Violation describes a value violating a Rule.
+type Violation struct {
+	Row    int         // Row is the index of the row in the bound data.
+	Column string      // Column is the name of the column.
+	Rule   string      // Rule is the name of the violated rule.
+	Value  interface{} // Value is the violating value as by Column.Value.
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("row %d, column %s: %v violates %s", v.Row, v.Column, v.Value, v.Rule)
+}
+
+// Validate checks the values of e against the rules and returns all
+// violations ordered by rule and row. It returns an error if a rule names
+// an unknown column or cannot be applied to the type of its column.
+func (e *Extractor) Validate(rules ...Rule) ([]Violation, error) {
+	fields := make([]Column, len(rules))
+	for i, rule := range rules {
+		idx, err := e.columnIndices([]string{rule.Column})
+		if err != nil {
+			return nil, err
+		}
+		fields[i] = e.Columns[idx[0]]
+		admissible := rule.types == nil
+		for _, t := range rule.types {
+			admissible = admissible || t == fields[i].Type()
+		}
+		if !admissible {
+			return nil, fmt.Errorf("export: rule %s cannot be applied to %s column %s",
+				rule.Name, fields[i].Type(), rule.Column)
+		}
+	}
+
+	var violations []Violation
+	for i, rule := range rules {
+		var prev interface{}
+		for r := 0; r < e.N; r++ {
+			v := fields[i].value(r)
+			if isNA(v) && !rule.na {
+				continue
+			}
+			if !rule.ok(v, prev) {
+				violations = append(violations, Violation{
+					Row:    r,
+					Column: rule.Column,
+					Rule:   rule.Name,
+					Value:  v,
+				})
+			}
+			if !isNA(v) {
+				prev = v
+			}
+		}
+	}
+	return violations, nil
+}
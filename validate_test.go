@@ -0,0 +1,63 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	one := 1
+	data := []gem{
+		{Cut: "Ideal", Color: "E", Price: 300, X: &one, Bought: time1},
+		{Cut: "good", Color: "J", Price: -5, Bought: time1},
+		{Cut: "Fair", Color: "DD", Price: 9000, X: &one, Bought: time1.Add(-time.Hour)},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Color", "Price", "X", "Bought")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	violations, err := extractor.Validate(
+		NotNA("X"),
+		InRange("Price", 0, 5000),
+		Matches("Color", regexp.MustCompile(`^[D-J]$`)),
+		Increasing("Bought", false),
+		Check("Cut", "capitalized", func(v interface{}) bool {
+			s := v.(string)
+			return strings.ToUpper(s[:1]) == s[:1]
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := []string{
+		"row 1, column X: <nil> violates not NA",
+		"row 1, column Price: -5 violates in [0,5000]",
+		"row 2, column Price: 9000 violates in [0,5000]",
+		"row 2, column Color: DD violates matches ^[D-J]$",
+		"row 2, column Bought: " + time1.Add(-time.Hour).String() + " violates non-decreasing",
+		"row 1, column Cut: good violates capitalized",
+	}
+	if len(violations) != len(want) {
+		t.Fatalf("Got %d violations %v, want %d", len(violations), violations, len(want))
+	}
+	for i, v := range violations {
+		if got := v.String(); got != want[i] {
+			t.Errorf("%d: Got %q, want %q", i, got, want[i])
+		}
+	}
+
+	if v, _ := extractor.Validate(Increasing("Bought", true)); len(v) != 2 {
+		t.Errorf("Got %v", v)
+	}
+	for i, rule := range []Rule{InRange("Cut", 0, 1), Matches("Price", regexp.MustCompile("")), NotNA("Weight")} {
+		if _, err := extractor.Validate(rule); err == nil {
+			t.Errorf("%d: Missing error", i)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"fmt"
+	"math"
+)
+
+// column returns the column name of e; it panics if name does not denote
+// exactly one column.
+func (e *Extractor) column(name string) Column {
+	idx, err := e.columnIndices([]string{name})
+	if err != nil {
+		panic(err.Error())
+	}
+	return e.Columns[idx[0]]
+}
+
+// Value returns the value of column name in the given row like
+// Column.Value. It panics if name does not denote exactly one column or
+// if row is out of range.
+func (e *Extractor) Value(row int, name string) interface{} {
+	if row < 0 || row >= e.N {
+		panic(fmt.Sprintf("export: row %d out of range [0,%d)", row, e.N))
+	}
+	return e.column(name).value(row)
+}
+
+// IsNA reports whether the value of column name in the given row is NA.
+// It panics like Value.
+func (e *Extractor) IsNA(row int, name string) bool {
+	return e.Value(row, name) == nil
+}
+
+// Float returns the value of the Int, Uint or Float column name in the
+// given row as a float64; NA values are NaN. It panics like Value and if
+// the column is not numeric.
+func (e *Extractor) Float(row int, name string) float64 {
+	numericColumn(e.column(name))
+	return floatOrNaN(e.Value(row, name))
+}
+
+// Floats returns all values of the Int, Uint or Float column name like
+// Float.
+func (e *Extractor) Floats(name string) []float64 {
+	field := numericColumn(e.column(name))
+	values := make([]float64, e.N)
+	for r := range values {
+		values[r] = floatOrNaN(field.value(r))
+	}
+	return values
+}
+
+// Strings returns all values of column name formatted with DefaultFormat;
+// NA values are empty strings. Values of String columns are returned
+// unchanged. It panics if name does not denote exactly one column.
+func (e *Extractor) Strings(name string) []string {
+	field := e.column(name)
+	values := make([]string, e.N)
+	for r := range values {
+		v := field.value(r)
+		if s, ok := v.(string); ok {
+			values[r] = s
+		} else if v != nil {
+			values[r] = formatValue(DefaultFormat, field.Type(), v)
+		}
+	}
+	return values
+}
+
+// numericColumn returns c and panics if c is not an Int, Uint or Float
+// column.
+func numericColumn(c Column) Column {
+	if t := c.Type(); t != Int && t != Uint && t != Float {
+		panic(fmt.Sprintf("export: column %s of type %s is not numeric", c.Name, t))
+	}
+	return c
+}
+
+// floatOrNaN returns the numeric canonical value v as float64 or NaN if v
+// is NA.
+func floatOrNaN(v interface{}) float64 {
+	if v == nil {
+		return math.NaN()
+	}
+	return toFloat(v)
+}
@@ -0,0 +1,62 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestValueAccess(t *testing.T) {
+	two := 2
+	data := []gem{
+		{Cut: "Ideal", Price: 326, Carat: 0.25, X: &two, Held: time.Hour},
+		{Cut: "Good", Price: 500, Carat: 0.5},
+	}
+	extractor, err := NewExtractor(data, "Cut", "Price", "Carat", "X", "Held")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if got := extractor.Value(0, "Price"); got != int64(326) {
+		t.Errorf("Got %#v", got)
+	}
+	if got := extractor.Columns[4].Value(0); got != time.Hour {
+		t.Errorf("Got %#v", got)
+	}
+	if !extractor.IsNA(1, "X") || extractor.IsNA(0, "X") {
+		t.Errorf("Wrong NA")
+	}
+	if got := extractor.Float(1, "Carat"); got != 0.5 {
+		t.Errorf("Got %v", got)
+	}
+	if xs := extractor.Floats("X"); xs[0] != 2 || !math.IsNaN(xs[1]) {
+		t.Errorf("Got %v", xs)
+	}
+	if got, want := extractor.Strings("Cut"), []string{"Ideal", "Good"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v", got)
+	}
+	if got, want := extractor.Strings("X"), []string{"2", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Got %v", got)
+	}
+
+	for i, f := range []func(){
+		func() { extractor.Value(2, "Price") },
+		func() { extractor.Value(0, "Weight") },
+		func() { extractor.Float(0, "Cut") },
+		func() { extractor.Floats("Held") },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: Missing panic", i)
+				}
+			}()
+			f()
+		}()
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// VegaLiteDumper dumps the values as the inline data of a Vega-Lite
+// specification which can be rendered e.g. in the Vega online editor or
+// with vega-embed in a browser.
+//
+// The encoding channels X, Y, Color and Size name the column to use for
+// the channel; empty channels are omitted. The data type of a channel is
+// quantitative for Int, Float and Duration columns, temporal for Time
+// columns and nominal otherwise. Durations are quantitative only for a
+// format producing numbers, e.g. with DurationFmt "%d".
+type VegaLiteDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+
+	Title string // Title of the plot, may be empty.
+	Mark  string // Mark is the type of mark, defaults to "point".
+
+	X, Y, Color, Size string // Column names to use for the encoding channels.
+}
+
+// Dump implements the Dump method of a Dumper.
+// The values are represented like in JSONDumper.
+func (d VegaLiteDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	mark := d.Mark
+	if mark == "" {
+		mark = "point"
+	}
+	var encoding []string
+	for _, channel := range []struct{ name, column string }{
+		{"x", d.X}, {"y", d.Y}, {"color", d.Color}, {"size", d.Size},
+	} {
+		if channel.column == "" {
+			continue
+		}
+		typ := Type(NA)
+		for _, field := range e.Columns {
+			if field.Name == channel.column {
+				typ = field.Type()
+				break
+			}
+		}
+		if typ == NA {
+			return fmt.Errorf("export: no column %q for channel %s", channel.column, channel.name)
+		}
+		encoding = append(encoding, fmt.Sprintf("%q:{\"field\":%s,\"type\":%q}",
+			channel.name, jsonQuote(vegaField(channel.column)), vegaType(typ)))
+	}
+
+	w := bufio.NewWriter(d.Writer)
+	w.WriteString("{\n\"$schema\":\"https://vega.github.io/schema/vega-lite/v5.json\",\n")
+	if d.Title != "" {
+		w.WriteString("\"title\":" + jsonQuote(d.Title) + ",\n")
+	}
+	w.WriteString("\"data\":{\"values\":[")
+	f := jsonFormat{format}
+	keys := jsonKeys(e)
+	sep := "\n"
+	for r := 0; r < e.N; r++ {
+		w.WriteString(sep + jsonObject(e, keys, f, r))
+		sep = ",\n"
+		e.progress(r, r+1)
+	}
+	w.WriteString("\n]},\n\"mark\":" + jsonQuote(mark) + ",\n")
+	w.WriteString("\"encoding\":{" + strings.Join(encoding, ",") + "}\n}\n")
+	return w.Flush()
+}
+
+// vegaField escapes the characters in name which Vega-Lite would
+// interpret as access to nested fields.
+func vegaField(name string) string {
+	return strings.NewReplacer(`\`, `\\`, ".", `\.`, "[", `\[`, "]", `\]`).Replace(name)
+}
+
+// vegaType returns the Vega-Lite data type for a column of type t.
+func vegaType(t Type) string {
+	switch t {
+	case Int, Uint, Float, Duration:
+		return "quantitative"
+	case Time:
+		return "temporal"
+	}
+	return "nominal"
+}
@@ -0,0 +1,58 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestVegaLiteDumper(t *testing.T) {
+	type Inner struct{ Name string }
+	data := []struct {
+		X float64
+		T time.Time
+		I Inner
+	}{
+		{1.5, time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC), Inner{"a"}},
+		{2.5, time.Date(2001, 1, 2, 3, 4, 5, 0, time.UTC), Inner{"b"}},
+	}
+	extractor, err := NewExtractor(data, "X", "T", "I.Name")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	buf := &bytes.Buffer{}
+	d := VegaLiteDumper{Writer: buf, Title: "Test", Mark: "line", X: "T", Y: "X", Color: "I.Name"}
+	if err := d.Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `{
+"$schema":"https://vega.github.io/schema/vega-lite/v5.json",
+"title":"Test",
+"data":{"values":[
+{"X":1.5,"T":"2000-01-02T03:04:05","I.Name":"a"},
+{"X":2.5,"T":"2001-01-02T03:04:05","I.Name":"b"}
+]},
+"mark":"line",
+"encoding":{"x":{"field":"T","type":"temporal"},"y":{"field":"X","type":"quantitative"},"color":{"field":"I\\.Name","type":"nominal"}}
+}
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Errorf("Invalid JSON")
+	}
+
+	d = VegaLiteDumper{Writer: buf, X: "Nope"}
+	if err := d.Dump(extractor, format); err == nil {
+		t.Errorf("Missing error for unknown column")
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// view returns an Extractor with n rows whose i'th row is the row index(i)
+// of e. The returned Extractor reads the values from e and cannot be
+// rebound; it becomes invalid if e is rebound. Failures are reported to
+// e with the rows of e.
+func (e *Extractor) view(n int, index func(i int) int) *Extractor {
+	v := &Extractor{N: n, Columns: make([]Column, len(e.Columns)), prepared: e.prepared}
+	if report := e.report; report != nil {
+		v.report = func(re *RowError) {
+			re.Row = index(re.Row)
+			report(re)
+		}
+	}
+	for c, field := range e.Columns {
+		value := field.value
+		field.value = func(i int) interface{} { return value(index(i)) }
+		if fail := field.fail; fail != nil {
+			field.fail = func(i int) error { return fail(index(i)) }
+		}
+		v.Columns[c] = field
+	}
+	return v
+}
+
+// window returns a view of the rows start to end-1 of e.
+func (e *Extractor) window(start, end int) *Extractor {
+	return e.view(end-start, func(i int) int { return start + i })
+}
+
+// cached returns an Extractor with the values of e extracted once into
+// memory. Like a view it cannot be rebound.
+func (e *Extractor) cached() *Extractor {
+	values := make([][]interface{}, len(e.Columns))
+	for i := range values {
+		values[i] = make([]interface{}, e.N)
+	}
+	for r := 0; r < e.N; r++ {
+		for i, field := range e.Columns {
+			values[i][r] = field.value(r)
+		}
+		e.progress(r, r+1)
+	}
+	c := &Extractor{N: e.N, Columns: make([]Column, len(e.Columns)), prepared: e.prepared, report: e.report}
+	for i, field := range e.Columns {
+		column := values[i]
+		field.value = func(r int) interface{} { return column[r] }
+		c.Columns[i] = field
+	}
+	return c
+}
+
+// inherit sets the error policy, progress reporting and workers of the
+// view v to the ones of e and returns v.
+func (e *Extractor) inherit(v *Extractor) *Extractor {
+	v.OnError = e.OnError
+	v.Progress, v.ProgressInterval = e.Progress, e.ProgressInterval
+	v.Workers = e.Workers
+	v.memos = e.memos
+	return v
+}
+
+// subset returns a view of the given rows of e.
+func (e *Extractor) subset(rows []int) *Extractor {
+	return e.inherit(e.view(len(rows), func(i int) int { return rows[i] }))
+}
+
+// Slice returns a view of the rows from to to-1 of e; the bounds are
+// clipped to the rows of e. The view reads its values from e, cannot be
+// rebound and becomes invalid if e is rebound.
+func (e *Extractor) Slice(from, to int) *Extractor {
+	if from < 0 {
+		from = 0
+	}
+	if to > e.N {
+		to = e.N
+	}
+	if to < from {
+		to = from
+	}
+	return e.inherit(e.window(from, to))
+}
+
+// Head returns a view of the first n rows of e like Slice.
+func (e *Extractor) Head(n int) *Extractor { return e.Slice(0, n) }
+
+// Tail returns a view of the last n rows of e like Slice.
+func (e *Extractor) Tail(n int) *Extractor { return e.Slice(e.N-n, e.N) }
+
+// SampleRandom returns a view of n rows of e chosen uniformly at random
+// without replacement (all rows if n >= e.N) in their original order. The
+// same seed selects the same rows. Only the indices of the chosen rows are
+// kept in memory.
+func (e *Extractor) SampleRandom(n int, seed int64) *Extractor {
+	if n > e.N {
+		n = e.N
+	}
+	if n < 0 {
+		n = 0
+	}
+	// Robert Floyd's algorithm draws n distinct rows with n random numbers.
+	rng := rand.New(rand.NewSource(seed))
+	chosen := make(map[int]bool, n)
+	rows := make([]int, 0, n)
+	for j := e.N - n; j < e.N; j++ {
+		r := rng.Intn(j + 1)
+		if chosen[r] {
+			r = j
+		}
+		chosen[r] = true
+		rows = append(rows, r)
+	}
+	sort.Ints(rows)
+	return e.subset(rows)
+}
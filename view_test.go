@@ -0,0 +1,87 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestRowWindows(t *testing.T) {
+	data := make([]gem, 10)
+	for i := range data {
+		data[i].Price = i
+	}
+	extractor, err := NewExtractor(data, "Price")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	rows := func(e *Extractor) []int64 {
+		var r []int64
+		for i := 0; i < e.N; i++ {
+			r = append(r, e.Columns[0].value(i).(int64))
+		}
+		return r
+	}
+	for i, tc := range []struct {
+		view *Extractor
+		want string
+	}{
+		{extractor.Head(3), "[0 1 2]"},
+		{extractor.Head(20), "[0 1 2 3 4 5 6 7 8 9]"},
+		{extractor.Tail(2), "[8 9]"},
+		{extractor.Slice(4, 6), "[4 5]"},
+		{extractor.Slice(-3, 1), "[0]"},
+		{extractor.Slice(7, 3), "[]"},
+		{extractor.SampleRandom(0, 1), "[]"},
+		{extractor.SampleRandom(12, 1), "[0 1 2 3 4 5 6 7 8 9]"},
+	} {
+		if got := fmt.Sprint(rows(tc.view)); got != tc.want {
+			t.Errorf("%d: Got %s, want %s", i, got, tc.want)
+		}
+	}
+
+	a, b := rows(extractor.SampleRandom(4, 7)), rows(extractor.SampleRandom(4, 7))
+	if fmt.Sprint(a) != fmt.Sprint(b) || len(a) != 4 {
+		t.Errorf("Got samples %v and %v", a, b)
+	}
+	for i := 1; i < len(a); i++ {
+		if a[i] <= a[i-1] {
+			t.Errorf("Sample %v not in original order", a)
+		}
+	}
+}
+
+func TestDumpRange(t *testing.T) {
+	type value struct{ V int }
+	data := []value{{10}, {11}, {12}, {13}, {14}}
+	extractor, err := NewExtractor(data, "V")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	extractor.AddIndexColumn("Row", 0)
+	var done []int
+	extractor.Progress = func(rowsDone, totalRows int) {
+		if totalRows != len(data) {
+			t.Errorf("Got total %d", totalRows)
+		}
+		done = append(done, rowsDone)
+	}
+	buf := &bytes.Buffer{}
+	dumper := DelimitedDumper{Writer: buf}
+	for from := -1; from < extractor.N; from += 2 {
+		if err := DumpRange(dumper, extractor, from, from+2, DefaultFormat); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		dumper.OmitHeader = true
+	}
+	if got, want := buf.String(), "Row,V\n0,10\n1,11\n2,12\n3,13\n4,14\n"; got != want {
+		t.Errorf("Got %q, want %q", got, want)
+	}
+	if fmt.Sprint(done) != "[1 3 5]" {
+		t.Errorf("Got progress %v", done)
+	}
+}
@@ -0,0 +1,221 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// XLSXDumper dumps the values as the single worksheet of an Office Open XML
+// spreadsheet as used by Excel.
+//
+// Bools, Ints and Floats are stored as native cells, Times as date cells
+// (in format.TimeLoc if set) and Durations as time cells. Strings and
+// Complex values are stored as text cells formated by the format. NA
+// values, NaN and infinite floats yield empty cells.
+type XLSXDumper struct {
+	Writer     io.Writer // Writer is the writer to output the data.
+	OmitHeader bool      // OmitHeader suppresses the row of column names.
+	BoldHeader bool      // BoldHeader uses a bold font for column names.
+
+	// Sheet is the name of the worksheet. It defaults to "Sheet1".
+	Sheet string
+}
+
+// Cell styles defined in xlsxStyles.
+const (
+	xlsxBold     = 1
+	xlsxDate     = 2
+	xlsxDuration = 3
+)
+
+// xlsxEpoch is the origin of date serial numbers in the 1900 date system.
+var xlsxEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// Dump implements the Dump method of a Dumper.
+func (d XLSXDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	sheet := d.Sheet
+	if sheet == "" {
+		sheet = "Sheet1"
+	}
+	z := zip.NewWriter(d.Writer)
+	files := []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", strings.Replace(xlsxWorkbook, "{{sheet}}", xmlEscape(sheet), 1)},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, f := range files {
+		w, err := z.Create(f.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, xmlHeader+f.content); err != nil {
+			return err
+		}
+	}
+
+	zw, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(zw)
+	w.WriteString(xmlHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	row := 1
+	if !d.OmitHeader {
+		style := 0
+		if d.BoldHeader {
+			style = xlsxBold
+		}
+		w.WriteString(`<row r="1">`)
+		for c, field := range e.Columns {
+			w.WriteString(xlsxCell(c, row, style, "inlineStr", field.Name))
+		}
+		w.WriteString("</row>")
+		row++
+	}
+	for r := 0; r < e.N; r++ {
+		w.WriteString(`<row r="` + strconv.Itoa(row) + `">`)
+		for c, field := range e.Columns {
+			var cell string
+			switch v := field.value(r).(type) {
+			case bool:
+				b := "0"
+				if v {
+					b = "1"
+				}
+				cell = xlsxCell(c, row, 0, "b", b)
+			case int64:
+				cell = xlsxCell(c, row, 0, "", strconv.FormatInt(v, 10))
+			case uint64:
+				cell = xlsxCell(c, row, 0, "", strconv.FormatUint(v, 10))
+			case float64:
+				if !math.IsNaN(v) && !math.IsInf(v, 0) {
+					cell = xlsxCell(c, row, 0, "", strconv.FormatFloat(v, 'g', -1, 64))
+				}
+			case time.Time:
+				cell = xlsxCell(c, row, xlsxDate, "", xlsxSerial(v, format.TimeLoc))
+			case time.Duration:
+				days := v.Hours() / 24
+				cell = xlsxCell(c, row, xlsxDuration, "", strconv.FormatFloat(days, 'g', -1, 64))
+			case string:
+				cell = xlsxCell(c, row, 0, "inlineStr", format.String(v))
+			case complex128:
+				cell = xlsxCell(c, row, 0, "inlineStr", format.Complex(v))
+			case []byte:
+				cell = xlsxCell(c, row, 0, "inlineStr", format.Bytes(v))
+			}
+			w.WriteString(cell)
+		}
+		w.WriteString("</row>")
+		row++
+		e.progress(r, r+1)
+	}
+	w.WriteString("</sheetData></worksheet>")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+// xlsxCell returns the cell at column c and row r with the given style
+// and type t. Values of type inlineStr are escaped.
+func xlsxCell(c, r, style int, t, value string) string {
+	cell := `<c r="` + xlsxColumn(c) + strconv.Itoa(r) + `"`
+	if style != 0 {
+		cell += ` s="` + strconv.Itoa(style) + `"`
+	}
+	if t == "inlineStr" {
+		return cell + ` t="inlineStr"><is><t xml:space="preserve">` + xmlEscape(value) + `</t></is></c>`
+	}
+	if t != "" {
+		cell += ` t="` + t + `"`
+	}
+	return cell + "><v>" + value + "</v></c>"
+}
+
+// xlsxColumn returns the letters naming the c'th column, e.g. "A", "AB".
+func xlsxColumn(c int) string {
+	name := ""
+	for c++; c > 0; c = (c - 1) / 26 {
+		name = string(rune('A'+(c-1)%26)) + name
+	}
+	return name
+}
+
+// xlsxSerial returns the date serial number of the wall clock of t in loc.
+func xlsxSerial(t time.Time, loc *time.Location) string {
+	if loc != nil {
+		t = t.In(loc)
+	}
+	y, m, d := t.Date()
+	wall := time.Date(y, m, d, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := float64(wall.Unix()-xlsxEpoch.Unix())/86400 + float64(wall.Nanosecond())/86400e9
+	return strconv.FormatFloat(days, 'f', -1, 64)
+}
+
+// xmlEscape escapes s for use in XML text and attribute values.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="{{sheet}}" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cell styles normal, bold, date and duration.
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="2"><numFmt numFmtId="164" formatCode="yyyy\-mm\-dd\ hh:mm:ss"/>` +
+	`<numFmt numFmtId="165" formatCode="[h]:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font>` +
+	`<font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func init() {
+	RegisterDumper("xlsx", func(w io.Writer) Dumper {
+		return XLSXDumper{Writer: w, BoldHeader: true}
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readZip returns the content of all files in the zip archive data.
+func readZip(t *testing.T, data []byte) map[string]string {
+	z, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	files := map[string]string{}
+	for _, f := range z.File {
+		r, err := f.Open()
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		content, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		r.Close()
+		// All parts must be well-formed XML.
+		dec := xml.NewDecoder(bytes.NewReader(content))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("File %s: %s", f.Name, err)
+			}
+		}
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+func TestXLSXDumper(t *testing.T) {
+	data := []struct {
+		B bool
+		I *int
+		F float64
+		S string
+		T time.Time
+		D time.Duration
+	}{
+		{true, new(int), 1.5, "a<b", time.Date(2000, 1, 2, 18, 0, 0, 0, time.UTC), 36 * time.Hour},
+		{false, nil, math.NaN(), "", time.Date(1899, 12, 31, 0, 0, 0, 0, time.UTC), 0},
+	}
+	extractor, err := NewExtractor(data, "B", "I", "F", "S", "T", "D")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	buf := &bytes.Buffer{}
+	d := XLSXDumper{Writer: buf, BoldHeader: true, Sheet: "Q&A"}
+	if err := d.Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	files := readZip(t, buf.Bytes())
+	if !strings.Contains(files["xl/workbook.xml"], `name="Q&amp;A"`) {
+		t.Errorf("Missing sheet name in %s", files["xl/workbook.xml"])
+	}
+	sheet := files["xl/worksheets/sheet1.xml"]
+	for _, want := range []string{
+		`<row r="1"><c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">B</t></is></c>`,
+		`<c r="F1" s="1" t="inlineStr"><is><t xml:space="preserve">D</t></is></c></row>`,
+		`<c r="A2" t="b"><v>1</v></c><c r="B2"><v>0</v></c><c r="C2"><v>1.5</v></c>`,
+		`<c r="D2" t="inlineStr"><is><t xml:space="preserve">a&lt;b</t></is></c>`,
+		`<c r="E2" s="2"><v>36527.75</v></c><c r="F2" s="3"><v>1.5</v></c></row>`,
+		`<row r="3"><c r="A3" t="b"><v>0</v></c><c r="D3" t="inlineStr">`,
+		`<c r="E3" s="2"><v>1</v></c><c r="F3" s="3"><v>0</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, want) {
+			t.Errorf("Missing %s in\n%s", want, sheet)
+		}
+	}
+
+	buf.Reset()
+	XLSXDumper{Writer: buf, OmitHeader: true}.Dump(extractor, DefaultFormat)
+	files = readZip(t, buf.Bytes())
+	if !strings.Contains(files["xl/workbook.xml"], `name="Sheet1"`) {
+		t.Errorf("Missing default sheet name in %s", files["xl/workbook.xml"])
+	}
+	if sheet := files["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, `<sheetData><row r="1"><c r="A1" t="b"><v>1</v></c>`) {
+		t.Errorf("Unexpected header in %s", sheet)
+	}
+}
+
+func TestXLSXColumn(t *testing.T) {
+	for c, want := range map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"} {
+		if got := xlsxColumn(c); got != want {
+			t.Errorf("Column %d: got %s, want %s", c, got, want)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"math/cmplx"
+	"strings"
+	"time"
+)
+
+// YAMLDumper dumps the values as a YAML sequence of mappings, one mapping
+// per row with the column names as keys.
+type YAMLDumper struct {
+	Writer io.Writer // Writer is the writer to output the data.
+}
+
+// Dump implements the Dump method of a Dumper.
+// Bools, numbers, NaN, infinities and NA values are output as YAML plain
+// scalars, everything else as strings formatted according to format.
+// Strings are double quoted unless they are unambiguous as plain scalars.
+func (d YAMLDumper) Dump(e *Extractor, format Format) error {
+	e, err := e.prepare()
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(d.Writer)
+	if e.N == 0 {
+		w.WriteString("[]\n")
+	}
+	f := yamlFormat{format}
+	for r := 0; r < e.N; r++ {
+		if len(e.Columns) == 0 {
+			w.WriteString("- {}\n")
+		}
+		for i, field := range e.Columns {
+			indent := "  "
+			if i == 0 {
+				indent = "- "
+			}
+			w.WriteString(indent + yamlString(field.Name) + ": " + field.Print(f, r) + "\n")
+		}
+		e.progress(r, r+1)
+	}
+	return w.Flush()
+}
+
+// yamlString returns s as a plain scalar if this is unambiguous and as a
+// double quoted scalar otherwise.
+func yamlString(s string) string {
+	if s == "" || strings.HasSuffix(s, " ") {
+		return jsonQuote(s)
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_':
+		case i > 0 && (r >= '0' && r <= '9' || strings.ContainsRune(" ./-", r)):
+		default:
+			return jsonQuote(s)
+		}
+	}
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "y", "n", "null":
+		return jsonQuote(s)
+	}
+	return s
+}
+
+// yamlNumber returns s if it is a valid number and s as a string otherwise.
+// Exponents are written like "1.0e+5" as YAML 1.1 requires a decimal point
+// and a signed exponent.
+func yamlNumber(s string) string {
+	if s == "" || (s[0] != '-' && (s[0] < '0' || s[0] > '9')) || !json.Valid([]byte(s)) {
+		return yamlString(s)
+	}
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		mantissa, exp := s[:i], s[i+1:]
+		if !strings.Contains(mantissa, ".") {
+			mantissa += ".0"
+		}
+		if exp[0] != '+' && exp[0] != '-' {
+			exp = "+" + exp
+		}
+		s = mantissa + s[i:i+1] + exp
+	}
+	return s
+}
+
+// yamlFormat is a Formater producing YAML scalars based on Format.
+type yamlFormat struct {
+	Format
+}
+
+func (f yamlFormat) Bool(b bool) string {
+	if b {
+		return "true"
+	}
+	return "false"
+}
+func (f yamlFormat) Int(i int64) string {
+	return yamlNumber(f.Format.Int(i))
+}
+func (f yamlFormat) Uint(u uint64) string {
+	return yamlNumber(f.Format.Uint(u))
+}
+func (f yamlFormat) Float(x float64) string {
+	switch {
+	case math.IsNaN(x):
+		return ".nan"
+	case math.IsInf(x, 1):
+		return ".inf"
+	case math.IsInf(x, -1):
+		return "-.inf"
+	}
+	return yamlNumber(f.Format.Float(x))
+}
+func (f yamlFormat) Complex(c complex128) string {
+	if cmplx.IsNaN(c) {
+		return ".nan"
+	}
+	return yamlString(f.Format.Complex(c))
+}
+func (f yamlFormat) String(s string) string {
+	return yamlString(f.truncate(s))
+}
+func (f yamlFormat) Bytes(b []byte) string {
+	return yamlString(f.truncate(f.encode(b)))
+}
+func (f yamlFormat) Time(t time.Time) string {
+	if f.TimeUnit > 0 {
+		return f.Format.Time(t)
+	}
+	return yamlString(f.Format.Time(t))
+}
+func (f yamlFormat) Duration(d time.Duration) string {
+	return yamlNumber(f.Format.Duration(d))
+}
+func (f yamlFormat) NA() string {
+	return "null"
+}
+
+func init() {
+	RegisterDumper("yaml", func(w io.Writer) Dumper {
+		return YAMLDumper{Writer: w}
+	})
+}
@@ -0,0 +1,92 @@
+// Copyright 2014 Volker Dobler. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package export
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestYAMLDumper(t *testing.T) {
+	extractor, err := NewExtractor(table, "B", "I", "F", "S", "T", "D", "C", "SME()")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	format := DefaultFormat
+	format.TimeLoc = time.UTC
+	format.DurationFmt = "%d"
+	extractor.Bind(table[2:4])
+	buf := &bytes.Buffer{}
+	if err := (YAMLDumper{Writer: buf}).Dump(extractor, format); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	want := `- B: false
+  I: 14
+  F: .nan
+  S: Go
+  T: "2000-01-02T15:20:30"
+  D: 0
+  C: "(0+0i)"
+  SME: null
+- B: false
+  I: 16
+  F: 6.022e+23
+  S: A Lot
+  T: "2009-12-28T09:45:00"
+  D: 30000000000000
+  C: "+∞"
+  SME: null
+`
+	if got := buf.String(); got != want {
+		t.Errorf("Got:\n%s\nWant:\n%s", got, want)
+	}
+
+	buf.Reset()
+	extractor.Bind(table[:0])
+	YAMLDumper{Writer: buf}.Dump(extractor, format)
+	if got := buf.String(); got != "[]\n" {
+		t.Errorf("Got %q for empty data", got)
+	}
+}
+
+func TestYAMLNumber(t *testing.T) {
+	for s, want := range map[string]string{
+		"12":        "12",
+		"-0.5":      "-0.5",
+		"1e5":       "1.0e+5",
+		"1e+05":     "1.0e+05",
+		"2.5E-3":    "2.5E-3",
+		"-3E10":     "-3.0E+10",
+		"6.022e+23": "6.022e+23",
+		"1,5":       `"1,5"`,
+		"NaN":       "NaN",
+	} {
+		if got := yamlNumber(s); got != want {
+			t.Errorf("yamlNumber(%q) = %s, want %s", s, got, want)
+		}
+	}
+}
+
+func TestYAMLString(t *testing.T) {
+	for s, want := range map[string]string{
+		"Hello World": "Hello World",
+		"a-b/c.d_e":   "a-b/c.d_e",
+		"":            `""`,
+		"yes":         `"yes"`,
+		"Null":        `"Null"`,
+		"12":          `"12"`,
+		"-x":          `"-x"`,
+		"a: b":        `"a: b"`,
+		"trailing ":   `"trailing "`,
+		"x\ny":        `"x\ny"`,
+		"#c":          `"#c"`,
+	} {
+		if got := yamlString(s); got != want {
+			t.Errorf("yamlString(%q) = %s, want %s", s, got, want)
+		}
+	}
+}